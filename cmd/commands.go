@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/wikasdude/blog-backend/config"
+	service "github.com/wikasdude/blog-backend/services"
+)
+
+// runCommand executes a one-off maintenance subcommand instead of starting
+// the HTTP server, e.g. `blog-backend backfill-post-metadata -batch 500`.
+func runCommand(args []string, postService *service.PostService, exportService *service.ExportService) error {
+	switch args[0] {
+	case "backfill-post-metadata":
+		fs := flag.NewFlagSet(args[0], flag.ExitOnError)
+		batch := fs.Int("batch", 200, "posts to process per batch")
+		fs.Parse(args[1:])
+
+		updated, err := postService.BackfillDerivedFields(*batch)
+		log.Printf("Backfilled derived metadata for %d posts", updated)
+		return err
+	case "purge-trash":
+		fs := flag.NewFlagSet(args[0], flag.ExitOnError)
+		olderThan := fs.Duration("older-than", config.DefaultTrashRetention, "purge posts trashed longer ago than this")
+		all := fs.Bool("all", false, "empty the trash, however recently posts were trashed")
+		fs.Parse(args[1:])
+
+		if *all {
+			*olderThan = 0
+		} else if *olderThan <= 0 {
+			return fmt.Errorf("-older-than must be positive; use -all to empty the trash")
+		}
+		purged, err := postService.PurgeExpiredTrash(*olderThan)
+		log.Printf("Purged %d trashed posts", purged)
+		return err
+	case "export":
+		fs := flag.NewFlagSet(args[0], flag.ExitOnError)
+		out := fs.String("o", "blog-export.zip", "file to write the ZIP to")
+		author := fs.Int("author", 0, "only export posts by this user id")
+		category := fs.Int("category", 0, "only export posts in this category id")
+		from := fs.String("from", "", "only export posts created on or after this date (YYYY-MM-DD or RFC 3339)")
+		to := fs.String("to", "", "only export posts created on or before this date (YYYY-MM-DD or RFC 3339)")
+		fs.Parse(args[1:])
+
+		filter, err := service.NewExportFilter(*author, *category, *from, *to)
+		if err != nil {
+			return err
+		}
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		manifest, err := exportService.WriteExport(f, filter)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(*out)
+			return err
+		}
+		c := manifest.Counts
+		log.Printf("Exported %d posts, %d categories, %d tags, %d authors and %d media files to %s",
+			c.Posts, c.Categories, c.Tags, c.Authors, c.Media, *out)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
@@ -21,13 +21,17 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 
 	"github.com/joho/godotenv"
 
 	"github.com/wikasdude/blog-backend/config"
 	controller "github.com/wikasdude/blog-backend/controllers"
+	"github.com/wikasdude/blog-backend/events"
 	repository "github.com/wikasdude/blog-backend/repositories"
 	service "github.com/wikasdude/blog-backend/services"
+	"github.com/wikasdude/blog-backend/storage"
+	"github.com/wikasdude/blog-backend/theme"
 
 	httpSwagger "github.com/swaggo/http-swagger"
 	_ "github.com/wikasdude/blog-backend/docs"
@@ -42,23 +46,127 @@ func main() {
 	db, err := config.ConnectDB()
 	fmt.Println(err)
 	defer db.Close()
+	bus := events.NewBus()
+
 	userRepo := repository.NewUserRepository(db)
-	userService := service.NewUserService(userRepo)
+	userService := service.NewUserService(userRepo, bus)
 	userController := controller.NewUserController(userService)
 
 	postRepo := repository.NewPostRepository(db)
-	postService := service.NewPostService(postRepo)
+	postService := service.NewPostService(postRepo, bus)
 	postController := controller.NewPostController(postService)
 
 	categoryRepo := repository.NewCategoryRepository(db)
 	categoryService := service.NewCategoryService(categoryRepo)
 	categoryController := controller.NewCategoryController(categoryService)
 
-	router.InitRoutes(userController, postController, categoryController)
+	commentRepo := repository.NewCommentRepository(db)
+	moderationService, err := service.NewModerationService(commentRepo, postRepo, repository.NewSpamRepository(db), bus)
+	if err != nil {
+		log.Fatal("failed to load spam filter: ", err)
+	}
+	commentService := service.NewCommentService(commentRepo, postRepo, moderationService, bus)
+	commentController := controller.NewCommentController(commentService, moderationService)
+
+	reactionService := service.NewReactionService(repository.NewReactionRepository(db), postRepo, bus)
+	reactionController := controller.NewReactionController(reactionService)
+
+	readingListService := service.NewReadingListService(repository.NewBookmarkRepository(db), repository.NewReadingListRepository(db), postRepo)
+	readingListController := controller.NewReadingListController(readingListService)
+
+	followService := service.NewFollowService(repository.NewFollowRepository(db), postRepo, categoryRepo, postService, bus)
+	followController := controller.NewFollowController(followService)
+
+	notificationService := service.NewNotificationService(repository.NewNotificationRepository(db), postRepo, commentRepo, bus)
+	notificationController := controller.NewNotificationController(notificationService)
+
+	streamService := service.NewStreamService(repository.NewStreamRepository(db), postRepo, bus)
+	streamController := controller.NewStreamController(streamService)
+
+	webhookService := service.NewWebhookService(repository.NewWebhookRepository(db), postRepo, commentRepo, userRepo, bus)
+	webhookController := controller.NewWebhookController(webhookService)
+
+	feedController := controller.NewFeedController(service.NewFeedService(postRepo, categoryRepo))
+	sitemapController := controller.NewSitemapController(service.NewSitemapService(repository.NewSitemapRepository(db), bus))
+	seoService := service.NewSEOService(postRepo, categoryRepo)
+	seoController := controller.NewSEOController(seoService)
+
+	mediaStore, err := mediaStorage()
+	if err != nil {
+		log.Fatal("failed to set up media storage: ", err)
+	}
+	mediaRepo := repository.NewMediaRepository(db)
+	mediaService := service.NewMediaService(mediaRepo, mediaStore, config.GetEnv(config.EnvMediaSigningKey, ""))
+	mediaController := controller.NewMediaController(mediaService)
+
+	importRepo := repository.NewImportRepository(db)
+	importService := service.NewImportService(importRepo, postRepo, categoryRepo, userRepo)
+	importController := controller.NewImportController(importService)
+
+	exportService := service.NewExportService(repository.NewExportRepository(db), importRepo, postRepo, categoryRepo, userRepo, mediaRepo, mediaStore)
+	exportController := controller.NewExportController(exportService)
+
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:], postService, exportService); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// the public site is optional; without it, routes get a nil controller
+	var siteController *controller.SiteController
+	if config.GetEnvBool(config.EnvSiteEnabled, false) {
+		dev := config.GetEnvBool(config.EnvSiteDevMode, false)
+		siteTheme, err := theme.Load(config.GetEnv(config.EnvSiteThemeDir, ""), dev)
+		if err != nil {
+			log.Fatal("failed to load the site theme: ", err)
+		}
+		siteController = controller.NewSiteController(service.NewSiteService(postService, postRepo, categoryRepo, seoService), siteTheme, dev)
+	}
+
+	go postService.RunTrashPurger(
+		config.GetEnvDuration(config.EnvTrashRetention, config.DefaultTrashRetention),
+		config.GetEnvDuration(config.EnvTrashPurgeInterval, config.DefaultTrashPurgeInterval),
+		nil,
+	)
+
+	go streamService.Run(
+		config.GetEnv(config.EnvDatabaseURL, ""),
+		config.GetEnvDuration(config.EnvStreamRetention, config.DefaultStreamRetention),
+		nil,
+	)
+
+	go mediaService.RunProcessor(config.GetEnvInt(config.EnvMediaWorkers, config.DefaultMediaWorkers), nil)
+	go importService.RunImporter(nil)
+	go exportService.RunExporter(nil)
+
+	go webhookService.RunDispatcher(config.GetEnvDuration(config.EnvWebhookPollInterval, config.DefaultWebhookPollInterval), nil)
+
+	router.InitRoutes(userController, postController, categoryController, commentController, reactionController, readingListController, followController, notificationController, streamController, webhookController, feedController, sitemapController, mediaController, seoController, importController, exportController, siteController)
 	log.Println("Server running on :8080")
 	http.Handle("/swagger/", enableCORS(httpSwagger.WrapHandler))
 	http.ListenAndServe(":8080", enableCORS(http.DefaultServeMux))
 }
+
+// mediaStorage sets up the storage backend MEDIA_STORAGE names.
+func mediaStorage() (storage.Storage, error) {
+	switch backend := config.GetEnv(config.EnvMediaStorage, config.DefaultMediaStorage); backend {
+	case "local":
+		return storage.NewLocal(config.GetEnv(config.EnvMediaLocalDir, config.DefaultMediaLocalDir))
+	case "s3":
+		return storage.NewS3(
+			config.GetEnv(config.EnvS3Endpoint, ""),
+			config.GetEnv(config.EnvS3Region, ""),
+			config.GetEnv(config.EnvS3Bucket, ""),
+			config.GetEnv(config.EnvS3AccessKey, ""),
+			config.GetEnv(config.EnvS3SecretKey, ""),
+			config.GetEnvBool(config.EnvS3PathStyle, true),
+		)
+	default:
+		return nil, fmt.Errorf("unknown %s %q", config.EnvMediaStorage, backend)
+	}
+}
+
 func enableCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
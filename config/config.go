@@ -81,7 +81,7 @@ func ConnectDB() (*sql.DB, error) {
 	// if err != nil {
 	// 	log.Fatalf("Failed to connect to DB: %v", err)
 	// }
-	databaseURL := os.Getenv("DATABASE_URL")
+	databaseURL := os.Getenv(EnvDatabaseURL)
 	if databaseURL == "" {
 		log.Fatal("DATABASE_URL is not set")
 	}
@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 // API messages
 const (
 	MessagePostCreated   = "Post created successfully"
@@ -12,18 +14,305 @@ const (
 	MessageInvalidURL    = "Invalid URL"
 	MessageInvalidMethod = "Method not allowed"
 	MessageInternalError = "Internal server error"
+	MessageVersionStale  = "Post was modified since you last fetched it"
+	MessageIfMatchNeeded = "If-Match header with the post's ETag is required"
+	MessagePostTrashed   = "Post moved to trash"
+	MessagePostRestored  = "Post restored successfully"
+	MessagePostPurged    = "Post permanently deleted"
+	MessageTrashFetched  = "Trash fetched successfully"
+	MessageCommentAdded  = "Comment added successfully"
+	MessageCommentEdited = "Comment updated successfully"
+	MessageCommentGone   = "Comment deleted successfully"
+	MessageComments      = "Comments fetched successfully"
+	MessageCommentHeld   = "Comment is awaiting moderation"
+	MessageModeration    = "Moderation queue fetched successfully"
+	MessageModerated     = "Comment moderated successfully"
+	MessageReacted       = "Reaction saved"
+	MessageUnreacted     = "Reaction removed"
+	MessageReactions     = "Reactions fetched successfully"
+	MessageBookmarked    = "Post bookmarked"
+	MessageUnbookmarked  = "Bookmark removed"
+	MessageBookmarks     = "Bookmarks fetched successfully"
+	MessageListCreated   = "Reading list created successfully"
+	MessageListUpdated   = "Reading list updated successfully"
+	MessageListDeleted   = "Reading list deleted successfully"
+	MessageListFetched   = "Reading list fetched successfully"
+	MessageListsFetched  = "Reading lists fetched successfully"
+	MessageFollowed      = "Followed successfully"
+	MessageUnfollowed    = "Unfollowed successfully"
+	MessageFollows       = "Follows fetched successfully"
+	MessageFeed          = "Feed fetched successfully"
+	MessageAuthor        = "Author fetched successfully"
+	MessageNotifications = "Notifications fetched successfully"
+	MessageMarkedRead    = "Notifications marked as read"
+	MessagePreferences   = "Notification preferences fetched successfully"
+	MessagePrefsUpdated  = "Notification preferences updated successfully"
+	MessageWebhookAdded  = "Webhook created successfully"
+	MessageWebhookSaved  = "Webhook updated successfully"
+	MessageWebhookGone   = "Webhook deleted successfully"
+	MessageWebhook       = "Webhook fetched successfully"
+	MessageWebhooks      = "Webhooks fetched successfully"
+	MessageDeliveries    = "Webhook deliveries fetched successfully"
+	MessageReplayQueued  = "Webhook delivery queued for replay"
+	MessageAdminOnly     = "Only admins can do this"
+	MessageFeedNotFound  = "Feed not found"
+	MessageNoSitemap     = "Sitemap not found"
+	MessageMediaUploaded = "Media uploaded successfully"
+	MessageMediaDeleted  = "Media deleted successfully"
+	MessageMediaFetched  = "Media fetched successfully"
+	MessageMediaURL      = "Signed URL created successfully"
+	MessageNoFile        = "A file is required in the \"file\" form field"
+	MessagePostMeta      = "Post metadata fetched successfully"
+	MessageImportQueued  = "Import queued"
+	MessageImport        = "Import fetched successfully"
+	MessageImports       = "Imports fetched successfully"
+	MessageExportQueued  = "Export queued"
+	MessageExport        = "Export fetched successfully"
+	MessageExports       = "Exports fetched successfully"
+	MessageExportDeleted = "Export deleted successfully"
 )
 
 // Default pagination and sorting
 const (
 	DefaultPage  = 1
 	DefaultLimit = 10
+	MaxLimit     = 100
 	DefaultSort  = "created_at"
 	DefaultOrder = "desc"
 )
 
+// Derived post metadata
+const (
+	ExcerptWords   = 40
+	WordsPerMinute = 200
+)
+
+// Comments
+const (
+	DefaultCommentSort  = "oldest"
+	DefaultCommentDepth = 5
+	MaxCommentLength    = 10000
+	DefaultCommentLinks = 2
+)
+
+// MaxReadingListName is the longest reading list name allowed, in characters
+const MaxReadingListName = 100
+
+// NotificationActors is how many of the latest actors a grouped notification names
+const NotificationActors = 3
+
+// DefaultReactions is the reaction set used when REACTIONS is not set
+const DefaultReactions = "like:👍,love:❤️,laugh:😂,celebrate:🎉,wow:😮,sad:😢"
+
+// Environment variables
+const (
+	// EnvRequireIfMatch makes post updates and deletes without If-Match fail with 428
+	EnvRequireIfMatch = "REQUIRE_IF_MATCH"
+	// EnvTrashRetention is how long trashed posts are kept, e.g. "720h"; 0 keeps them forever
+	EnvTrashRetention = "TRASH_RETENTION"
+	// EnvTrashPurgeInterval is how often expired trash is purged
+	EnvTrashPurgeInterval = "TRASH_PURGE_INTERVAL"
+	// EnvCommentMaxDepth is how deeply replies may nest; top-level comments are depth 0
+	EnvCommentMaxDepth = "COMMENT_MAX_DEPTH"
+	// EnvCommentPremoderateFirst holds a user's comments for moderation until one has been approved
+	EnvCommentPremoderateFirst = "COMMENT_PREMODERATE_FIRST"
+	// EnvCommentBlockedWords is a comma separated list of words or phrases that mark a comment as spam
+	EnvCommentBlockedWords = "COMMENT_BLOCKED_WORDS"
+	// EnvCommentMaxLinks holds comments with more links than this for moderation; -1 disables the check
+	EnvCommentMaxLinks = "COMMENT_MAX_LINKS"
+	// EnvReactions configures the reactions readers can leave as name:emoji pairs, e.g. "love:❤️,wow:😮"
+	EnvReactions = "REACTIONS"
+	// EnvDatabaseURL is the Postgres connection string
+	EnvDatabaseURL = "DATABASE_URL"
+	// EnvStreamHeartbeat is how often idle event streams get a keepalive comment
+	EnvStreamHeartbeat = "STREAM_HEARTBEAT"
+	// EnvStreamRetention is how long stream events are kept for Last-Event-ID resume
+	EnvStreamRetention = "STREAM_RETENTION"
+	// EnvWebhookMaxAttempts is how many times a webhook delivery is tried before it is marked failed
+	EnvWebhookMaxAttempts = "WEBHOOK_MAX_ATTEMPTS"
+	// EnvWebhookDisableAfter disables a webhook after this many failed attempts in a row
+	EnvWebhookDisableAfter = "WEBHOOK_DISABLE_AFTER"
+	// EnvWebhookPollInterval is how often the dispatcher looks for deliveries that are due
+	EnvWebhookPollInterval = "WEBHOOK_POLL_INTERVAL"
+	// EnvSiteURL is the public address of the site, e.g. "https://blog.example.com"; links in feeds
+	// point there. Unset, it is taken from the request, so feed ids change with the Host header
+	EnvSiteURL = "SITE_URL"
+	// EnvSiteTitle names the site in feeds
+	EnvSiteTitle = "SITE_TITLE"
+	// EnvSiteDescription describes the site in feeds
+	EnvSiteDescription = "SITE_DESCRIPTION"
+	// EnvSiteEnabled serves the public site, rendered from a theme, next to the API
+	EnvSiteEnabled = "SITE_ENABLED"
+	// EnvPublicPostPath, EnvPublicCategoryPath, EnvPublicTagPath and EnvPublicAuthorPath are where a
+	// separate front end serves posts, categories, tags and authors, e.g. "/blog/{ref}"; "{ref}" is
+	// replaced by the ID or slug. Feeds, the sitemap and SEO metadata link there. They are ignored
+	// when SITE_ENABLED is set, as the built-in site serves the default paths
+	EnvPublicPostPath     = "PUBLIC_POST_PATH"
+	EnvPublicCategoryPath = "PUBLIC_CATEGORY_PATH"
+	EnvPublicTagPath      = "PUBLIC_TAG_PATH"
+	EnvPublicAuthorPath   = "PUBLIC_AUTHOR_PATH"
+	// EnvSiteThemeDir is the directory of the theme the public site is rendered with; unset, the built-in theme is used
+	EnvSiteThemeDir = "SITE_THEME_DIR"
+	// EnvSiteDevMode parses the theme's templates again whenever they change and shows errors on error pages
+	EnvSiteDevMode = "SITE_DEV_MODE"
+	// EnvSitePageSize is how many posts the public site lists per page
+	EnvSitePageSize = "SITE_PAGE_SIZE"
+	// EnvTwitterSite is the site's Twitter handle, e.g. "@example", for Twitter Card tags
+	EnvTwitterSite = "TWITTER_SITE"
+	// EnvFeedContent is "full" to put whole posts in feeds or "excerpt" for summaries only
+	EnvFeedContent = "FEED_CONTENT"
+	// EnvSitemapTTL is how long the sitemap is cached when no post changes; post events rebuild it sooner
+	EnvSitemapTTL = "SITEMAP_TTL"
+	// EnvRobotsAllow is a comma separated list of paths robots.txt allows
+	EnvRobotsAllow = "ROBOTS_ALLOW"
+	// EnvRobotsDisallow is a comma separated list of paths robots.txt disallows
+	EnvRobotsDisallow = "ROBOTS_DISALLOW"
+	// EnvRobotsBlockAll makes robots.txt disallow everything, e.g. on staging
+	EnvRobotsBlockAll = "ROBOTS_BLOCK_ALL"
+	// EnvMediaStorage picks where uploads are kept: "local" or "s3"
+	EnvMediaStorage = "MEDIA_STORAGE"
+	// EnvMediaLocalDir is the directory local storage keeps uploads in
+	EnvMediaLocalDir = "MEDIA_LOCAL_DIR"
+	// EnvMediaMaxBytes is the largest upload accepted, in bytes
+	EnvMediaMaxBytes = "MEDIA_MAX_BYTES"
+	// EnvMediaAllowedTypes is a comma separated list of MIME types that may be uploaded
+	EnvMediaAllowedTypes = "MEDIA_ALLOWED_TYPES"
+	// EnvMediaSigningKey signs URLs for private media; a random key is used when unset
+	EnvMediaSigningKey = "MEDIA_SIGNING_KEY"
+	// EnvMediaMaxPixels refuses images with more pixels than this, which would take too much memory to process
+	EnvMediaMaxPixels = "MEDIA_MAX_PIXELS"
+	// EnvMediaVariantWidths is a comma separated list of widths resized image variants are made at
+	EnvMediaVariantWidths = "MEDIA_VARIANT_WIDTHS"
+	// EnvMediaVariantFormats lists the formats variants are written in; "original" keeps the upload's format, or uses
+	// JPEG or PNG for formats no encoder is registered for, such as WebP
+	EnvMediaVariantFormats = "MEDIA_VARIANT_FORMATS"
+	// EnvMediaImageQuality is the quality, 1 to 100, lossy variants are encoded at
+	EnvMediaImageQuality = "MEDIA_IMAGE_QUALITY"
+	// EnvMediaWorkers is how many images are processed at once
+	EnvMediaWorkers = "MEDIA_WORKERS"
+	// EnvImportMaxBytes is the largest WXR or Markdown ZIP export accepted for import, in bytes
+	EnvImportMaxBytes = "IMPORT_MAX_BYTES"
+	// EnvS3Endpoint is the base URL of the S3-compatible service, e.g. "http://localhost:9000"
+	EnvS3Endpoint = "S3_ENDPOINT"
+	// EnvS3Region is the bucket's region
+	EnvS3Region = "S3_REGION"
+	// EnvS3Bucket is the bucket uploads are kept in
+	EnvS3Bucket = "S3_BUCKET"
+	// EnvS3AccessKey and EnvS3SecretKey are the credentials requests are signed with
+	EnvS3AccessKey = "S3_ACCESS_KEY"
+	EnvS3SecretKey = "S3_SECRET_KEY"
+	// EnvS3PathStyle addresses the bucket in the path rather than the host name
+	EnvS3PathStyle = "S3_PATH_STYLE"
+)
+
+// Trash defaults
+const (
+	DefaultTrashRetention     = 30 * 24 * time.Hour
+	DefaultTrashPurgeInterval = time.Hour
+)
+
+// Server-Sent Events stream
+const (
+	DefaultStreamHeartbeat = 15 * time.Second
+	DefaultStreamRetention = 24 * time.Hour
+	// StreamBuffer is how many events a slow subscriber may fall behind before it is dropped
+	StreamBuffer = 64
+	// StreamReplayLimit caps how many missed events one resume replays
+	StreamReplayLimit = 1000
+)
+
+// Webhooks
+const (
+	DefaultWebhookMaxAttempts  = 8
+	DefaultWebhookDisableAfter = 20
+	DefaultWebhookPollInterval = 5 * time.Second
+	// WebhookTimeout is how long a webhook endpoint has to respond
+	WebhookTimeout = 10 * time.Second
+	// WebhookBackoff is the wait before the first retry; it doubles with each attempt
+	WebhookBackoff    = 30 * time.Second
+	WebhookMaxBackoff = 6 * time.Hour
+)
+
+// Syndication feeds
+const (
+	DefaultSiteTitle   = "Blog"
+	DefaultFeedItems   = 20
+	DefaultFeedContent = "full"
+)
+
+// Sitemap and robots.txt
+const (
+	DefaultSitemapTTL     = time.Hour
+	DefaultRobotsAllow    = "/api/feeds/"
+	DefaultRobotsDisallow = "/api/,/swagger/,/users/,/login"
+)
+
+// Media library
+const (
+	DefaultMediaStorage      = "local"
+	DefaultMediaLocalDir     = "uploads"
+	DefaultMediaMaxBytes     = 10 << 20
+	DefaultMediaAllowedTypes = "image/jpeg,image/png,image/gif,image/webp,application/pdf"
+	// DefaultSignedURLTTL is how long signed media URLs work unless expires_in asks otherwise
+	DefaultSignedURLTTL = time.Hour
+	MaxSignedURLTTL     = 7 * 24 * time.Hour
+)
+
+// Image processing
+const (
+	DefaultMediaMaxPixels      = 50_000_000
+	DefaultMediaVariantWidths  = "320,640,1024,1600"
+	DefaultMediaVariantFormats = "original"
+	DefaultMediaImageQuality   = 82
+	DefaultMediaWorkers        = 2
+	// MediaProcessLease is how long a worker may take over an image before another picks it up
+	MediaProcessLease = 5 * time.Minute
+	// MediaPollInterval is how often idle workers look for images left over from a restart
+	MediaPollInterval = 30 * time.Second
+	// BlurHashX and BlurHashY are the placeholder's components; it is computed from a BlurHashSize thumbnail
+	BlurHashX    = 4
+	BlurHashY    = 3
+	BlurHashSize = 32
+)
+
+// Imports
+const (
+	DefaultImportMaxBytes = 64 << 20
+	// DefaultImportCategory holds imported posts that have no category
+	DefaultImportCategory = "Uncategorized"
+	// ImportLease is how long an import may run before another worker takes it over
+	ImportLease        = 30 * time.Minute
+	ImportPollInterval = time.Minute
+)
+
+// Exports
+const (
+	// ExportLease is how long an export may run before another worker takes it over
+	ExportLease        = 30 * time.Minute
+	ExportPollInterval = time.Minute
+)
+
+// Public site
+const (
+	DefaultSitePageSize       = 10
+	DefaultPublicPostPath     = "/posts/{ref}"
+	DefaultPublicCategoryPath = "/categories/{ref}"
+	DefaultPublicTagPath      = "/tags/{ref}"
+	DefaultPublicAuthorPath   = "/authors/{ref}"
+)
+
+// Post SEO fields
+const (
+	MaxMetaTitleLength       = 120
+	MaxMetaDescriptionLength = 320
+	MaxAltTextLength         = 250
+)
+
 // Valid sort fields
 var ValidSortFields = map[string]bool{
-	"title":      true,
-	"created_at": true,
+	"title":        true,
+	"created_at":   true,
+	"updated_at":   true,
+	"published_at": true,
+	"popularity":   true,
 }
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// GetEnvBool reads a boolean environment variable, falling back to def when
+// it is unset or malformed.
+func GetEnvBool(key string, def bool) bool {
+	v, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// GetEnvInt reads an integer environment variable, falling back to def when
+// it is unset or malformed.
+func GetEnvInt(key string, def int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// GetEnvDuration reads a duration such as "720h" from the environment,
+// falling back to def when it is unset or malformed.
+func GetEnvDuration(key string, def time.Duration) time.Duration {
+	v, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// GetEnv reads a string environment variable, falling back to def when it is unset.
+func GetEnv(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return def
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/wikasdude/blog-backend/model"
 	service "github.com/wikasdude/blog-backend/services"
@@ -19,38 +21,65 @@ func NewCategoryController(s *service.CategoryService) *CategoryController {
 	return &CategoryController{Service: s}
 }
 
+// sendCategoryError maps category service errors onto HTTP status codes.
+func sendCategoryError(w http.ResponseWriter, message string, err error) {
+	switch err {
+	case service.ErrCategoryNotFound:
+		utils.SendError(w, http.StatusNotFound, err.Error(), nil)
+	case service.ErrCategoryNameTaken, service.ErrCategorySlugTaken, service.ErrCategoryInUse:
+		utils.SendError(w, http.StatusConflict, err.Error(), nil)
+	case service.ErrCategoryInvalid, service.ErrCategoryParent, service.ErrCategoryReassign:
+		utils.SendError(w, http.StatusBadRequest, err.Error(), nil)
+	default:
+		utils.SendError(w, http.StatusInternalServerError, message, err.Error())
+	}
+}
+
 // CreateCategory godoc
 // @Summary      Create a new category
-// @Description  Takes a JSON body and creates a new category.
+// @Description  Takes a JSON body and creates a new category. The slug is derived from the name when omitted.
 // @Tags         categories
 // @Accept       json
 // @Produce      json
 // @Param        category  body      model.CategoryRequest true  "Category to create"
 // @Success      201       {object}  utils.APIResponse
 // @Failure      400       {object}  utils.APIResponse
+// @Failure      409       {object}  utils.APIResponse
 // @Failure      500       {object}  utils.APIResponse
 // @Router       /api/categories [post]
 func (c *CategoryController) CreateCategory(w http.ResponseWriter, r *http.Request) {
-	var category model.Category
-	var err error
-	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
+	var input model.CategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 		utils.SendError(w, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
-	if category.Name == "" {
-		utils.SendError(w, http.StatusBadRequest, "Category name cannot be empty", err)
+	if strings.TrimSpace(input.Name) == "" {
+		utils.SendError(w, http.StatusBadRequest, "Category name cannot be empty", nil)
 		return
 	}
 
-	err = c.Service.CreateCategory(&category)
+	category := model.Category{
+		Name:        input.Name,
+		Slug:        input.Slug,
+		Description: input.Description,
+		ParentID:    input.ParentID,
+	}
+	err := c.Service.CreateCategory(&category)
 	if err != nil {
-
-		utils.SendError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create category %s", err), err)
+		sendCategoryError(w, fmt.Sprintf("Failed to create category %s", err), err)
 		return
 	}
 	utils.SendSuccess(w, http.StatusCreated, "Category created successfully", category)
 }
 
+// GetAllCategories godoc
+// @Summary      List categories
+// @Description  Returns every category as a flat list with its published post count.
+// @Tags         categories
+// @Produce      json
+// @Success      200  {array}   model.Category
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /api/categories [get]
 func (c *CategoryController) GetAllCategories(w http.ResponseWriter, r *http.Request) {
 	categories, err := c.Service.GetAllCategories()
 	if err != nil {
@@ -59,3 +88,133 @@ func (c *CategoryController) GetAllCategories(w http.ResponseWriter, r *http.Req
 	}
 	utils.SendSuccess(w, http.StatusOK, "Categories fetched successfully", categories)
 }
+
+// GetCategoryTree godoc
+// @Summary      Category tree
+// @Description  Returns root categories with their sub-categories nested under children.
+// @Tags         categories
+// @Produce      json
+// @Success      200  {array}   model.Category
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /api/categories/tree [get]
+func (c *CategoryController) GetCategoryTree(w http.ResponseWriter, r *http.Request) {
+	tree, err := c.Service.GetCategoryTree()
+	if err != nil {
+		utils.SendError(w, http.StatusInternalServerError, "Failed to fetch category tree", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, "Category tree fetched successfully", tree)
+}
+
+// GetCategoryByID godoc
+// @Summary      Get a category
+// @Tags         categories
+// @Produce      json
+// @Param        id   path      int  true  "Category ID"
+// @Success      200  {object}  model.Category
+// @Failure      404  {object}  utils.APIResponse
+// @Router       /api/categories/{id} [get]
+func (c *CategoryController) GetCategoryByID(w http.ResponseWriter, r *http.Request, id int) {
+	category, err := c.Service.GetCategoryByID(id)
+	if err != nil {
+		sendCategoryError(w, "Failed to fetch category", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, "Category fetched successfully", category)
+}
+
+// UpdateCategory godoc
+// @Summary      Update a category
+// @Description  Replaces the name, slug, description and parent of a category. Admin only.
+// @Tags         categories
+// @Accept       json
+// @Produce      json
+// @Param        id        path      int                    true  "Category ID"
+// @Param        category  body      model.CategoryRequest  true  "Category fields"
+// @Success      200       {object}  model.Category
+// @Failure      400       {object}  utils.APIResponse
+// @Failure      403       {object}  utils.APIResponse
+// @Failure      404       {object}  utils.APIResponse
+// @Failure      409       {object}  utils.APIResponse
+// @Security     BearerAuth
+// @Router       /api/categories/{id} [put]
+func (c *CategoryController) UpdateCategory(w http.ResponseWriter, r *http.Request, id int) {
+	tokenString := r.Header.Get("Authorization")
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(tokenString, "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if claims.Role != "admin" {
+		utils.SendError(w, http.StatusForbidden, "Only admins can update categories", nil)
+		return
+	}
+
+	var input model.CategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.SendError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	category := model.Category{
+		ID:          id,
+		Name:        input.Name,
+		Slug:        input.Slug,
+		Description: input.Description,
+		ParentID:    input.ParentID,
+	}
+	if err := c.Service.UpdateCategory(&category); err != nil {
+		sendCategoryError(w, "Failed to update category", err)
+		return
+	}
+
+	updated, err := c.Service.GetCategoryByID(id)
+	if err != nil {
+		sendCategoryError(w, "Failed to fetch category", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, "Category updated successfully", updated)
+}
+
+// DeleteCategory godoc
+// @Summary      Delete a category
+// @Description  Deletes a category. If posts still reference it the request is rejected with 409 unless reassign_to names a category to move them into. Sub-categories move up to the deleted category's parent. Admin only.
+// @Tags         categories
+// @Produce      json
+// @Param        id           path      int  true   "Category ID"
+// @Param        reassign_to  query     int  false  "Category that receives the deleted category's posts"
+// @Success      200          {object}  utils.APIResponse
+// @Failure      400          {object}  utils.APIResponse
+// @Failure      403          {object}  utils.APIResponse
+// @Failure      404          {object}  utils.APIResponse
+// @Failure      409          {object}  utils.APIResponse
+// @Security     BearerAuth
+// @Router       /api/categories/{id} [delete]
+func (c *CategoryController) DeleteCategory(w http.ResponseWriter, r *http.Request, id int) {
+	tokenString := r.Header.Get("Authorization")
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(tokenString, "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if claims.Role != "admin" {
+		utils.SendError(w, http.StatusForbidden, "Only admins can delete categories", nil)
+		return
+	}
+
+	var reassignTo *int
+	if v := r.URL.Query().Get("reassign_to"); v != "" {
+		target, err := strconv.Atoi(v)
+		if err != nil {
+			utils.SendError(w, http.StatusBadRequest, "Invalid reassign_to", err.Error())
+			return
+		}
+		reassignTo = &target
+	}
+
+	if err := c.Service.DeleteCategory(id, reassignTo); err != nil {
+		sendCategoryError(w, "Failed to delete category", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, "Category deleted successfully", nil)
+}
@@ -0,0 +1,281 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/wikasdude/blog-backend/config"
+	"github.com/wikasdude/blog-backend/model"
+	service "github.com/wikasdude/blog-backend/services"
+	"github.com/wikasdude/blog-backend/utils"
+)
+
+type CommentController struct {
+	Service    *service.CommentService
+	Moderation *service.ModerationService
+}
+
+func NewCommentController(s *service.CommentService, m *service.ModerationService) *CommentController {
+	return &CommentController{Service: s, Moderation: m}
+}
+
+// sendCommentError maps comment service errors onto HTTP status codes.
+func sendCommentError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrCommentNotFound), errors.Is(err, service.ErrPostNotFound):
+		utils.SendError(w, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, service.ErrCommentForbidden):
+		utils.SendError(w, http.StatusForbidden, err.Error(), nil)
+	case errors.Is(err, service.ErrCommentInvalid), errors.Is(err, service.ErrCommentTooDeep), errors.Is(err, service.ErrCommentCursor),
+		errors.Is(err, service.ErrModerationAction), errors.Is(err, service.ErrModerationStatus):
+		utils.SendError(w, http.StatusBadRequest, err.Error(), nil)
+	default:
+		utils.SendError(w, http.StatusInternalServerError, message, err.Error())
+	}
+}
+
+// ListComments godoc
+// @Summary List comments on a post
+// @Description Returns one page of top-level comments with every reply nested under its parent (replies oldest first). Follow next_cursor, also sent as a Link header, for the next page.
+// @Tags comments
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param sort query string false "oldest (default), newest or top"
+// @Param limit query int false "Top-level comments per page (max 100)"
+// @Param cursor query string false "Opaque cursor from a previous page"
+// @Success 200 {object} model.CommentPage
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /api/blog-post/{id}/comments [get]
+func (c *CommentController) ListComments(w http.ResponseWriter, r *http.Request, postID int) {
+	query := r.URL.Query()
+	params := model.CommentListParams{
+		PostID: postID,
+		Sort:   config.DefaultCommentSort,
+		Limit:  config.DefaultLimit,
+	}
+	if v := query.Get("sort"); v != "" {
+		if v != model.CommentSortOldest && v != model.CommentSortNewest && v != model.CommentSortTop {
+			utils.SendError(w, http.StatusBadRequest, "Invalid sort", v)
+			return
+		}
+		params.Sort = v
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			utils.SendError(w, http.StatusBadRequest, "Invalid limit", v)
+			return
+		}
+		params.Limit = min(limit, config.MaxLimit)
+	}
+	if v := query.Get("cursor"); v != "" {
+		cursor, err := service.DecodeCommentCursor(v, params.Sort)
+		if err != nil {
+			sendCommentError(w, "Invalid cursor", err)
+			return
+		}
+		params.Cursor = cursor
+	}
+
+	page, err := c.Service.ListComments(params)
+	if err != nil {
+		sendCommentError(w, "Failed to fetch comments", err)
+		return
+	}
+	if page.NextCursor != "" {
+		w.Header().Set("Link", utils.LinkHeader(r.URL, map[string]string{"next": page.NextCursor}))
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageComments, page)
+}
+
+// CreateComment godoc
+// @Summary Comment on a post
+// @Description Adds a comment to a published post, or a reply to another comment when parent_id is set. Replies can nest up to COMMENT_MAX_DEPTH levels. Comments caught by the spam filter, or first comments when pre-moderation is on, are accepted with 202 and only appear once a moderator approves them.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param comment body model.CommentRequest true "Comment"
+// @Success 201 {object} model.Comment
+// @Success 202 {object} model.Comment
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/blog-post/{id}/comments [post]
+func (c *CommentController) CreateComment(w http.ResponseWriter, r *http.Request, postID int) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var input model.CommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.SendError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	comment, err := c.Service.CreateComment(postID, claims.UserID, claims.Role, input)
+	if err != nil {
+		sendCommentError(w, "Failed to add comment", err)
+		return
+	}
+	if comment.Status != model.CommentStatusApproved {
+		// spam and pending look the same to the commenter
+		comment.Status, comment.SpamScore, comment.Reasons = model.CommentStatusPending, nil, nil
+		utils.SendSuccess(w, http.StatusAccepted, config.MessageCommentHeld, comment)
+		return
+	}
+	utils.SendSuccess(w, http.StatusCreated, config.MessageCommentAdded, comment)
+}
+
+// GetComment godoc
+// @Summary Get a comment
+// @Tags comments
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Success 200 {object} model.Comment
+// @Failure 404 {object} utils.APIResponse
+// @Router /api/comments/{id} [get]
+func (c *CommentController) GetComment(w http.ResponseWriter, r *http.Request, id int) {
+	comment, err := c.Service.GetPublicComment(id)
+	if err != nil {
+		sendCommentError(w, "Failed to fetch comment", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, "Comment fetched successfully", comment)
+}
+
+// UpdateComment godoc
+// @Summary Edit a comment
+// @Description Replaces the body of a comment. Only its author or an admin may edit it.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Param comment body model.CommentRequest true "New body; parent_id is ignored"
+// @Success 200 {object} model.Comment
+// @Failure 400 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/comments/{id} [patch]
+func (c *CommentController) UpdateComment(w http.ResponseWriter, r *http.Request, id int) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var input model.CommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.SendError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	comment, err := c.Service.UpdateComment(id, claims.UserID, claims.Role, input.Body)
+	if err != nil {
+		sendCommentError(w, "Failed to update comment", err)
+		return
+	}
+	if comment.Status != model.CommentStatusApproved && claims.Role != "admin" {
+		comment.Status, comment.SpamScore, comment.Reasons = model.CommentStatusPending, nil, nil
+		utils.SendSuccess(w, http.StatusAccepted, config.MessageCommentHeld, comment)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageCommentEdited, comment)
+}
+
+// DeleteComment godoc
+// @Summary Delete a comment
+// @Description Deletes a comment. Its author, the author of the post and admins may delete it. Replies to it stay visible under a placeholder.
+// @Tags comments
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Success 200 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/comments/{id} [delete]
+func (c *CommentController) DeleteComment(w http.ResponseWriter, r *http.Request, id int) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := c.Service.DeleteComment(id, claims.UserID, claims.Role); err != nil {
+		sendCommentError(w, "Failed to delete comment", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageCommentGone, nil)
+}
+
+// ModerationQueue godoc
+// @Summary List the comment moderation queue
+// @Description Lists comments by moderation status, oldest first. Admins see every post's comments; other users see the comments on their own posts.
+// @Tags moderation
+// @Produce json
+// @Param status query string false "pending (default), spam or rejected"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size (max 100)"
+// @Success 200 {object} model.ModerationPage
+// @Failure 400 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/moderation/comments [get]
+func (c *CommentController) ModerationQueue(w http.ResponseWriter, r *http.Request) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	page, limit, ok := parsePageParams(w, query)
+	if !ok {
+		return
+	}
+	status := query.Get("status")
+	if status == "" {
+		status = model.CommentStatusPending
+	}
+
+	queue, err := c.Moderation.Queue(status, claims.UserID, claims.Role, page, limit)
+	if err != nil {
+		sendCommentError(w, "Failed to fetch moderation queue", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageModeration, queue)
+}
+
+// ModerateComment godoc
+// @Summary Approve, reject or mark a comment as spam
+// @Description Admins may moderate any comment and post authors the comments on their own posts. An admin's approve and spam decisions train the spam filter, and a later admin decision on the same comment takes that back; post authors' decisions do not train it.
+// @Tags moderation
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Param action path string true "approve, reject or spam"
+// @Success 200 {object} model.Comment
+// @Failure 400 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/moderation/comments/{id}/{action} [post]
+func (c *CommentController) ModerateComment(w http.ResponseWriter, r *http.Request, id int, action string) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	comment, err := c.Moderation.Moderate(id, action, claims.UserID, claims.Role)
+	if err != nil {
+		sendCommentError(w, "Failed to moderate comment", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageModerated, comment)
+}
@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/wikasdude/blog-backend/config"
+	"github.com/wikasdude/blog-backend/model"
+	service "github.com/wikasdude/blog-backend/services"
+	"github.com/wikasdude/blog-backend/utils"
+)
+
+type ExportController struct {
+	Service *service.ExportService
+}
+
+func NewExportController(s *service.ExportService) *ExportController {
+	return &ExportController{Service: s}
+}
+
+// sendExportError maps export service errors onto HTTP status codes.
+func sendExportError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrExportNotFound):
+		utils.SendError(w, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, service.ErrExportFilter):
+		utils.SendError(w, http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, service.ErrExportNotReady), errors.Is(err, service.ErrExportRunning):
+		utils.SendError(w, http.StatusConflict, err.Error(), nil)
+	default:
+		utils.SendError(w, http.StatusInternalServerError, message, err.Error())
+	}
+}
+
+// StartExport godoc
+// @Summary Export the blog
+// @Description Queues an export of the blog as a ZIP: posts as Markdown files with YAML front matter that Hugo and Jekyll read (content/posts), authors, categories and tags as JSON, the media files the posts use (static/media, private media under private/media) and a manifest.json listing what was exported. All fields are optional; without any, every post and all media are exported. Dates are RFC 3339 or YYYY-MM-DD and filter on creation date. Trashed posts are left out. Poll the returned job and download the ZIP from its download_path once done. The ZIP can be imported again as a Markdown export. Admins only.
+// @Tags exports
+// @Accept json
+// @Produce json
+// @Param export body model.ExportRequest false "Posts to export"
+// @Success 202 {object} model.ExportJob
+// @Failure 400 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/exports [post]
+func (c *ExportController) StartExport(w http.ResponseWriter, r *http.Request) {
+	claims := requireAdmin(w, r)
+	if claims == nil {
+		return
+	}
+
+	var input model.ExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && err != io.EOF {
+		utils.SendError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	filter, err := service.NewExportFilter(input.AuthorID, input.CategoryID, input.From, input.To)
+	if err != nil {
+		sendExportError(w, "", err)
+		return
+	}
+
+	job, err := c.Service.StartExport(claims.UserID, filter)
+	if err != nil {
+		sendExportError(w, "Failed to start export", err)
+		return
+	}
+	w.Header().Set("Location", "/api/exports/"+strconv.Itoa(job.ID))
+	utils.SendSuccess(w, http.StatusAccepted, config.MessageExportQueued, job)
+}
+
+// ListExports godoc
+// @Summary List exports
+// @Description Lists export jobs, newest first. Admins only.
+// @Tags exports
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size (max 100)"
+// @Success 200 {object} model.ExportPage
+// @Failure 403 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/exports [get]
+func (c *ExportController) ListExports(w http.ResponseWriter, r *http.Request) {
+	if requireAdmin(w, r) == nil {
+		return
+	}
+	page, limit, ok := parsePageParams(w, r.URL.Query())
+	if !ok {
+		return
+	}
+	exports, err := c.Service.ListExports(page, limit)
+	if err != nil {
+		sendExportError(w, "Failed to fetch exports", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageExports, exports)
+}
+
+// GetExport godoc
+// @Summary Get an export
+// @Description Returns an export job's status: queued, running, done or failed. Once done, it has the ZIP's size, counts of what it holds and the path to download it from. Admins only.
+// @Tags exports
+// @Produce json
+// @Param id path int true "Export ID"
+// @Success 200 {object} model.ExportJob
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/exports/{id} [get]
+func (c *ExportController) GetExport(w http.ResponseWriter, r *http.Request, id int) {
+	if requireAdmin(w, r) == nil {
+		return
+	}
+	job, err := c.Service.GetExport(id)
+	if err != nil {
+		sendExportError(w, "Failed to fetch export", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageExport, job)
+}
+
+// DownloadExport godoc
+// @Summary Download an export
+// @Description Downloads a finished export's ZIP. Admins only.
+// @Tags exports
+// @Produce application/zip
+// @Param id path int true "Export ID"
+// @Success 200 {file} file
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/exports/{id}/download [get]
+func (c *ExportController) DownloadExport(w http.ResponseWriter, r *http.Request, id int) {
+	if requireAdmin(w, r) == nil {
+		return
+	}
+	job, body, err := c.Service.OpenExport(id)
+	if err != nil {
+		sendExportError(w, "Failed to open export", err)
+		return
+	}
+	defer body.Close()
+
+	filename := fmt.Sprintf("blog-export-%d-%s.zip", job.ID, job.CreatedAt.Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Length", strconv.FormatInt(job.Size, 10))
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("Cache-Control", "private, no-store")
+	io.Copy(w, body)
+}
+
+// DeleteExport godoc
+// @Summary Delete an export
+// @Description Deletes an export and its ZIP. Running exports cannot be deleted. Admins only.
+// @Tags exports
+// @Produce json
+// @Param id path int true "Export ID"
+// @Success 200 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/exports/{id} [delete]
+func (c *ExportController) DeleteExport(w http.ResponseWriter, r *http.Request, id int) {
+	if requireAdmin(w, r) == nil {
+		return
+	}
+	if err := c.Service.DeleteExport(id); err != nil {
+		sendExportError(w, "Failed to delete export", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageExportDeleted, nil)
+}
@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/wikasdude/blog-backend/config"
+	service "github.com/wikasdude/blog-backend/services"
+	"github.com/wikasdude/blog-backend/utils"
+)
+
+type FeedController struct {
+	Service *service.FeedService
+	SiteURL string
+	Content string
+}
+
+func NewFeedController(s *service.FeedService) *FeedController {
+	return &FeedController{
+		Service: s,
+		SiteURL: config.GetEnv(config.EnvSiteURL, ""),
+		Content: config.GetEnv(config.EnvFeedContent, config.DefaultFeedContent),
+	}
+}
+
+// sendFeedError maps feed service errors onto HTTP status codes.
+func sendFeedError(w http.ResponseWriter, format string, err error) {
+	switch {
+	case errors.Is(err, service.ErrFeedNotFound):
+		utils.SendError(w, http.StatusNotFound, config.MessageFeedNotFound, nil)
+	case errors.Is(err, service.ErrFeedFormat):
+		utils.SendError(w, http.StatusBadRequest, err.Error(), format)
+	default:
+		utils.SendError(w, http.StatusInternalServerError, "Failed to build feed", err.Error())
+	}
+}
+
+// Feed godoc
+// @Summary Syndication feed of published posts
+// @Description Serves the latest published posts as RSS 2.0 (rss), Atom 1.0 (atom) or JSON Feed 1.1 (json). Entries carry the post's full HTML, or only its excerpt with content=excerpt; FEED_CONTENT sets the default. Responses have an ETag and a Last-Modified taken from the most recently updated post, and honour If-None-Match and If-Modified-Since with 304. Links point at SITE_URL.
+// @Tags feeds
+// @Produce application/rss+xml
+// @Produce application/atom+xml
+// @Produce application/feed+json
+// @Param format path string true "rss, atom or json"
+// @Param content query string false "full or excerpt"
+// @Param limit query int false "Number of posts (max 100)"
+// @Success 200 {string} string
+// @Success 304 {string} string
+// @Failure 400 {object} utils.APIResponse
+// @Router /api/feeds/{format} [get]
+func (c *FeedController) Feed(w http.ResponseWriter, r *http.Request, format string) {
+	c.serveFeed(w, r, "", "", format)
+}
+
+// ScopedFeed godoc
+// @Summary Syndication feed of a category, tag or author
+// @Description Like /api/feeds/{format}, limited to the published posts of one category (by id), tag (by slug) or author (by id).
+// @Tags feeds
+// @Produce application/rss+xml
+// @Produce application/atom+xml
+// @Produce application/feed+json
+// @Param kind path string true "category, tag or author"
+// @Param ref path string true "Category id, tag slug or author id"
+// @Param format path string true "rss, atom or json"
+// @Param content query string false "full or excerpt"
+// @Param limit query int false "Number of posts (max 100)"
+// @Success 200 {string} string
+// @Success 304 {string} string
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /api/feeds/{kind}/{ref}/{format} [get]
+func (c *FeedController) ScopedFeed(w http.ResponseWriter, r *http.Request, kind, ref, format string) {
+	if kind != service.FeedCategory && kind != service.FeedTag && kind != service.FeedAuthor {
+		utils.SendError(w, http.StatusNotFound, config.MessageFeedNotFound, nil)
+		return
+	}
+	c.serveFeed(w, r, kind, ref, format)
+}
+
+func (c *FeedController) serveFeed(w http.ResponseWriter, r *http.Request, kind, ref, format string) {
+	query := r.URL.Query()
+	content := query.Get("content")
+	if content == "" {
+		content = c.Content
+	}
+	if content != "full" && content != "excerpt" {
+		utils.SendError(w, http.StatusBadRequest, "content must be full or excerpt", content)
+		return
+	}
+	limit := config.DefaultFeedItems
+	if v := query.Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > config.MaxLimit {
+			utils.SendError(w, http.StatusBadRequest, "Invalid limit", v)
+			return
+		}
+	}
+
+	site := utils.SiteURL(r, c.SiteURL)
+	f, err := c.Service.BuildFeed(service.FeedOptions{
+		Kind:    kind,
+		Ref:     ref,
+		Full:    content == "full",
+		Limit:   limit,
+		SiteURL: site,
+		// the feed URL is also the Atom feed's id, so it leaves out the
+		// query, which only changes how much of the same feed is shown
+		FeedURL: site + r.URL.EscapedPath(),
+	})
+	if err != nil {
+		sendFeedError(w, format, err)
+		return
+	}
+	body, contentType, err := c.Service.RenderFeed(format, f)
+	if err != nil {
+		sendFeedError(w, format, err)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", utils.ContentETag(body))
+	w.Header().Set("Cache-Control", "public, no-cache")
+	// ServeContent answers If-None-Match and If-Modified-Since with 304
+	http.ServeContent(w, r, "", f.Updated, bytes.NewReader(body))
+}
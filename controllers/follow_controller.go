@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/wikasdude/blog-backend/config"
+	"github.com/wikasdude/blog-backend/model"
+	service "github.com/wikasdude/blog-backend/services"
+	"github.com/wikasdude/blog-backend/utils"
+)
+
+type FollowController struct {
+	Service *service.FollowService
+}
+
+func NewFollowController(s *service.FollowService) *FollowController {
+	return &FollowController{Service: s}
+}
+
+// followKinds maps the plural path segments onto follow kinds.
+var followKinds = map[string]string{
+	"authors":    model.FollowAuthor,
+	"categories": model.FollowCategory,
+	"tags":       model.FollowTag,
+}
+
+// sendFollowError maps follow service errors onto HTTP status codes.
+func sendFollowError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrFollowTargetNotFound), errors.Is(err, service.ErrAuthorNotFound):
+		utils.SendError(w, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, service.ErrFollowKind), errors.Is(err, service.ErrFollowSelf), errors.Is(err, service.ErrFeedCursor):
+		utils.SendError(w, http.StatusBadRequest, err.Error(), nil)
+	default:
+		utils.SendError(w, http.StatusInternalServerError, message, err.Error())
+	}
+}
+
+// Follow godoc
+// @Summary Follow an author, category or tag
+// @Description Published posts from what you follow show up in your feed. Authors and categories are addressed by id, tags by slug. Repeating the request changes nothing.
+// @Tags follows
+// @Produce json
+// @Param kind path string true "authors, categories or tags"
+// @Param target path string true "Author or category ID, or tag slug"
+// @Success 200 {object} utils.APIResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/me/follows/{kind}/{target} [put]
+func (c *FollowController) Follow(w http.ResponseWriter, r *http.Request, kind, target string) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := c.Service.Follow(claims.UserID, followKinds[kind], target); err != nil {
+		sendFollowError(w, "Failed to follow", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageFollowed, nil)
+}
+
+// Unfollow godoc
+// @Summary Unfollow an author, category or tag
+// @Description Repeating the request changes nothing.
+// @Tags follows
+// @Produce json
+// @Param kind path string true "authors, categories or tags"
+// @Param target path string true "Author or category ID, or tag slug"
+// @Success 200 {object} utils.APIResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/me/follows/{kind}/{target} [delete]
+func (c *FollowController) Unfollow(w http.ResponseWriter, r *http.Request, kind, target string) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := c.Service.Unfollow(claims.UserID, followKinds[kind], target); err != nil {
+		sendFollowError(w, "Failed to unfollow", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageUnfollowed, nil)
+}
+
+// ListFollows godoc
+// @Summary List what I follow
+// @Tags follows
+// @Produce json
+// @Success 200 {array} model.Follow
+// @Security BearerAuth
+// @Router /api/me/follows [get]
+func (c *FollowController) ListFollows(w http.ResponseWriter, r *http.Request) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	follows, err := c.Service.Follows(claims.UserID)
+	if err != nil {
+		sendFollowError(w, "Failed to fetch follows", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageFollows, follows)
+}
+
+// Feed godoc
+// @Summary Get my feed
+// @Description Returns published posts by followed authors, in followed categories or with followed tags, newest first. Pages use opaque cursors, also sent as RFC 8288 Link headers.
+// @Tags follows
+// @Produce json
+// @Param limit query int false "Page size (max 100)"
+// @Param cursor query string false "Opaque cursor from a previous page"
+// @Success 200 {object} model.PostPage
+// @Failure 400 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/feed [get]
+func (c *FollowController) Feed(w http.ResponseWriter, r *http.Request) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	limit := config.DefaultLimit
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			utils.SendError(w, http.StatusBadRequest, "Invalid pagination params", v)
+			return
+		}
+		limit = min(n, config.MaxLimit)
+	}
+
+	page, err := c.Service.Feed(claims.UserID, limit, query.Get("cursor"))
+	if err != nil {
+		sendFollowError(w, "Failed to fetch feed", err)
+		return
+	}
+	setPageLinks(w, r, page.Pagination)
+	utils.SendSuccess(w, http.StatusOK, config.MessageFeed, page)
+}
+
+// GetAuthor godoc
+// @Summary Get an author's profile
+// @Description Returns an author's public profile with follower and following counts. With a token, followed_by_me says whether you follow them.
+// @Tags follows
+// @Produce json
+// @Param id path int true "Author (user) ID"
+// @Success 200 {object} model.AuthorProfile
+// @Failure 404 {object} utils.APIResponse
+// @Router /api/authors/{id} [get]
+func (c *FollowController) GetAuthor(w http.ResponseWriter, r *http.Request, id int) {
+	viewerID := 0
+	if claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")); err == nil {
+		viewerID = claims.UserID
+	}
+
+	profile, err := c.Service.AuthorProfile(id, viewerID)
+	if err != nil {
+		sendFollowError(w, "Failed to fetch author", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageAuthor, profile)
+}
@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/wikasdude/blog-backend/config"
+	service "github.com/wikasdude/blog-backend/services"
+	"github.com/wikasdude/blog-backend/utils"
+)
+
+type ImportController struct {
+	Service *service.ImportService
+}
+
+func NewImportController(s *service.ImportService) *ImportController {
+	return &ImportController{Service: s}
+}
+
+// sendImportError maps import service errors onto HTTP status codes.
+func sendImportError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrImportNotFound):
+		utils.SendError(w, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, service.ErrImportTooLarge):
+		utils.SendError(w, http.StatusRequestEntityTooLarge, err.Error(), nil)
+	case errors.Is(err, service.ErrImportFormat), errors.Is(err, service.ErrImportSource):
+		utils.SendError(w, http.StatusBadRequest, err.Error(), nil)
+	default:
+		utils.SendError(w, http.StatusInternalServerError, message, err.Error())
+	}
+}
+
+// StartImport godoc
+// @Summary Import posts from WordPress or Markdown
+// @Description Queues an import of a WordPress WXR export or a ZIP of Markdown files with YAML or TOML front matter (Hugo, Jekyll), uploaded as multipart/form-data; the format is detected when not given. Authors are matched to users by email, falling back to the caller; categories are matched by slug and created when missing; dates and statuses are kept. Importing the same export again updates the posts it created, keyed by source and source post id, and leaves unchanged ones alone. The source is the WordPress site for WXR and "markdown:" plus the site for Markdown, taken from the source field or else from the ZIP's Hugo or Jekyll config (baseURL, url and baseurl) or export manifest. Posts here are addressed by id, so each post's old slug and link are not used for its address; they are listed in the report, for setting up redirects, and kept with the import. With dry_run nothing is written and the report says what would happen. Poll the returned job for its status and report. Admins only.
+// @Tags imports
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "WXR file or ZIP of Markdown files"
+// @Param format formData string false "wxr or markdown"
+// @Param source formData string false "The site a Markdown ZIP comes from, e.g. https://old.example.com; required when the ZIP has no Hugo or Jekyll config naming it"
+// @Param dry_run formData bool false "Only report what would be imported"
+// @Success 202 {object} model.ImportJob
+// @Failure 400 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 413 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/imports [post]
+func (c *ImportController) StartImport(w http.ResponseWriter, r *http.Request) {
+	claims := requireAdmin(w, r)
+	if claims == nil {
+		return
+	}
+
+	// leave room for the multipart framing and the other fields
+	r.Body = http.MaxBytesReader(w, r.Body, c.Service.MaxBytes()+1<<20)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			sendImportError(w, "", service.ErrImportTooLarge)
+			return
+		}
+		utils.SendError(w, http.StatusBadRequest, config.MessageNoFile, err.Error())
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		utils.SendError(w, http.StatusBadRequest, config.MessageNoFile, nil)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		utils.SendError(w, http.StatusBadRequest, config.MessageNoFile, err.Error())
+		return
+	}
+	dryRun, _ := strconv.ParseBool(r.FormValue("dry_run"))
+
+	job, err := c.Service.StartImport(claims.UserID, r.FormValue("format"), header.Filename, r.FormValue("source"), data, dryRun)
+	if err != nil {
+		sendImportError(w, "Failed to start import", err)
+		return
+	}
+	w.Header().Set("Location", "/api/imports/"+strconv.Itoa(job.ID))
+	utils.SendSuccess(w, http.StatusAccepted, config.MessageImportQueued, job)
+}
+
+// ListImports godoc
+// @Summary List imports
+// @Description Lists import jobs, newest first. Admins only.
+// @Tags imports
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size (max 100)"
+// @Success 200 {object} model.ImportPage
+// @Failure 403 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/imports [get]
+func (c *ImportController) ListImports(w http.ResponseWriter, r *http.Request) {
+	if requireAdmin(w, r) == nil {
+		return
+	}
+	page, limit, ok := parsePageParams(w, r.URL.Query())
+	if !ok {
+		return
+	}
+	imports, err := c.Service.ListImports(page, limit)
+	if err != nil {
+		sendImportError(w, "Failed to fetch imports", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageImports, imports)
+}
+
+// GetImport godoc
+// @Summary Get an import
+// @Description Returns an import job's status: queued, running, done or failed. Once done, report counts the posts created, updated, left unchanged, skipped and failed, lists each post with its old slug and link, and names the categories created and the authors that matched no user. Admins only.
+// @Tags imports
+// @Produce json
+// @Param id path int true "Import ID"
+// @Success 200 {object} model.ImportJob
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/imports/{id} [get]
+func (c *ImportController) GetImport(w http.ResponseWriter, r *http.Request, id int) {
+	if requireAdmin(w, r) == nil {
+		return
+	}
+	job, err := c.Service.GetImport(id)
+	if err != nil {
+		sendImportError(w, "Failed to fetch import", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageImport, job)
+}
@@ -0,0 +1,243 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wikasdude/blog-backend/config"
+	service "github.com/wikasdude/blog-backend/services"
+	"github.com/wikasdude/blog-backend/utils"
+)
+
+type MediaController struct {
+	Service *service.MediaService
+	SiteURL string
+}
+
+func NewMediaController(s *service.MediaService) *MediaController {
+	return &MediaController{Service: s, SiteURL: config.GetEnv(config.EnvSiteURL, "")}
+}
+
+// sendMediaError maps media service errors onto HTTP status codes.
+func sendMediaError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrMediaNotFound):
+		utils.SendError(w, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, service.ErrMediaTooLarge):
+		utils.SendError(w, http.StatusRequestEntityTooLarge, err.Error(), nil)
+	case errors.Is(err, service.ErrMediaType):
+		utils.SendError(w, http.StatusUnsupportedMediaType, err.Error(), nil)
+	case errors.Is(err, service.ErrMediaForbidden), errors.Is(err, service.ErrMediaSignature):
+		utils.SendError(w, http.StatusForbidden, err.Error(), nil)
+	case errors.Is(err, service.ErrMediaInUse):
+		utils.SendError(w, http.StatusConflict, err.Error(), nil)
+	default:
+		utils.SendError(w, http.StatusInternalServerError, message, err.Error())
+	}
+}
+
+// UploadMedia godoc
+// @Summary Upload media
+// @Description Uploads a file as multipart/form-data. The type is detected from the file's contents, not its name or the client's Content-Type, and must be one of MEDIA_ALLOWED_TYPES; files over MEDIA_MAX_BYTES are refused. Images are stored without their EXIF, XMP and other metadata, keeping only the orientation, and come back with status "pending": a worker then adds a blurhash placeholder and resized variants at MEDIA_VARIANT_WIDTHS in MEDIA_VARIANT_FORMATS, listed in variants and srcset. Public media gets a permanent url; private media is only reachable through signed URLs.
+// @Tags media
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "File"
+// @Param private formData bool false "Keep the file private"
+// @Success 201 {object} model.Media
+// @Failure 400 {object} utils.APIResponse
+// @Failure 413 {object} utils.APIResponse
+// @Failure 415 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/media [post]
+func (c *MediaController) UploadMedia(w http.ResponseWriter, r *http.Request) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// leave room for the multipart framing and the other fields
+	r.Body = http.MaxBytesReader(w, r.Body, c.Service.MaxBytes()+1<<20)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			sendMediaError(w, "", service.ErrMediaTooLarge)
+			return
+		}
+		utils.SendError(w, http.StatusBadRequest, config.MessageNoFile, err.Error())
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		utils.SendError(w, http.StatusBadRequest, config.MessageNoFile, nil)
+		return
+	}
+	defer file.Close()
+	private, _ := strconv.ParseBool(r.FormValue("private"))
+
+	media, err := c.Service.Upload(claims.UserID, header.Filename, file, header.Size, private, utils.SiteURL(r, c.SiteURL))
+	if err != nil {
+		sendMediaError(w, "Failed to upload media", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusCreated, config.MessageMediaUploaded, media)
+}
+
+// ListMyMedia godoc
+// @Summary List my media
+// @Description Lists the caller's uploads, newest first.
+// @Tags media
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size (max 100)"
+// @Success 200 {object} model.MediaPage
+// @Failure 400 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/me/media [get]
+func (c *MediaController) ListMyMedia(w http.ResponseWriter, r *http.Request) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	page, limit, ok := parsePageParams(w, r.URL.Query())
+	if !ok {
+		return
+	}
+
+	media, err := c.Service.ListMedia(claims.UserID, page, limit, utils.SiteURL(r, c.SiteURL))
+	if err != nil {
+		sendMediaError(w, "Failed to fetch media", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageMediaFetched, media)
+}
+
+// GetMedia godoc
+// @Summary Get media
+// @Description Private media is only visible to its owner and admins.
+// @Tags media
+// @Produce json
+// @Param id path int true "Media ID"
+// @Success 200 {object} model.Media
+// @Failure 404 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/media/{id} [get]
+func (c *MediaController) GetMedia(w http.ResponseWriter, r *http.Request, id int) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	media, err := c.Service.GetMedia(id, claims.UserID, claims.Role, utils.SiteURL(r, c.SiteURL))
+	if err != nil {
+		sendMediaError(w, "Failed to fetch media", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageMediaFetched, media)
+}
+
+// DeleteMedia godoc
+// @Summary Delete media
+// @Description Deletes media and its file. Only the owner or an admin may delete it, and not while a post body, including posts in the trash, still links to it.
+// @Tags media
+// @Produce json
+// @Param id path int true "Media ID"
+// @Success 200 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/media/{id} [delete]
+func (c *MediaController) DeleteMedia(w http.ResponseWriter, r *http.Request, id int) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := c.Service.DeleteMedia(id, claims.UserID, claims.Role); err != nil {
+		sendMediaError(w, "Failed to delete media", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageMediaDeleted, nil)
+}
+
+// SignedMediaURL godoc
+// @Summary Create a signed media URL
+// @Description Returns links to the media and its variants, and a srcset of them, that work without authentication until they expire. Only the owner or an admin may create them.
+// @Tags media
+// @Produce json
+// @Param id path int true "Media ID"
+// @Param expires_in query string false "Lifetime such as 15m or 24h (default 1h, max 168h)"
+// @Success 200 {object} model.SignedMediaURL
+// @Failure 400 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/media/{id}/url [get]
+func (c *MediaController) SignedMediaURL(w http.ResponseWriter, r *http.Request, id int) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	ttl := config.DefaultSignedURLTTL
+	if v := r.URL.Query().Get("expires_in"); v != "" {
+		if ttl, err = time.ParseDuration(v); err != nil || ttl <= 0 || ttl > config.MaxSignedURLTTL {
+			utils.SendError(w, http.StatusBadRequest, "Invalid expires_in", v)
+			return
+		}
+	}
+
+	signed, err := c.Service.SignedURL(id, claims.UserID, claims.Role, ttl, utils.SiteURL(r, c.SiteURL))
+	if err != nil {
+		sendMediaError(w, "Failed to sign media URL", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageMediaURL, signed)
+}
+
+// ServeMedia godoc
+// @Summary Download media
+// @Description Serves the original or a variant stored under key. Private media needs the expires and signature parameters of a signed URL.
+// @Tags media
+// @Produce octet-stream
+// @Param key path string true "Storage key"
+// @Param expires query int false "Expiry of a signed URL"
+// @Param signature query string false "Signature of a signed URL"
+// @Success 200 {file} file
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /media/{key} [get]
+func (c *MediaController) ServeMedia(w http.ResponseWriter, r *http.Request, key string) {
+	query := r.URL.Query()
+	file, body, err := c.Service.Open(key, query.Get("expires"), query.Get("signature"))
+	if err != nil {
+		sendMediaError(w, "Failed to open media", err)
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", file.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(file.Size, 10))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; sandbox")
+	if file.Private {
+		w.Header().Set("Cache-Control", "private, no-store")
+	} else {
+		// keys are never reused, so public files never change
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	}
+	if r.Method == http.MethodHead {
+		return
+	}
+	io.Copy(w, body)
+}
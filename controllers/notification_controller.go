@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/wikasdude/blog-backend/config"
+	"github.com/wikasdude/blog-backend/model"
+	service "github.com/wikasdude/blog-backend/services"
+	"github.com/wikasdude/blog-backend/utils"
+)
+
+type NotificationController struct {
+	Service *service.NotificationService
+}
+
+func NewNotificationController(s *service.NotificationService) *NotificationController {
+	return &NotificationController{Service: s}
+}
+
+// sendNotificationError maps notification service errors onto HTTP status codes.
+func sendNotificationError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrNotificationNotFound):
+		utils.SendError(w, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, service.ErrNotificationType):
+		utils.SendError(w, http.StatusBadRequest, err.Error(), nil)
+	default:
+		utils.SendError(w, http.StatusInternalServerError, message, err.Error())
+	}
+}
+
+// ListNotifications godoc
+// @Summary List my notifications
+// @Description Lists the caller's notifications, most recent activity first. Repeated activity on the same thing is grouped into one entry, e.g. "5 people reacted to your post", until it is read.
+// @Tags notifications
+// @Produce json
+// @Param unread query bool false "Only unread notifications"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size (max 100)"
+// @Success 200 {object} model.NotificationPage
+// @Failure 400 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/me/notifications [get]
+func (c *NotificationController) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	query := r.URL.Query()
+	page, limit, ok := parsePageParams(w, query)
+	if !ok {
+		return
+	}
+	unreadOnly := false
+	if v := query.Get("unread"); v != "" {
+		if unreadOnly, err = strconv.ParseBool(v); err != nil {
+			utils.SendError(w, http.StatusBadRequest, "Invalid unread", v)
+			return
+		}
+	}
+
+	notifications, err := c.Service.Inbox(claims.UserID, unreadOnly, page, limit)
+	if err != nil {
+		sendNotificationError(w, "Failed to fetch notifications", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageNotifications, notifications)
+}
+
+// UnreadCount godoc
+// @Summary Count my unread notifications
+// @Tags notifications
+// @Produce json
+// @Success 200 {object} map[string]int
+// @Security BearerAuth
+// @Router /api/me/notifications/unread-count [get]
+func (c *NotificationController) UnreadCount(w http.ResponseWriter, r *http.Request) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	unread, err := c.Service.UnreadCount(claims.UserID)
+	if err != nil {
+		sendNotificationError(w, "Failed to count notifications", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageNotifications, map[string]int{"unread": unread})
+}
+
+// MarkRead godoc
+// @Summary Mark a notification read
+// @Tags notifications
+// @Produce json
+// @Param id path int true "Notification ID"
+// @Success 200 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/me/notifications/{id}/read [post]
+func (c *NotificationController) MarkRead(w http.ResponseWriter, r *http.Request, id int) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := c.Service.MarkRead(claims.UserID, id); err != nil {
+		sendNotificationError(w, "Failed to mark notification read", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageMarkedRead, nil)
+}
+
+// MarkAllRead godoc
+// @Summary Mark all my notifications read
+// @Tags notifications
+// @Produce json
+// @Success 200 {object} map[string]int64
+// @Security BearerAuth
+// @Router /api/me/notifications/read-all [post]
+func (c *NotificationController) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	marked, err := c.Service.MarkAllRead(claims.UserID)
+	if err != nil {
+		sendNotificationError(w, "Failed to mark notifications read", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageMarkedRead, map[string]int64{"marked": marked})
+}
+
+// GetPreferences godoc
+// @Summary Get my notification preferences
+// @Description Returns every notification type (comment, reply, reaction, mention, follow, new_post) with whether it is delivered.
+// @Tags notifications
+// @Produce json
+// @Success 200 {object} model.NotificationPreferences
+// @Security BearerAuth
+// @Router /api/me/notification-preferences [get]
+func (c *NotificationController) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	prefs, err := c.Service.Preferences(claims.UserID)
+	if err != nil {
+		sendNotificationError(w, "Failed to fetch notification preferences", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessagePreferences, prefs)
+}
+
+// UpdatePreferences godoc
+// @Summary Update my notification preferences
+// @Description Turns notification types on or off. Types left out keep their current setting.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param preferences body model.NotificationPreferences true "Types to change, e.g. {\"reaction\": false}"
+// @Success 200 {object} model.NotificationPreferences
+// @Failure 400 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/me/notification-preferences [put]
+func (c *NotificationController) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var input model.NotificationPreferences
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.SendError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	prefs, err := c.Service.UpdatePreferences(claims.UserID, input)
+	if err != nil {
+		sendNotificationError(w, "Failed to update notification preferences", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessagePrefsUpdated, prefs)
+}
@@ -1,11 +1,18 @@
 package controller
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"mime"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/wikasdude/blog-backend/config"
 	"github.com/wikasdude/blog-backend/model"
@@ -61,8 +68,23 @@ func (c *PostController) CreatePost(w http.ResponseWriter, r *http.Request) {
 		utils.SendError(w, http.StatusBadRequest, "All fields (title, description, user_id, category_id) are required", err)
 		return
 	}
+	if post.Status == "" {
+		post.Status = model.PostStatusPublished
+	}
+	if !model.IsValidPostStatus(post.Status) {
+		utils.SendError(w, http.StatusBadRequest, "Invalid post status", post.Status)
+		return
+	}
 
 	err = c.postService.CreatePost(&post)
+	if errors.Is(err, service.ErrInvalidContentFormat) {
+		utils.SendError(w, http.StatusBadRequest, err.Error(), post.ContentFormat)
+		return
+	}
+	if errors.Is(err, service.ErrInvalidPost) {
+		utils.SendError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
 	if err != nil {
 		fmt.Println("line no 35:", err)
 		//http.Error(w, "Failed to create post", http.StatusInternalServerError)
@@ -91,13 +113,14 @@ func (c *PostController) CreatePost(w http.ResponseWriter, r *http.Request) {
 
 // GetPostByID godoc
 // @Summary Get a blog post by ID
-// @Description Fetch a single post by its ID
+// @Description Fetch a single post by its ID. Drafts are only returned to their author and admins; to anyone else they are not found.
 // @Tags posts
 // @Produce json
 // @Param id path int true "Post ID"
 // @Success 200 {object} model.Post
 // @Failure 400 {object} utils.APIResponse
 // @Failure 404 {object} utils.APIResponse
+// @Security BearerAuth
 // @Router /api/blog-post/{id} [get]
 func (c *PostController) GetPostByID(w http.ResponseWriter, r *http.Request) {
 	pathParts := strings.Split(r.URL.Path, "/")
@@ -112,7 +135,7 @@ func (c *PostController) GetPostByID(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(pathParts[3])
 	if err != nil {
 		utils.SendError(w, http.StatusNotFound, "Post not found", err)
-
+		return
 	}
 
 	post, err := c.postService.GetPostByID(id)
@@ -128,23 +151,46 @@ func (c *PostController) GetPostByID(w http.ResponseWriter, r *http.Request) {
 		// http.Error(w, "Post not found", http.StatusNotFound)
 		// return
 	}
+	if post.Status != model.PostStatusPublished {
+		if !canReadUnpublished(r, post) {
+			utils.SendError(w, http.StatusNotFound, fmt.Sprintf("Post not found with id: %d", id), nil)
+			return
+		}
+	} else if err := c.postService.RecordView(id); err != nil {
+		log.Println("failed to record post view:", err)
+	}
+	w.Header().Set("ETag", utils.VersionETag(post.Version))
 	utils.SendSuccess(w, http.StatusOK, "Post fetched successfully", post)
 
 }
 
+// canReadUnpublished reports whether the caller may see post while it is
+// unpublished: only its author and admins can.
+func canReadUnpublished(r *http.Request, post *model.Post) bool {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	return err == nil && (claims.Role == "admin" || claims.UserID == post.UserID)
+}
+
 // Update Post
 // UpdatePost godoc
 // @Summary Update an existing blog post
-// @Description Allows the owner or an admin to update a blog post
+// @Description Allows the owner or an admin to update a blog post. The body is an RFC 7396 merge patch (application/merge-patch+json, or plain application/json): only the members sent are changed and null clears a field. An RFC 6902 JSON Patch is accepted as application/json-patch+json. The response is the stored post. Send the ETag from a previous GET as If-Match to avoid overwriting someone else's changes; a stale ETag is rejected with 412 and the current version.
 // @Tags posts
 // @Accept json
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
 // @Produce json
 // @Param id path int true "Post ID"
-// @Param post body model.UpdatePostRequest true "Updated Post JSON"
+// @Param If-Match header string false "ETag of the version being edited"
+// @Param post body model.UpdatePostRequest true "Fields to change"
 // @Success 200 {object} model.Post
 // @Failure 400 {object} utils.APIResponse
 // @Failure 403 {object} utils.APIResponse
 // @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Failure 412 {object} utils.APIResponse
+// @Failure 415 {object} utils.APIResponse
+// @Failure 428 {object} utils.APIResponse
 // @Failure 500 {object} utils.APIResponse
 // @Security BearerAuth
 // @Router /api/blog-post/{id} [patch]
@@ -185,36 +231,118 @@ func (c *PostController) UpdatePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var updatedPost model.Post
-	if err := json.NewDecoder(r.Body).Decode(&updatedPost); err != nil {
-		utils.SendError(w, http.StatusBadRequest, "Invalid request payload", err)
+	expectedVersion, ok := checkIfMatch(w, r, existingPost)
+	if !ok {
 		return
 	}
 
-	updatedPost.ID = id
-	updatedPost.UserID = existingPost.UserID // keep original owner
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		utils.SendError(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+	current, err := json.Marshal(model.NewPostPatch(existingPost))
+	if err != nil {
+		utils.SendError(w, http.StatusInternalServerError, "Failed to update post", err.Error())
+		return
+	}
 
-	err = c.postService.UpdatePost(&updatedPost)
+	var merged []byte
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "", "application/json", utils.MergePatchContentType:
+		merged, err = utils.MergePatch(current, raw)
+	case utils.JSONPatchContentType:
+		merged, err = utils.ApplyJSONPatch(current, raw)
+	default:
+		w.Header().Set("Accept-Patch", utils.MergePatchContentType+", "+utils.JSONPatchContentType)
+		utils.SendError(w, http.StatusUnsupportedMediaType, "Unsupported patch format", mediaType)
+		return
+	}
+	if errors.Is(err, utils.ErrPatchTestFailed) {
+		utils.SendError(w, http.StatusConflict, "Patch test failed", err.Error())
+		return
+	}
+	if err != nil {
+		utils.SendError(w, http.StatusBadRequest, "Invalid patch", err.Error())
+		return
+	}
+
+	var patch model.PostPatch
+	dec := json.NewDecoder(bytes.NewReader(merged))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&patch); err != nil {
+		utils.SendError(w, http.StatusBadRequest, "Invalid patch", err.Error())
+		return
+	}
+
+	updatedPost, err := c.postService.PatchPost(existingPost, patch, expectedVersion)
+	if errors.Is(err, service.ErrInvalidContentFormat) {
+		utils.SendError(w, http.StatusBadRequest, err.Error(), patch.ContentFormat)
+		return
+	}
+	if errors.Is(err, service.ErrInvalidPost) {
+		utils.SendError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if errors.Is(err, service.ErrPostVersionConflict) {
+		c.sendVersionConflict(w, id)
+		return
+	}
 	if err != nil {
 		utils.SendError(w, http.StatusInternalServerError, "Failed to update post", err)
 		return
 	}
 
+	w.Header().Set("ETag", utils.VersionETag(updatedPost.Version))
 	utils.SendSuccess(w, http.StatusOK, "Post updated successfully", updatedPost)
 }
 
+// PreviewPost godoc
+// @Summary Preview a post body
+// @Description Renders a markdown, html or plain body to sanitized HTML with heading anchors and a table of contents, without saving anything
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param body body model.PreviewRequest true "Body to render"
+// @Success 200 {object} model.RenderedBody
+// @Failure 400 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/blog-post/preview [post]
+func (c *PostController) PreviewPost(w http.ResponseWriter, r *http.Request) {
+	var input model.PreviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.SendError(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	rendered, err := c.postService.PreviewBody(input.ContentFormat, input.Body)
+	if errors.Is(err, service.ErrInvalidContentFormat) {
+		utils.SendError(w, http.StatusBadRequest, err.Error(), input.ContentFormat)
+		return
+	}
+	if err != nil {
+		utils.SendError(w, http.StatusInternalServerError, "Failed to render preview", err.Error())
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, "Preview rendered successfully", rendered)
+}
+
 // Delete Post
 // Decode updated data
 // DeletePost godoc
 // @Summary Delete a blog post
-// @Description Allows the owner or an admin to delete a blog post
+// @Description Allows the owner or an admin to move a blog post to the trash, from where it can be restored until it is purged. If-Match is checked the same way as for updates.
 // @Tags posts
 // @Produce json
 // @Param id path int true "Post ID"
+// @Param If-Match header string false "ETag of the version being deleted"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} utils.APIResponse
 // @Failure 403 {object} utils.APIResponse
 // @Failure 404 {object} utils.APIResponse
+// @Failure 412 {object} utils.APIResponse
+// @Failure 428 {object} utils.APIResponse
 // @Failure 500 {object} utils.APIResponse
 // @Security BearerAuth
 // @Router /api/blog-post/{id} [delete]
@@ -253,71 +381,320 @@ func (c *PostController) DeletePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = c.postService.DeletePost(id)
+	expectedVersion, ok := checkIfMatch(w, r, post)
+	if !ok {
+		return
+	}
+
+	err = c.postService.DeletePost(id, userID, expectedVersion)
+	if errors.Is(err, service.ErrPostVersionConflict) {
+		c.sendVersionConflict(w, id)
+		return
+	}
 	if err != nil {
 		utils.SendError(w, http.StatusInternalServerError, "Failed to delete post", err.Error())
 		return
 	}
 
-	utils.SendSuccess(w, http.StatusOK, "Post deleted successfully", nil)
+	utils.SendSuccess(w, http.StatusOK, config.MessagePostTrashed, nil)
+}
+
+// checkIfMatch validates the If-Match header against the post's current
+// version and returns the version the write must still find in the database
+// (0 when the client sent "*" or, unless REQUIRE_IF_MATCH is set, nothing).
+// On failure the 412/428 response has already been written.
+func checkIfMatch(w http.ResponseWriter, r *http.Request, post *model.Post) (int, bool) {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		if config.GetEnvBool(config.EnvRequireIfMatch, false) {
+			utils.SendError(w, http.StatusPreconditionRequired, config.MessageIfMatchNeeded, nil)
+			return 0, false
+		}
+		return 0, true
+	}
+	if strings.TrimSpace(ifMatch) == "*" {
+		return 0, true
+	}
+	current := utils.VersionETag(post.Version)
+	if !utils.MatchesETag(ifMatch, current) {
+		w.Header().Set("ETag", current)
+		utils.SendError(w, http.StatusPreconditionFailed, config.MessageVersionStale, map[string]int{"current_version": post.Version})
+		return 0, false
+	}
+	return post.Version, true
+}
+
+// sendVersionConflict reports a write that lost the race against another
+// one after If-Match had already been checked.
+func (c *PostController) sendVersionConflict(w http.ResponseWriter, id int) {
+	var data interface{}
+	if post, err := c.postService.GetPostByID(id); err == nil {
+		w.Header().Set("ETag", utils.VersionETag(post.Version))
+		data = map[string]int{"current_version": post.Version}
+	}
+	utils.SendError(w, http.StatusPreconditionFailed, config.MessageVersionStale, data)
 }
 
 // GetAllPosts godoc
-// @Summary Get all blog posts
-// @Description Fetch all posts from the database
+// @Summary List blog posts
+// @Description Returns one page of posts using opaque cursor pagination. Follow next_cursor / prev_cursor (also sent as RFC 8288 Link headers) to move between pages; the sort and order of a cursor cannot be changed mid-walk. popularity orders by view count, which every read of a post increases, so a post read during a walk in that order can move onto a page already seen and be repeated or skipped.
 // @Tags posts
 // @Produce json
-// @Success 200 {array} model.Post
+// @Param limit query int false "Page size (max 100)"
+// @Param cursor query string false "Opaque cursor from a previous page"
+// @Param order query string false "asc or desc"
+// @Param sort query string false "Sort field (title, created_at, updated_at, published_at, popularity)"
+// @Param search query string false "Search in title and description"
+// @Param category_id query int false "Filter by category"
+// @Param author_id query int false "Filter by author"
+// @Param tag query string false "Filter by tag slug"
+// @Param status query string false "published (default), draft or all; draft and all require a token and show only your own posts unless you are an admin"
+// @Param created_from query string false "Created on or after (YYYY-MM-DD or RFC 3339)"
+// @Param created_to query string false "Created on or before"
+// @Param updated_from query string false "Updated on or after"
+// @Param updated_to query string false "Updated on or before"
+// @Param published_from query string false "Published on or after"
+// @Param published_to query string false "Published on or before"
+// @Param has_body query bool false "Only posts with (true) or without (false) a body"
+// @Success 200 {object} model.PostPage
+// @Failure 400 {object} utils.APIResponse
 // @Failure 500 {object} utils.APIResponse
 // @Router /api/blog-posts [get]
 func (pc *PostController) GetAllPosts(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
-	pageStr := query.Get("page")
-	limitStr := query.Get("limit")
+	filter, status, err := parsePostFilter(r, query)
+	if err != nil {
+		utils.SendError(w, status, err.Error(), nil)
+		return
+	}
+	params := model.PostListParams{
+		Filter: filter,
+		Sort:   query.Get("sort"),
+		Order:  query.Get("order"),
+		Limit:  config.DefaultLimit,
+	}
 
-	if pageStr != "" && limitStr != "" {
-		// Paginated
-		page, err1 := strconv.Atoi(pageStr)
-		limit, err2 := strconv.Atoi(limitStr)
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			utils.SendError(w, http.StatusBadRequest, "Invalid pagination params", v)
+			return
+		}
+		params.Limit = min(limit, config.MaxLimit)
+	}
 
-		if err1 != nil || err2 != nil || page < 1 || limit < 1 {
-			utils.SendError(w, http.StatusBadRequest, "Invalid pagination params", nil)
+	if token := query.Get("cursor"); token != "" {
+		cursor, err := service.DecodePostCursor(token)
+		if err != nil {
+			utils.SendError(w, http.StatusBadRequest, "Invalid cursor", nil)
 			return
 		}
-		sort := r.URL.Query().Get("sort")
-		order := r.URL.Query().Get("order")
-		if sort == "" {
-			sort = config.DefaultSort
+		if (params.Sort != "" && params.Sort != cursor.Sort) || (params.Order != "" && params.Order != cursor.Order) {
+			utils.SendError(w, http.StatusBadRequest, "Cursor does not match the requested sort order", nil)
+			return
 		}
-		if order == "" {
-			order = config.DefaultOrder
+		params.Sort, params.Order, params.Cursor = cursor.Sort, cursor.Order, cursor
+	}
+
+	if !config.ValidSortFields[params.Sort] {
+		params.Sort = config.DefaultSort
+	}
+	if params.Order != "asc" && params.Order != "desc" {
+		params.Order = config.DefaultOrder
+	}
+
+	page, err := pc.postService.ListPosts(params)
+	if err != nil {
+		utils.SendError(w, http.StatusInternalServerError, "Failed to fetch posts", err.Error())
+		return
+	}
+
+	setPageLinks(w, r, page.Pagination)
+	utils.SendSuccess(w, http.StatusOK, config.MessagePostsFetched, page)
+}
+
+// setPageLinks sends the first, prev and next pages of a cursor listing as a Link header.
+func setPageLinks(w http.ResponseWriter, r *http.Request, p model.Pagination) {
+	links := map[string]string{"first": ""}
+	if p.PrevCursor != "" {
+		links["prev"] = p.PrevCursor
+	}
+	if p.NextCursor != "" {
+		links["next"] = p.NextCursor
+	}
+	w.Header().Set("Link", utils.LinkHeader(r.URL, links))
+}
+
+// parsePostFilter reads the listing filters from the query string. It returns
+// the HTTP status to answer with alongside any error.
+func parsePostFilter(r *http.Request, query url.Values) (model.PostFilter, int, error) {
+	filter := model.PostFilter{
+		Search: query.Get("search"),
+		Tag:    query.Get("tag"),
+		Status: query.Get("status"),
+	}
+
+	var err error
+	if v := query.Get("category_id"); v != "" {
+		if filter.CategoryID, err = strconv.Atoi(v); err != nil {
+			return filter, http.StatusBadRequest, errors.New("invalid category_id")
 		}
-		search := r.URL.Query().Get("search")
-		//validSortFields := map[string]bool{"title": true, "created_at": true}
-		if !config.ValidSortFields[sort] {
-			sort = config.DefaultSort
+	}
+	if v := query.Get("author_id"); v != "" {
+		if filter.AuthorID, err = strconv.Atoi(v); err != nil {
+			return filter, http.StatusBadRequest, errors.New("invalid author_id")
 		}
-		if order != "asc" && order != "desc" {
-			order = config.DefaultOrder
+	}
+	if v := query.Get("has_body"); v != "" {
+		hasBody, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, http.StatusBadRequest, errors.New("invalid has_body")
 		}
+		filter.HasBody = &hasBody
+	}
 
-		posts, err := pc.postService.GetPaginatedPosts(page, limit, sort, order, search)
+	dates := []struct {
+		name string
+		dest **time.Time
+		end  bool
+	}{
+		{"created_from", &filter.CreatedFrom, false},
+		{"created_to", &filter.CreatedTo, true},
+		{"updated_from", &filter.UpdatedFrom, false},
+		{"updated_to", &filter.UpdatedTo, true},
+		{"published_from", &filter.PublishedFrom, false},
+		{"published_to", &filter.PublishedTo, true},
+	}
+	for _, d := range dates {
+		v := query.Get(d.name)
+		if v == "" {
+			continue
+		}
+		t, err := utils.ParseDate(v)
 		if err != nil {
-			utils.SendError(w, http.StatusInternalServerError, "Failed to fetch paginated posts", err)
-			return
+			return filter, http.StatusBadRequest, fmt.Errorf("invalid %s", d.name)
+		}
+		if d.end && len(v) == len("2006-01-02") {
+			t = t.Add(24*time.Hour - time.Nanosecond) // include the whole day
 		}
+		*d.dest = &t
+	}
 
-		utils.SendSuccess(w, http.StatusOK, "Paginated posts fetched", posts)
+	switch filter.Status {
+	case "", model.PostStatusPublished:
+		filter.Status = model.PostStatusPublished
+	case model.PostStatusDraft, model.PostStatusAll:
+		// unpublished posts are only visible to their authors and admins
+		tokenString := r.Header.Get("Authorization")
+		claims, err := utils.ValidateJWT(strings.TrimPrefix(tokenString, "Bearer "))
+		if err != nil {
+			return filter, http.StatusUnauthorized, errors.New("unauthorized")
+		}
+		if claims.Role != "admin" {
+			if filter.AuthorID != 0 && filter.AuthorID != claims.UserID {
+				return filter, http.StatusForbidden, errors.New("you can only list your own unpublished posts")
+			}
+			filter.AuthorID = claims.UserID
+		}
+	default:
+		return filter, http.StatusBadRequest, errors.New("invalid status")
+	}
+	return filter, 0, nil
+}
+
+// SearchPosts godoc
+// @Summary Full-text search over published posts
+// @Description Ranked search across title, description and body (weighted in that order). q accepts web-search syntax: "quoted phrases", OR, and -excluded words. Snippets are HTML-escaped with matches wrapped in <mark>.
+// @Tags posts
+// @Produce json
+// @Param q query string true "Search query"
+// @Param category_id query int false "Filter by category"
+// @Param tag query string false "Filter by tag slug"
+// @Param author_id query int false "Filter by author"
+// @Param from query string false "Published on or after (YYYY-MM-DD or RFC 3339)"
+// @Param to query string false "Published on or before (YYYY-MM-DD or RFC 3339)"
+// @Param page query int false "Page number"
+// @Param limit query int false "Results per page"
+// @Success 200 {object} model.PostSearchResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /api/blog-posts/search [get]
+func (pc *PostController) SearchPosts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	params := model.PostSearchParams{
+		Query: strings.TrimSpace(query.Get("q")),
+		Tag:   query.Get("tag"),
+	}
+	if params.Query == "" {
+		utils.SendError(w, http.StatusBadRequest, "Search query q is required", nil)
 		return
 	}
 
-	// No pagination
-	posts, err := pc.postService.GetAllPosts()
+	page, limit, ok := parsePageParams(w, query)
+	if !ok {
+		return
+	}
+	params.Limit = limit
+	var err error
+	if v := query.Get("category_id"); v != "" {
+		if params.CategoryID, err = strconv.Atoi(v); err != nil {
+			utils.SendError(w, http.StatusBadRequest, "Invalid category_id", v)
+			return
+		}
+	}
+	if v := query.Get("author_id"); v != "" {
+		if params.AuthorID, err = strconv.Atoi(v); err != nil {
+			utils.SendError(w, http.StatusBadRequest, "Invalid author_id", v)
+			return
+		}
+	}
+	if v := query.Get("from"); v != "" {
+		from, err := utils.ParseDate(v)
+		if err != nil {
+			utils.SendError(w, http.StatusBadRequest, "Invalid from date", v)
+			return
+		}
+		params.From = &from
+	}
+	if v := query.Get("to"); v != "" {
+		to, err := utils.ParseDate(v)
+		if err != nil {
+			utils.SendError(w, http.StatusBadRequest, "Invalid to date", v)
+			return
+		}
+		if len(v) == len("2006-01-02") {
+			to = to.Add(24*time.Hour - time.Nanosecond) // include the whole day
+		}
+		params.To = &to
+	}
+
+	results, err := pc.postService.SearchPosts(params, page)
 	if err != nil {
-		fmt.Println("line no 317", err)
-		utils.SendError(w, http.StatusInternalServerError, "Failed to fetch posts", err)
+		utils.SendError(w, http.StatusInternalServerError, "Failed to search posts", err.Error())
 		return
 	}
+	utils.SendSuccess(w, http.StatusOK, "Search results fetched", results)
+}
 
-	utils.SendSuccess(w, http.StatusOK, "All posts fetched", posts)
+// parsePageParams reads offset pagination parameters, capping limit at
+// config.MaxLimit. On failure the 400 response has already been written.
+func parsePageParams(w http.ResponseWriter, query url.Values) (page, limit int, ok bool) {
+	page, limit = config.DefaultPage, config.DefaultLimit
+	var err error
+	if v := query.Get("page"); v != "" {
+		if page, err = strconv.Atoi(v); err != nil || page < 1 {
+			utils.SendError(w, http.StatusBadRequest, "Invalid page", v)
+			return 0, 0, false
+		}
+	}
+	if v := query.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+			utils.SendError(w, http.StatusBadRequest, "Invalid limit", v)
+			return 0, 0, false
+		}
+		if limit > config.MaxLimit {
+			limit = config.MaxLimit
+		}
+	}
+	return page, limit, true
 }
@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/wikasdude/blog-backend/config"
+	service "github.com/wikasdude/blog-backend/services"
+	"github.com/wikasdude/blog-backend/utils"
+)
+
+type ReactionController struct {
+	Service *service.ReactionService
+}
+
+func NewReactionController(s *service.ReactionService) *ReactionController {
+	return &ReactionController{Service: s}
+}
+
+// sendReactionError maps reaction service errors onto HTTP status codes.
+func sendReactionError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrPostNotFound):
+		utils.SendError(w, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, service.ErrUnknownReaction):
+		utils.SendError(w, http.StatusBadRequest, err.Error(), nil)
+	default:
+		utils.SendError(w, http.StatusInternalServerError, message, err.Error())
+	}
+}
+
+// ListReactionTypes godoc
+// @Summary List available reactions
+// @Description Returns the reactions readers can leave on posts, as configured by REACTIONS. like is always available.
+// @Tags reactions
+// @Produce json
+// @Success 200 {array} model.ReactionType
+// @Router /api/reactions [get]
+func (c *ReactionController) ListReactionTypes(w http.ResponseWriter, r *http.Request) {
+	utils.SendSuccess(w, http.StatusOK, config.MessageReactions, c.Service.ReactionTypes())
+}
+
+// GetReactions godoc
+// @Summary Get a post's reactions
+// @Description Returns the reaction counts of a post. With a token, the caller's own reactions are included as mine.
+// @Tags reactions
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 200 {object} model.ReactionSummary
+// @Failure 404 {object} utils.APIResponse
+// @Router /api/blog-post/{id}/reactions [get]
+func (c *ReactionController) GetReactions(w http.ResponseWriter, r *http.Request, postID int) {
+	userID := 0
+	if claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")); err == nil {
+		userID = claims.UserID
+	}
+
+	summary, err := c.Service.PostSummary(postID, userID)
+	if err != nil {
+		sendReactionError(w, "Failed to fetch reactions", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageReactions, summary)
+}
+
+// React godoc
+// @Summary React to a post
+// @Description Adds the caller's reaction to a post. Repeating the request changes nothing.
+// @Tags reactions
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param reaction path string true "Reaction name, e.g. like"
+// @Success 200 {object} model.ReactionSummary
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/blog-post/{id}/reactions/{reaction} [put]
+func (c *ReactionController) React(w http.ResponseWriter, r *http.Request, postID int, reaction string) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	summary, err := c.Service.React(postID, claims.UserID, reaction)
+	if err != nil {
+		sendReactionError(w, "Failed to save reaction", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageReacted, summary)
+}
+
+// Unreact godoc
+// @Summary Remove a reaction from a post
+// @Description Removes the caller's reaction from a post. Repeating the request changes nothing.
+// @Tags reactions
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param reaction path string true "Reaction name, e.g. like"
+// @Success 200 {object} model.ReactionSummary
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/blog-post/{id}/reactions/{reaction} [delete]
+func (c *ReactionController) Unreact(w http.ResponseWriter, r *http.Request, postID int, reaction string) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	summary, err := c.Service.Unreact(postID, claims.UserID, reaction)
+	if err != nil {
+		sendReactionError(w, "Failed to remove reaction", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageUnreacted, summary)
+}
+
+// ListReactors godoc
+// @Summary List who reacted to a post
+// @Tags reactions
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param reaction path string true "Reaction name"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size (max 100)"
+// @Success 200 {object} model.ReactorPage
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /api/blog-post/{id}/reactions/{reaction}/users [get]
+func (c *ReactionController) ListReactors(w http.ResponseWriter, r *http.Request, postID int, reaction string) {
+	page, limit, ok := parsePageParams(w, r.URL.Query())
+	if !ok {
+		return
+	}
+
+	reactors, err := c.Service.Reactors(postID, reaction, page, limit)
+	if err != nil {
+		sendReactionError(w, "Failed to fetch reactions", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageReactions, reactors)
+}
+
+// MyReactions godoc
+// @Summary List my reactions
+// @Description Lists the posts the caller reacted to, most recent first, with the reactions they left.
+// @Tags reactions
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size (max 100)"
+// @Success 200 {object} model.UserReactionPage
+// @Failure 400 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/me/reactions [get]
+func (c *ReactionController) MyReactions(w http.ResponseWriter, r *http.Request) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	page, limit, ok := parsePageParams(w, r.URL.Query())
+	if !ok {
+		return
+	}
+
+	reactions, err := c.Service.MyReactions(claims.UserID, page, limit)
+	if err != nil {
+		sendReactionError(w, "Failed to fetch reactions", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageReactions, reactions)
+}
@@ -0,0 +1,358 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/wikasdude/blog-backend/config"
+	"github.com/wikasdude/blog-backend/model"
+	service "github.com/wikasdude/blog-backend/services"
+	"github.com/wikasdude/blog-backend/utils"
+)
+
+type ReadingListController struct {
+	Service *service.ReadingListService
+}
+
+func NewReadingListController(s *service.ReadingListService) *ReadingListController {
+	return &ReadingListController{Service: s}
+}
+
+// sendReadingListError maps bookmark and reading list service errors onto HTTP status codes.
+func sendReadingListError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrPostNotFound), errors.Is(err, service.ErrReadingListNotFound):
+		utils.SendError(w, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, service.ErrReadingListInvalid):
+		utils.SendError(w, http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, service.ErrReadingListForbidden):
+		utils.SendError(w, http.StatusForbidden, err.Error(), nil)
+	default:
+		utils.SendError(w, http.StatusInternalServerError, message, err.Error())
+	}
+}
+
+// ListBookmarks godoc
+// @Summary List my bookmarks
+// @Description Lists the caller's bookmarked posts, most recently saved first. Trashed and unpublished posts are left out.
+// @Tags bookmarks
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size (max 100)"
+// @Success 200 {object} model.BookmarkPage
+// @Failure 400 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/me/bookmarks [get]
+func (c *ReadingListController) ListBookmarks(w http.ResponseWriter, r *http.Request) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	page, limit, ok := parsePageParams(w, r.URL.Query())
+	if !ok {
+		return
+	}
+
+	bookmarks, err := c.Service.Bookmarks(claims.UserID, page, limit)
+	if err != nil {
+		sendReadingListError(w, "Failed to fetch bookmarks", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageBookmarks, bookmarks)
+}
+
+// Bookmark godoc
+// @Summary Bookmark a post
+// @Description Saves a published post for later. Repeating the request changes nothing.
+// @Tags bookmarks
+// @Produce json
+// @Param postId path int true "Post ID"
+// @Success 200 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/me/bookmarks/{postId} [put]
+func (c *ReadingListController) Bookmark(w http.ResponseWriter, r *http.Request, postID int) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := c.Service.Bookmark(claims.UserID, postID); err != nil {
+		sendReadingListError(w, "Failed to save bookmark", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageBookmarked, nil)
+}
+
+// Unbookmark godoc
+// @Summary Remove a bookmark
+// @Description Removes a post from the caller's bookmarks. Repeating the request changes nothing.
+// @Tags bookmarks
+// @Produce json
+// @Param postId path int true "Post ID"
+// @Success 200 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/me/bookmarks/{postId} [delete]
+func (c *ReadingListController) Unbookmark(w http.ResponseWriter, r *http.Request, postID int) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := c.Service.Unbookmark(claims.UserID, postID); err != nil {
+		sendReadingListError(w, "Failed to remove bookmark", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageUnbookmarked, nil)
+}
+
+// ListReadingLists godoc
+// @Summary List my reading lists
+// @Tags reading-lists
+// @Produce json
+// @Success 200 {array} model.ReadingList
+// @Security BearerAuth
+// @Router /api/me/reading-lists [get]
+func (c *ReadingListController) ListReadingLists(w http.ResponseWriter, r *http.Request) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	lists, err := c.Service.Lists(claims.UserID)
+	if err != nil {
+		sendReadingListError(w, "Failed to fetch reading lists", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageListsFetched, lists)
+}
+
+// CreateReadingList godoc
+// @Summary Create a reading list
+// @Description Creates a named reading list. With shared set to true the list gets a share_token that anyone can use to read it.
+// @Tags reading-lists
+// @Accept json
+// @Produce json
+// @Param list body model.ReadingListRequest true "Reading list"
+// @Success 201 {object} model.ReadingList
+// @Failure 400 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/me/reading-lists [post]
+func (c *ReadingListController) CreateReadingList(w http.ResponseWriter, r *http.Request) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var input model.ReadingListRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.SendError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	list, err := c.Service.CreateList(claims.UserID, input)
+	if err != nil {
+		sendReadingListError(w, "Failed to create reading list", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusCreated, config.MessageListCreated, list)
+}
+
+// GetReadingList godoc
+// @Summary Get one of my reading lists
+// @Description Returns a reading list with its posts in list order. Trashed and unpublished posts are left out.
+// @Tags reading-lists
+// @Produce json
+// @Param id path int true "Reading list ID"
+// @Success 200 {object} model.ReadingList
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/reading-lists/{id} [get]
+func (c *ReadingListController) GetReadingList(w http.ResponseWriter, r *http.Request, id int) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	list, err := c.Service.GetList(id, claims.UserID)
+	if err != nil {
+		sendReadingListError(w, "Failed to fetch reading list", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageListFetched, list)
+}
+
+// UpdateReadingList godoc
+// @Summary Update a reading list
+// @Description Changes the fields sent. Setting shared to false revokes the share link; setting it to true again issues a new one.
+// @Tags reading-lists
+// @Accept json
+// @Produce json
+// @Param id path int true "Reading list ID"
+// @Param list body model.ReadingListRequest true "Fields to change"
+// @Success 200 {object} model.ReadingList
+// @Failure 400 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/reading-lists/{id} [patch]
+func (c *ReadingListController) UpdateReadingList(w http.ResponseWriter, r *http.Request, id int) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var input model.ReadingListRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.SendError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	list, err := c.Service.UpdateList(id, claims.UserID, input)
+	if err != nil {
+		sendReadingListError(w, "Failed to update reading list", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageListUpdated, list)
+}
+
+// DeleteReadingList godoc
+// @Summary Delete a reading list
+// @Tags reading-lists
+// @Produce json
+// @Param id path int true "Reading list ID"
+// @Success 200 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/reading-lists/{id} [delete]
+func (c *ReadingListController) DeleteReadingList(w http.ResponseWriter, r *http.Request, id int) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := c.Service.DeleteList(id, claims.UserID); err != nil {
+		sendReadingListError(w, "Failed to delete reading list", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageListDeleted, nil)
+}
+
+// AddToReadingList godoc
+// @Summary Add a post to a reading list
+// @Description Appends a published post to the end of the list. Repeating the request changes nothing.
+// @Tags reading-lists
+// @Produce json
+// @Param id path int true "Reading list ID"
+// @Param postId path int true "Post ID"
+// @Success 200 {object} model.ReadingList
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/reading-lists/{id}/posts/{postId} [put]
+func (c *ReadingListController) AddToReadingList(w http.ResponseWriter, r *http.Request, id, postID int) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	list, err := c.Service.AddToList(id, claims.UserID, postID)
+	if err != nil {
+		sendReadingListError(w, "Failed to update reading list", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageListUpdated, list)
+}
+
+// RemoveFromReadingList godoc
+// @Summary Remove a post from a reading list
+// @Description Repeating the request changes nothing.
+// @Tags reading-lists
+// @Produce json
+// @Param id path int true "Reading list ID"
+// @Param postId path int true "Post ID"
+// @Success 200 {object} model.ReadingList
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/reading-lists/{id}/posts/{postId} [delete]
+func (c *ReadingListController) RemoveFromReadingList(w http.ResponseWriter, r *http.Request, id, postID int) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	list, err := c.Service.RemoveFromList(id, claims.UserID, postID)
+	if err != nil {
+		sendReadingListError(w, "Failed to update reading list", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageListUpdated, list)
+}
+
+// ReorderReadingList godoc
+// @Summary Reorder a reading list
+// @Description Moves the given posts to the front of the list in the order sent; posts left out keep their relative order after them.
+// @Tags reading-lists
+// @Accept json
+// @Produce json
+// @Param id path int true "Reading list ID"
+// @Param order body model.ReorderRequest true "New order"
+// @Success 200 {object} model.ReadingList
+// @Failure 400 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/reading-lists/{id}/order [put]
+func (c *ReadingListController) ReorderReadingList(w http.ResponseWriter, r *http.Request, id int) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var input model.ReorderRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.SendError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	list, err := c.Service.ReorderList(id, claims.UserID, input.PostIDs)
+	if err != nil {
+		sendReadingListError(w, "Failed to reorder reading list", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageListUpdated, list)
+}
+
+// GetSharedReadingList godoc
+// @Summary Read a shared reading list
+// @Description Returns a reading list by its share token. No login is needed; lists that are not shared are not found.
+// @Tags reading-lists
+// @Produce json
+// @Param token path string true "Share token"
+// @Success 200 {object} model.ReadingList
+// @Failure 404 {object} utils.APIResponse
+// @Router /api/shared/reading-lists/{token} [get]
+func (c *ReadingListController) GetSharedReadingList(w http.ResponseWriter, r *http.Request, token string) {
+	list, err := c.Service.SharedList(token)
+	if err != nil {
+		sendReadingListError(w, "Failed to fetch reading list", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageListFetched, list)
+}
@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/wikasdude/blog-backend/config"
+	service "github.com/wikasdude/blog-backend/services"
+	"github.com/wikasdude/blog-backend/utils"
+)
+
+type SEOController struct {
+	Service *service.SEOService
+	SiteURL string
+}
+
+func NewSEOController(s *service.SEOService) *SEOController {
+	return &SEOController{Service: s, SiteURL: config.GetEnv(config.EnvSiteURL, "")}
+}
+
+// PostMetadata godoc
+// @Summary Open Graph, Twitter Card and JSON-LD metadata of a post
+// @Description Returns what the page of a published post puts in its head: title, description, canonical URL and robots directive, Open Graph and Twitter Card tags, and a schema.org BlogPosting for JSON-LD. meta_title, meta_description and canonical_url override what is derived from the post; the featured image, or else the first image in the body, is used for previews. URLs point at SITE_URL.
+// @Tags seo
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 200 {object} model.PostMetadata
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /api/blog-post/{id}/meta [get]
+func (c *SEOController) PostMetadata(w http.ResponseWriter, r *http.Request, postID int) {
+	meta, err := c.Service.PostMetadata(postID, utils.SiteURL(r, c.SiteURL))
+	if errors.Is(err, service.ErrPostNotFound) {
+		utils.SendError(w, http.StatusNotFound, "Post not found", nil)
+		return
+	}
+	if err != nil {
+		utils.SendError(w, http.StatusInternalServerError, "Failed to build post metadata", err.Error())
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessagePostMeta, meta)
+}
@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/wikasdude/blog-backend/config"
+	"github.com/wikasdude/blog-backend/model"
+	service "github.com/wikasdude/blog-backend/services"
+	"github.com/wikasdude/blog-backend/theme"
+	"github.com/wikasdude/blog-backend/utils"
+)
+
+// SiteController serves the public site as HTML pages rendered from a
+// theme. In dev mode, error pages show what went wrong.
+type SiteController struct {
+	Service *service.SiteService
+	Theme   *theme.Theme
+	SiteURL string
+	Dev     bool
+}
+
+func NewSiteController(s *service.SiteService, t *theme.Theme, dev bool) *SiteController {
+	return &SiteController{Service: s, Theme: t, SiteURL: config.GetEnv(config.EnvSiteURL, ""), Dev: dev}
+}
+
+// render renders page into a buffer first, so that a template failing half
+// way shows an error page instead of half a page.
+func (c *SiteController) render(w http.ResponseWriter, r *http.Request, status int, name string, page *model.SitePage) {
+	info, err := c.Service.Info(utils.SiteURL(r, c.SiteURL))
+	if err != nil {
+		if name != theme.PageError {
+			c.renderError(w, r, err)
+			return
+		}
+		// show the error page without navigation rather than fail again
+		log.Println("site: loading site info:", err)
+	}
+	page.Site = info
+	var buf bytes.Buffer
+	if err := c.Theme.Render(&buf, name, page); err != nil {
+		if name == theme.PageError {
+			log.Println("site: rendering error page:", err)
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		c.renderError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		w.Write(buf.Bytes())
+	}
+}
+
+// renderError shows the error page: not found for ErrPageNotFound and
+// ErrPostNotFound, a server error for anything else.
+func (c *SiteController) renderError(w http.ResponseWriter, r *http.Request, err error) {
+	page := &model.SitePage{Robots: "noindex", Error: &model.SiteError{
+		Status:  http.StatusNotFound,
+		Message: "There is nothing at this address.",
+	}}
+	if !errors.Is(err, service.ErrPageNotFound) && !errors.Is(err, service.ErrPostNotFound) {
+		log.Printf("site: %s: %v", r.URL.Path, err)
+		page.Error.Status = http.StatusInternalServerError
+		page.Error.Message = "The page could not be shown. Please try again later."
+		if c.Dev {
+			page.Error.Message = err.Error()
+		}
+	}
+	c.render(w, r, page.Error.Status, theme.PageError, page)
+}
+
+// NotFound shows the not found page.
+func (c *SiteController) NotFound(w http.ResponseWriter, r *http.Request) {
+	c.renderError(w, r, service.ErrPageNotFound)
+}
+
+func (c *SiteController) renderListing(w http.ResponseWriter, r *http.Request, name string, listing *model.SiteListing, err error) {
+	if err != nil {
+		c.renderError(w, r, err)
+		return
+	}
+	page := &model.SitePage{
+		Title:        listing.Heading,
+		Description:  listing.Description,
+		CanonicalURL: strings.TrimRight(utils.SiteURL(r, c.SiteURL), "/") + listing.Path,
+		FeedPath:     listing.FeedPath,
+		Listing:      listing,
+	}
+	if r.URL.Query().Get("cursor") != "" {
+		// later pages are reached from the first, which is the one to index
+		page.Robots = "noindex, follow"
+	}
+	c.render(w, r, http.StatusOK, name, page)
+}
+
+// Home shows the latest posts.
+func (c *SiteController) Home(w http.ResponseWriter, r *http.Request) {
+	listing, err := c.Service.Home(r.URL.Query().Get("cursor"))
+	c.renderListing(w, r, theme.PageHome, listing, err)
+}
+
+// Post shows a published post.
+func (c *SiteController) Post(w http.ResponseWriter, r *http.Request, id int) {
+	post, meta, err := c.Service.Post(id, utils.SiteURL(r, c.SiteURL))
+	if err != nil {
+		c.renderError(w, r, err)
+		return
+	}
+	c.render(w, r, http.StatusOK, theme.PagePost, &model.SitePage{
+		Title:        meta.Title,
+		Description:  meta.Description,
+		CanonicalURL: meta.CanonicalURL,
+		Robots:       meta.Robots,
+		Meta:         meta,
+		Post:         post,
+	})
+}
+
+// Category shows the posts in a category.
+func (c *SiteController) Category(w http.ResponseWriter, r *http.Request, slug string) {
+	listing, err := c.Service.CategoryArchive(slug, r.URL.Query().Get("cursor"))
+	c.renderListing(w, r, theme.PageArchive, listing, err)
+}
+
+// Tag shows the posts with a tag.
+func (c *SiteController) Tag(w http.ResponseWriter, r *http.Request, slug string) {
+	listing, err := c.Service.TagArchive(slug, r.URL.Query().Get("cursor"))
+	c.renderListing(w, r, theme.PageArchive, listing, err)
+}
+
+// Author shows the posts by an author.
+func (c *SiteController) Author(w http.ResponseWriter, r *http.Request, id int) {
+	listing, err := c.Service.AuthorArchive(id, r.URL.Query().Get("cursor"))
+	c.renderListing(w, r, theme.PageArchive, listing, err)
+}
+
+// Search shows the results of a full-text search.
+func (c *SiteController) Search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	search, err := c.Service.Search(query.Get("q"), page)
+	if err != nil {
+		c.renderError(w, r, err)
+		return
+	}
+	c.render(w, r, http.StatusOK, theme.PageSearch, &model.SitePage{Title: "Search", Robots: "noindex, follow", Search: search})
+}
+
+// Static serves the theme's stylesheets, scripts and images.
+func (c *SiteController) Static(w http.ResponseWriter, r *http.Request, name string) {
+	c.Theme.ServeStatic(w, r, name)
+}
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+
+	"github.com/wikasdude/blog-backend/config"
+	service "github.com/wikasdude/blog-backend/services"
+	"github.com/wikasdude/blog-backend/utils"
+)
+
+type SitemapController struct {
+	Service *service.SitemapService
+	SiteURL string
+}
+
+func NewSitemapController(s *service.SitemapService) *SitemapController {
+	return &SitemapController{Service: s, SiteURL: config.GetEnv(config.EnvSiteURL, "")}
+}
+
+// Sitemap godoc
+// @Summary XML sitemap
+// @Description Lists the home page, every published post and the category, tag and author pages that have published posts, with lastmod from the latest post update. Past 50,000 URLs, /sitemap.xml becomes a sitemap index of /sitemaps/{page}.xml. Honours If-None-Match and If-Modified-Since.
+// @Tags seo
+// @Produce xml
+// @Success 200 {string} string
+// @Success 304 {string} string
+// @Router /sitemap.xml [get]
+func (c *SitemapController) Sitemap(w http.ResponseWriter, r *http.Request, page int) {
+	body, modTime, err := c.Service.Sitemap(utils.SiteURL(r, c.SiteURL), page)
+	if errors.Is(err, service.ErrSitemapNotFound) {
+		utils.SendError(w, http.StatusNotFound, config.MessageNoSitemap, nil)
+		return
+	}
+	if err != nil {
+		utils.SendError(w, http.StatusInternalServerError, "Failed to build sitemap", err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Header().Set("ETag", utils.ContentETag(body))
+	http.ServeContent(w, r, "", modTime, bytes.NewReader(body))
+}
+
+// Robots godoc
+// @Summary robots.txt
+// @Description Generated from ROBOTS_ALLOW, ROBOTS_DISALLOW and ROBOTS_BLOCK_ALL, and points crawlers at the sitemap.
+// @Tags seo
+// @Produce plain
+// @Success 200 {string} string
+// @Router /robots.txt [get]
+func (c *SitemapController) Robots(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(c.Service.Robots(utils.SiteURL(r, c.SiteURL)))
+}
@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/wikasdude/blog-backend/config"
+	"github.com/wikasdude/blog-backend/model"
+	service "github.com/wikasdude/blog-backend/services"
+	"github.com/wikasdude/blog-backend/utils"
+)
+
+type StreamController struct {
+	Service   *service.StreamService
+	Heartbeat time.Duration
+}
+
+func NewStreamController(s *service.StreamService) *StreamController {
+	heartbeat := config.GetEnvDuration(config.EnvStreamHeartbeat, config.DefaultStreamHeartbeat)
+	if heartbeat <= 0 {
+		heartbeat = config.DefaultStreamHeartbeat
+	}
+	return &StreamController{Service: s, Heartbeat: heartbeat}
+}
+
+// writeStreamEvent writes one event in text/event-stream framing.
+func writeStreamEvent(w http.ResponseWriter, e *model.StreamEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
+
+// Stream godoc
+// @Summary Stream post and comment events
+// @Description Server-Sent Events stream of post.published, post.updated, post.deleted, comment.created, comment.updated and comment.deleted events on published posts. Each event carries an id; reconnect with the Last-Event-ID header (or last_event_id) to receive what was missed. Idle streams get a keepalive comment every STREAM_HEARTBEAT.
+// @Tags stream
+// @Produce text/event-stream
+// @Param topic query []string false "all (default), category:<id>, author:<id> or post:<id>; repeat or comma separate for several" collectionFormat(multi)
+// @Param last_event_id query int false "Resume after this event id, for clients that cannot send Last-Event-ID"
+// @Success 200 {object} model.StreamEvent
+// @Failure 400 {object} utils.APIResponse
+// @Router /api/stream [get]
+func (c *StreamController) Stream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		utils.SendError(w, http.StatusInternalServerError, "Streaming is not supported", nil)
+		return
+	}
+	topics, err := service.ParseStreamTopics(r.URL.Query()["topic"])
+	if err != nil {
+		utils.SendError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	resume := r.Header.Get("Last-Event-ID")
+	if resume == "" {
+		resume = r.URL.Query().Get("last_event_id")
+	}
+	var lastID int64
+	if resume != "" {
+		if lastID, err = strconv.ParseInt(resume, 10, 64); err != nil || lastID < 0 {
+			utils.SendError(w, http.StatusBadRequest, "Invalid Last-Event-ID", resume)
+			return
+		}
+	}
+
+	// subscribe before replaying so nothing published in between is lost
+	sub := c.Service.Subscribe(topics)
+	defer c.Service.Unsubscribe(sub)
+
+	replayed := map[int64]bool{}
+	var backlog []*model.StreamEvent
+	if resume != "" {
+		if backlog, err = c.Service.Replay(lastID, topics); err != nil {
+			utils.SendError(w, http.StatusInternalServerError, "Failed to replay events", err.Error())
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", 5000)
+	for _, e := range backlog {
+		if writeStreamEvent(w, e) != nil {
+			return
+		}
+		replayed[e.ID] = true
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(c.Heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case e, ok := <-sub.C:
+			if !ok {
+				// dropped for falling behind; the client resumes with Last-Event-ID
+				return
+			}
+			if replayed[e.ID] {
+				continue
+			}
+			if writeStreamEvent(w, e) != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/wikasdude/blog-backend/config"
+	"github.com/wikasdude/blog-backend/model"
+	"github.com/wikasdude/blog-backend/utils"
+)
+
+// ListTrash godoc
+// @Summary List trashed posts
+// @Description Lists posts in the trash, most recently deleted first. Authors see their own trashed posts; admins see everyone's and may filter by author_id.
+// @Tags trash
+// @Produce json
+// @Param author_id query int false "Only this author's posts (admins only)"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size (max 100)"
+// @Success 200 {object} model.TrashPage
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/trash/posts [get]
+func (c *PostController) ListTrash(w http.ResponseWriter, r *http.Request) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	page, limit, ok := parsePageParams(w, query)
+	if !ok {
+		return
+	}
+
+	authorID := claims.UserID
+	if claims.Role == "admin" {
+		authorID = 0
+		if v := query.Get("author_id"); v != "" {
+			if authorID, err = strconv.Atoi(v); err != nil {
+				utils.SendError(w, http.StatusBadRequest, "Invalid author_id", v)
+				return
+			}
+		}
+	}
+
+	trash, err := c.postService.ListTrash(authorID, page, limit)
+	if err != nil {
+		utils.SendError(w, http.StatusInternalServerError, "Failed to fetch trash", err.Error())
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageTrashFetched, trash)
+}
+
+// RestorePost godoc
+// @Summary Restore a trashed post
+// @Description Allows the owner or an admin to take a post back out of the trash
+// @Tags trash
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 200 {object} model.Post
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/trash/posts/{id}/restore [post]
+func (c *PostController) RestorePost(w http.ResponseWriter, r *http.Request, id int) {
+	if _, ok := c.trashedPostForOwner(w, r, id); !ok {
+		return
+	}
+
+	post, err := c.postService.RestorePost(id)
+	if err != nil {
+		utils.SendError(w, http.StatusInternalServerError, "Failed to restore post", err.Error())
+		return
+	}
+	w.Header().Set("ETag", utils.VersionETag(post.Version))
+	utils.SendSuccess(w, http.StatusOK, config.MessagePostRestored, post)
+}
+
+// PurgePost godoc
+// @Summary Permanently delete a trashed post
+// @Description Allows the owner or an admin to permanently delete a post that is in the trash. This cannot be undone.
+// @Tags trash
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 200 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/trash/posts/{id} [delete]
+func (c *PostController) PurgePost(w http.ResponseWriter, r *http.Request, id int) {
+	if _, ok := c.trashedPostForOwner(w, r, id); !ok {
+		return
+	}
+
+	if err := c.postService.PurgePost(id); err != nil {
+		utils.SendError(w, http.StatusInternalServerError, "Failed to delete post", err.Error())
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessagePostPurged, nil)
+}
+
+// trashedPostForOwner loads a trashed post and checks that the caller owns
+// it or is an admin. On failure the error response has already been written.
+func (c *PostController) trashedPostForOwner(w http.ResponseWriter, r *http.Request, id int) (*model.Post, bool) {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	post, err := c.postService.GetTrashedPost(id)
+	if err != nil {
+		utils.SendError(w, http.StatusNotFound, "Post not found in trash", err.Error())
+		return nil, false
+	}
+	if post.UserID != claims.UserID && claims.Role != "admin" {
+		utils.SendError(w, http.StatusForbidden, "You are not allowed to change this post", nil)
+		return nil, false
+	}
+	return post, true
+}
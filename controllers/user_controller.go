@@ -2,6 +2,7 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -18,7 +19,9 @@ type UserController struct {
 
 // DTO object
 type RegisterUserInput struct {
-	Name     string `json:"username"`
+	Name string `json:"username"`
+	// Handle is what others @mention the user by; derived from the username when left out
+	Handle   string `json:"handle"`
 	Email    string `json:"email"`
 	Password string `json:"password"`
 	Role     string `json:"role"`
@@ -30,7 +33,7 @@ func NewUserController(userService service.UserService) *UserController {
 
 // RegisterUser godoc
 // @Summary Register a new user
-// @Description Registers a new user with the provided name, email, and password.
+// @Description Registers a new user with the provided name, email, and password. The optional handle, which others @mention the user by, must be unique; without one it is derived from the name.
 // @Tags users
 // @Accept  json
 // @Produce  json
@@ -39,6 +42,8 @@ func NewUserController(userService service.UserService) *UserController {
 // @Failure 400 {object} map[string]string "Invalid request payload"
 // @Failure 400 {object} map[string]string "All fields (username, email, password) are required"
 // @Failure 400 {object} map[string]string "Invalid email format"
+// @Failure 400 {object} map[string]string "Invalid handle"
+// @Failure 409 {object} map[string]string "Handle is already taken"
 // @Failure 500 {object} map[string]string "Error hashing password"
 // @Failure 500 {object} map[string]string "Failed to create user"
 // @Router /users [post]
@@ -69,12 +74,21 @@ func (c *UserController) RegisterUser(w http.ResponseWriter, r *http.Request) {
 	}
 	user := model.User{
 		Name:     input.Name,
+		Handle:   input.Handle,
 		Email:    input.Email,
 		Password: hashedPassword,
 		Role:     role,
 	}
 	fmt.Println("User to create:", user)
 	err = c.userService.RegisterUser(&user)
+	if errors.Is(err, service.ErrInvalidHandle) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, service.ErrHandleTaken) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
 		fmt.Print(err)
 		http.Error(w, "Failed to create user", http.StatusInternalServerError)
@@ -182,7 +196,16 @@ func (c *UserController) UpdateUser(w http.ResponseWriter, r *http.Request, idSt
 
 	user.ID = id
 
-	if err := c.userService.UpdateUser(&user); err != nil {
+	err = c.userService.UpdateUser(&user)
+	if errors.Is(err, service.ErrInvalidHandle) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, service.ErrHandleTaken) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
 		fmt.Println("line no 63:", err)
 		http.Error(w, fmt.Sprintf("Failed to update user %s", err), http.StatusInternalServerError)
 		return
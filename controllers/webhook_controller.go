@@ -0,0 +1,233 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/wikasdude/blog-backend/config"
+	"github.com/wikasdude/blog-backend/model"
+	service "github.com/wikasdude/blog-backend/services"
+	"github.com/wikasdude/blog-backend/utils"
+)
+
+type WebhookController struct {
+	Service *service.WebhookService
+}
+
+func NewWebhookController(s *service.WebhookService) *WebhookController {
+	return &WebhookController{Service: s}
+}
+
+// sendWebhookError maps webhook service errors onto HTTP status codes.
+func sendWebhookError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrWebhookNotFound), errors.Is(err, service.ErrDeliveryNotFound):
+		utils.SendError(w, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, service.ErrWebhookInvalid):
+		utils.SendError(w, http.StatusBadRequest, err.Error(), nil)
+	default:
+		utils.SendError(w, http.StatusInternalServerError, message, err.Error())
+	}
+}
+
+// requireAdmin returns the caller's claims, or writes 401 or 403 and returns
+// nil when the caller is not an admin.
+func requireAdmin(w http.ResponseWriter, r *http.Request) *utils.Claims {
+	claims, err := utils.ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil
+	}
+	if claims.Role != "admin" {
+		utils.SendError(w, http.StatusForbidden, config.MessageAdminOnly, nil)
+		return nil
+	}
+	return claims
+}
+
+// ListWebhooks godoc
+// @Summary List webhooks
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} model.Webhook
+// @Failure 403 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/webhooks [get]
+func (c *WebhookController) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	if requireAdmin(w, r) == nil {
+		return
+	}
+
+	webhooks, err := c.Service.Webhooks()
+	if err != nil {
+		sendWebhookError(w, "Failed to fetch webhooks", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageWebhooks, webhooks)
+}
+
+// CreateWebhook godoc
+// @Summary Create a webhook
+// @Description Subscribes a URL to post.created, post.published, post.deleted, user.registered and/or comment.created. Each event is POSTed as JSON with X-Webhook-Event, X-Webhook-Delivery, X-Webhook-Timestamp and X-Webhook-Signature headers; the signature is "sha256=" and the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook's secret. The secret is only returned here and when rotated. Failed deliveries are retried with exponential backoff, and the webhook is disabled after WEBHOOK_DISABLE_AFTER failures in a row.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param webhook body model.WebhookRequest true "Webhook"
+// @Success 201 {object} model.Webhook
+// @Failure 400 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/webhooks [post]
+func (c *WebhookController) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	claims := requireAdmin(w, r)
+	if claims == nil {
+		return
+	}
+
+	var input model.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.SendError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	webhook, err := c.Service.CreateWebhook(input, claims.UserID)
+	if err != nil {
+		sendWebhookError(w, "Failed to create webhook", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusCreated, config.MessageWebhookAdded, webhook)
+}
+
+// GetWebhook godoc
+// @Summary Get a webhook
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} model.Webhook
+// @Failure 404 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/webhooks/{id} [get]
+func (c *WebhookController) GetWebhook(w http.ResponseWriter, r *http.Request, id int) {
+	if requireAdmin(w, r) == nil {
+		return
+	}
+
+	webhook, err := c.Service.GetWebhook(id)
+	if err != nil {
+		sendWebhookError(w, "Failed to fetch webhook", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageWebhook, webhook)
+}
+
+// UpdateWebhook godoc
+// @Summary Update a webhook
+// @Description Changes the fields sent. Setting active to true re-enables a webhook that was disabled for failing and resets its failure count; rotate_secret issues a new secret, returned in the response.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param webhook body model.WebhookRequest true "Fields to change"
+// @Success 200 {object} model.Webhook
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/webhooks/{id} [patch]
+func (c *WebhookController) UpdateWebhook(w http.ResponseWriter, r *http.Request, id int) {
+	if requireAdmin(w, r) == nil {
+		return
+	}
+
+	var input model.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.SendError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	webhook, err := c.Service.UpdateWebhook(id, input)
+	if err != nil {
+		sendWebhookError(w, "Failed to update webhook", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageWebhookSaved, webhook)
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook
+// @Description Deletes the webhook together with its delivery log.
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/webhooks/{id} [delete]
+func (c *WebhookController) DeleteWebhook(w http.ResponseWriter, r *http.Request, id int) {
+	if requireAdmin(w, r) == nil {
+		return
+	}
+
+	if err := c.Service.DeleteWebhook(id); err != nil {
+		sendWebhookError(w, "Failed to delete webhook", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageWebhookGone, nil)
+}
+
+// ListDeliveries godoc
+// @Summary List a webhook's deliveries
+// @Description The delivery log, newest first, with each delivery's payload, attempts, last response and, while pending, when it is tried next.
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param status query string false "pending, succeeded or failed"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size (max 100)"
+// @Success 200 {object} model.WebhookDeliveryPage
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/webhooks/{id}/deliveries [get]
+func (c *WebhookController) ListDeliveries(w http.ResponseWriter, r *http.Request, id int) {
+	if requireAdmin(w, r) == nil {
+		return
+	}
+	query := r.URL.Query()
+	page, limit, ok := parsePageParams(w, query)
+	if !ok {
+		return
+	}
+
+	deliveries, err := c.Service.Deliveries(id, query.Get("status"), page, limit)
+	if err != nil {
+		sendWebhookError(w, "Failed to fetch webhook deliveries", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusOK, config.MessageDeliveries, deliveries)
+}
+
+// ReplayDelivery godoc
+// @Summary Replay a webhook delivery
+// @Description Queues the delivery's payload to be sent again as a new delivery. The event id in the payload is unchanged, so receivers can recognise repeats.
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param deliveryId path int true "Delivery ID"
+// @Success 202 {object} map[string]int64
+// @Failure 404 {object} utils.APIResponse
+// @Security BearerAuth
+// @Router /api/webhooks/{id}/deliveries/{deliveryId}/replay [post]
+func (c *WebhookController) ReplayDelivery(w http.ResponseWriter, r *http.Request, id int, deliveryID int64) {
+	if requireAdmin(w, r) == nil {
+		return
+	}
+
+	replayID, err := c.Service.Replay(id, deliveryID)
+	if err != nil {
+		sendWebhookError(w, "Failed to replay webhook delivery", err)
+		return
+	}
+	utils.SendSuccess(w, http.StatusAccepted, config.MessageReplayQueued, map[string]int64{"delivery_id": replayID})
+}
@@ -0,0 +1,100 @@
+// Package events is a small in-process publish/subscribe bus for domain
+// events. Services publish what happened; subscribers such as the
+// notification center react to it without the services knowing about them.
+package events
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	// PostCreated is published when a post is saved for the first time,
+	// whatever its status
+	PostCreated = "post.created"
+	// PostPublished is published when a post becomes visible to readers
+	PostPublished = "post.published"
+	// PostUpdated is published when any other change is saved to a post,
+	// including a restore from the trash
+	PostUpdated = "post.updated"
+	// PostDeleted is published when a post is moved to the trash
+	PostDeleted = "post.deleted"
+	// CommentCreated is published when a comment becomes visible, either on
+	// creation or when a moderator approves it
+	CommentCreated = "comment.created"
+	// CommentUpdated is published when a visible comment is edited
+	CommentUpdated = "comment.updated"
+	// CommentDeleted is published when a visible comment is deleted
+	CommentDeleted = "comment.deleted"
+	// ReactionAdded is published when a user reacts to a post
+	ReactionAdded = "reaction.added"
+	// UserFollowed is published when a user follows an author
+	UserFollowed = "user.followed"
+	// UserRegistered is published when a new account is created
+	UserRegistered = "user.registered"
+)
+
+// Event describes something that happened. Fields that do not apply to an
+// event type are left zero.
+type Event struct {
+	Type      string
+	ActorID   int
+	PostID    int
+	CommentID int
+	// UserID is the user acted upon, e.g. the author who was followed
+	UserID   int
+	Reaction string
+	At       time.Time
+}
+
+// Handler reacts to an event. Handlers run on the publishing goroutine, so
+// anything slow should be handed off.
+type Handler func(Event)
+
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: map[string][]Handler{}}
+}
+
+// Subscribe registers h for events of the given type; "*" receives every
+// event. Subscribing on a nil bus does nothing.
+func (b *Bus) Subscribe(eventType string, h Handler) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], h)
+}
+
+// Publish hands e to every subscriber in the order they subscribed. A
+// handler that panics is logged and does not stop the others. Publishing on
+// a nil bus does nothing.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+	b.mu.RLock()
+	handlers := append(append([]Handler{}, b.handlers[e.Type]...), b.handlers["*"]...)
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("events: %s handler panicked: %v", e.Type, r)
+				}
+			}()
+			h(e)
+		}()
+	}
+}
@@ -0,0 +1,85 @@
+// Package feed writes syndication documents (RSS 2.0, Atom 1.0 and JSON
+// Feed 1.1) from a format-neutral description of a feed.
+package feed
+
+import (
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"time"
+)
+
+// Formats a feed can be written in
+const (
+	FormatRSS  = "rss"
+	FormatAtom = "atom"
+	FormatJSON = "json"
+)
+
+// Content types of the formats
+const (
+	ContentTypeRSS  = "application/rss+xml; charset=utf-8"
+	ContentTypeAtom = "application/atom+xml; charset=utf-8"
+	ContentTypeJSON = "application/feed+json; charset=utf-8"
+)
+
+// Feed describes a feed independently of its format.
+type Feed struct {
+	Title       string
+	Description string
+	// Link is the web page the feed belongs to; FeedURL is the feed itself
+	Link    string
+	FeedURL string
+	// Updated is when anything in the feed last changed
+	Updated time.Time
+	Items   []Item
+}
+
+// Item is one entry of a feed. ID must never change once published; feed
+// readers use it to tell new entries from ones they have already seen.
+type Item struct {
+	ID      string
+	Title   string
+	Link    string
+	Summary string
+	// ContentHTML is the full entry; left empty, readers only get Summary
+	ContentHTML string
+	Author      string
+	Categories  []string
+	Image       string
+	Published   time.Time
+	Updated     time.Time
+}
+
+// IsValidFormat reports whether format is one of the supported feed formats.
+func IsValidFormat(format string) bool {
+	return format == FormatRSS || format == FormatAtom || format == FormatJSON
+}
+
+// Write encodes f in the given format and returns it with its content type.
+func Write(format string, f *Feed) ([]byte, string, error) {
+	switch format {
+	case FormatRSS:
+		b, err := RSS(f)
+		return b, ContentTypeRSS, err
+	case FormatAtom:
+		b, err := Atom(f)
+		return b, ContentTypeAtom, err
+	case FormatJSON:
+		b, err := JSON(f)
+		return b, ContentTypeJSON, err
+	}
+	return nil, "", fmt.Errorf("unsupported feed format %q", format)
+}
+
+// imageType guesses an image's media type from its URL.
+func imageType(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err == nil {
+		if t := mime.TypeByExtension(path.Ext(u.Path)); t != "" {
+			return t
+		}
+	}
+	return "image/jpeg"
+}
@@ -0,0 +1,110 @@
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+type rssDocument struct {
+	XMLName      xml.Name   `xml:"rss"`
+	Version      string     `xml:"version,attr"`
+	NSAtom       string     `xml:"xmlns:atom,attr"`
+	NSContent    string     `xml:"xmlns:content,attr"`
+	NSDublinCore string     `xml:"xmlns:dc,attr"`
+	Channel      rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Self          rssLink   `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	GUID        rssGUID       `xml:"guid"`
+	PubDate     string        `xml:"pubDate"`
+	Creator     string        `xml:"dc:creator,omitempty"`
+	Categories  []string      `xml:"category"`
+	Description string        `xml:"description"`
+	Content     *rssContent   `xml:"content:encoded"`
+	Enclosure   *rssEnclosure `xml:"enclosure"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssContent struct {
+	Value string `xml:",cdata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int    `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+func rssDate(t time.Time) string {
+	return t.UTC().Format(time.RFC1123Z)
+}
+
+// RSS writes f as an RSS 2.0 document. RSS has no per-item update time, so
+// an edited item only changes its content; the channel's lastBuildDate
+// carries f.Updated.
+func RSS(f *Feed) ([]byte, error) {
+	doc := rssDocument{
+		Version:      "2.0",
+		NSAtom:       "http://www.w3.org/2005/Atom",
+		NSContent:    "http://purl.org/rss/1.0/modules/content/",
+		NSDublinCore: "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:       f.Title,
+			Link:        f.Link,
+			Description: f.Description,
+			Self:        rssLink{Href: f.FeedURL, Rel: "self", Type: "application/rss+xml"},
+			Items:       []rssItem{},
+		},
+	}
+	if !f.Updated.IsZero() {
+		doc.Channel.LastBuildDate = rssDate(f.Updated)
+	}
+	for _, it := range f.Items {
+		item := rssItem{
+			Title:       it.Title,
+			Link:        it.Link,
+			GUID:        rssGUID{IsPermaLink: it.ID == it.Link, Value: it.ID},
+			PubDate:     rssDate(it.Published),
+			Creator:     it.Author,
+			Categories:  it.Categories,
+			Description: it.Summary,
+		}
+		if it.ContentHTML != "" {
+			item.Content = &rssContent{Value: it.ContentHTML}
+		}
+		if it.Image != "" {
+			item.Enclosure = &rssEnclosure{URL: it.Image, Type: imageType(it.Image)}
+		}
+		doc.Channel.Items = append(doc.Channel.Items, item)
+	}
+	return marshalXML(doc)
+}
+
+func marshalXML(doc interface{}) ([]byte, error) {
+	b, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(b, '\n')...), nil
+}
@@ -0,0 +1,93 @@
+package imaging
+
+import (
+	"image"
+	"math"
+	"strings"
+)
+
+const base83 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+func encode83(value, length int) string {
+	var b strings.Builder
+	for i := length - 1; i >= 0; i-- {
+		b.WriteByte(base83[value/int(math.Pow(83, float64(i)))%83])
+	}
+	return b.String()
+}
+
+func srgbToLinear(v uint8) float64 {
+	c := float64(v) / 255
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(v float64) int {
+	v = math.Max(0, math.Min(1, v))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+// signPow raises v's magnitude to exp, keeping its sign.
+func signPow(v, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(v), exp), v)
+}
+
+// BlurHash encodes img as a BlurHash (https://blurha.sh) with x by y
+// components, each from 1 to 9. It reads every pixel, so pass a thumbnail.
+func BlurHash(img *image.RGBA, x, y int) string {
+	x, y = max(1, min(9, x)), max(1, min(9, y))
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if w == 0 || h == 0 {
+		return ""
+	}
+
+	factors := make([][3]float64, 0, x*y)
+	for j := 0; j < y; j++ {
+		for i := 0; i < x; i++ {
+			var f [3]float64
+			for py := 0; py < h; py++ {
+				basisY := math.Cos(math.Pi * float64(j) * float64(py) / float64(h))
+				for px := 0; px < w; px++ {
+					basis := basisY * math.Cos(math.Pi*float64(i)*float64(px)/float64(w))
+					p := img.Pix[img.PixOffset(img.Rect.Min.X+px, img.Rect.Min.Y+py):]
+					f[0] += basis * srgbToLinear(p[0])
+					f[1] += basis * srgbToLinear(p[1])
+					f[2] += basis * srgbToLinear(p[2])
+				}
+			}
+			scale := 2 / float64(w*h)
+			if i == 0 && j == 0 {
+				scale = 1 / float64(w*h)
+			}
+			factors = append(factors, [3]float64{f[0] * scale, f[1] * scale, f[2] * scale})
+		}
+	}
+
+	dc, ac := factors[0], factors[1:]
+	hash := encode83((x-1)+(y-1)*9, 1)
+	maxValue := 1.0
+	if len(ac) > 0 {
+		var actualMax float64
+		for _, f := range ac {
+			actualMax = math.Max(actualMax, math.Max(math.Abs(f[0]), math.Max(math.Abs(f[1]), math.Abs(f[2]))))
+		}
+		quantised := max(0, min(82, int(math.Floor(actualMax*166-0.5))))
+		maxValue = float64(quantised+1) / 166
+		hash += encode83(quantised, 1)
+	} else {
+		hash += encode83(0, 1)
+	}
+	hash += encode83(linearToSRGB(dc[0])<<16+linearToSRGB(dc[1])<<8+linearToSRGB(dc[2]), 4)
+	for _, f := range ac {
+		q := func(v float64) int {
+			return max(0, min(18, int(math.Floor(signPow(v/maxValue, 0.5)*9+9.5))))
+		}
+		hash += encode83(q(f[0])*19*19+q(f[1])*19+q(f[2]), 2)
+	}
+	return hash
+}
@@ -0,0 +1,156 @@
+// Package imaging prepares uploaded images for the web: it strips metadata
+// such as EXIF GPS positions without re-encoding, and makes resized variants
+// and blurhash placeholders. JPEG, PNG, GIF and WebP can be read, but only
+// JPEG and PNG can be written; other output formats such as WebP or AVIF plug
+// in through RegisterEncoder.
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // registered for Decode
+	"image/jpeg"
+	"image/png"
+	"io"
+	"sync"
+
+	_ "golang.org/x/image/webp" // registered for Decode
+)
+
+var ErrUnsupported = errors.New("unsupported image format")
+
+// Info describes an image without decoding its pixels.
+type Info struct {
+	// Format is "jpeg", "png", "gif" or "webp"
+	Format string
+	// Width and Height are the stored dimensions, before Orientation is applied
+	Width, Height int
+	// Orientation is the EXIF orientation, 1 to 8; 1 means upright
+	Orientation int
+}
+
+// Transposed reports whether Orientation swaps width and height.
+func (i Info) Transposed() bool {
+	return i.Orientation >= 5
+}
+
+// DisplaySize is the size the image appears at once Orientation is applied.
+func (i Info) DisplaySize() (width, height int) {
+	if i.Transposed() {
+		return i.Height, i.Width
+	}
+	return i.Width, i.Height
+}
+
+// Inspect reads the format, size and orientation of an encoded image.
+func Inspect(data []byte) (Info, error) {
+	if isWebP(data) {
+		w, h, err := webpSize(data)
+		return Info{Format: "webp", Width: w, Height: h, Orientation: 1}, err
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		if errors.Is(err, image.ErrFormat) {
+			return Info{}, ErrUnsupported
+		}
+		return Info{}, err
+	}
+	info := Info{Format: format, Width: cfg.Width, Height: cfg.Height, Orientation: 1}
+	if format == "jpeg" {
+		info.Orientation = jpegOrientation(data)
+	}
+	return info, nil
+}
+
+// webpSize reads the canvas size from the first chunk of a WebP file.
+func webpSize(data []byte) (int, int, error) {
+	if len(data) < 30 {
+		return 0, 0, ErrUnsupported
+	}
+	chunk := data[12:]
+	switch string(chunk[:4]) {
+	case "VP8X":
+		w := int(chunk[12]) | int(chunk[13])<<8 | int(chunk[14])<<16
+		h := int(chunk[15]) | int(chunk[16])<<8 | int(chunk[17])<<16
+		return w + 1, h + 1, nil
+	case "VP8L":
+		bits := binary.LittleEndian.Uint32(chunk[9:13])
+		return int(bits&0x3fff) + 1, int(bits>>14&0x3fff) + 1, nil
+	case "VP8 ":
+		return int(binary.LittleEndian.Uint16(chunk[14:16]) & 0x3fff), int(binary.LittleEndian.Uint16(chunk[16:18]) & 0x3fff), nil
+	}
+	return 0, 0, ErrUnsupported
+}
+
+// Encoder writes images in one format.
+type Encoder struct {
+	ContentType string
+	// Ext is the file extension, with its dot
+	Ext string
+	// Encode writes img; quality runs from 1 to 100 for lossy formats
+	Encode func(w io.Writer, img image.Image, quality int) error
+}
+
+var (
+	encodersMu sync.RWMutex
+	encoders   = map[string]Encoder{
+		"jpeg": {ContentType: "image/jpeg", Ext: ".jpg", Encode: encodeJPEG},
+		"png":  {ContentType: "image/png", Ext: ".png", Encode: encodePNG},
+	}
+)
+
+// RegisterEncoder makes format available as an output format, e.g. "webp"
+// backed by a cgo or WebAssembly encoder.
+func RegisterEncoder(format string, e Encoder) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	encoders[format] = e
+}
+
+// LookupEncoder returns the encoder registered for format.
+func LookupEncoder(format string) (Encoder, bool) {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	e, ok := encoders[format]
+	return e, ok
+}
+
+// Fallback is the built-in format variants of an image in format are written
+// in when no encoder is registered for it: JPEG for opaque images and PNG for
+// ones with transparency, which JPEG cannot keep.
+func Fallback(format string, opaque bool) string {
+	if _, ok := LookupEncoder(format); ok {
+		return format
+	}
+	if opaque {
+		return "jpeg"
+	}
+	return "png"
+}
+
+// Opaque reports whether img has no transparent pixels.
+func Opaque(img image.Image) bool {
+	o, ok := img.(interface{ Opaque() bool })
+	return ok && o.Opaque()
+}
+
+// encodeJPEG flattens transparency onto white, which is how most viewers
+// show transparent images, rather than JPEG's black.
+func encodeJPEG(w io.Writer, img image.Image, quality int) error {
+	if !Opaque(img) {
+		flat := image.NewRGBA(img.Bounds())
+		draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+		img = flat
+	}
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+}
+
+func encodePNG(w io.Writer, img image.Image, _ int) error {
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	return enc.Encode(w, img)
+}
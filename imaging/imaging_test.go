@@ -0,0 +1,83 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	var gifData bytes.Buffer
+	if err := gif.Encode(&gifData, image.NewPaletted(image.Rect(0, 0, 5, 7), color.Palette{color.Black, color.White}), nil); err != nil {
+		t.Fatal(err)
+	}
+	rotated := testJPEG(t, jpegSegment(0xE1, append([]byte("Exif\x00\x00"), exifPayload(binary.BigEndian, 8)...)))
+
+	tests := []struct {
+		name string
+		data []byte
+		want Info
+	}{
+		{"jpeg", testJPEG(t), Info{Format: "jpeg", Width: 4, Height: 2, Orientation: 1}},
+		{"rotated jpeg", rotated, Info{Format: "jpeg", Width: 4, Height: 2, Orientation: 8}},
+		{"png", testPNG(t), Info{Format: "png", Width: 3, Height: 3, Orientation: 1}},
+		{"gif", gifData.Bytes(), Info{Format: "gif", Width: 5, Height: 7, Orientation: 1}},
+		{"lossless webp", riff(tinyVP8L), Info{Format: "webp", Width: 1, Height: 1, Orientation: 1}},
+		{"extended webp", riff(vp8x(0x10, 300, 20000), tinyVP8L), Info{Format: "webp", Width: 300, Height: 20000, Orientation: 1}},
+	}
+	for _, tt := range tests {
+		got, err := Inspect(tt.data)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: Inspect = %+v, %v, want %+v", tt.name, got, err, tt.want)
+		}
+	}
+
+	for _, data := range [][]byte{[]byte("%PDF-1.7"), riff("VP8L"), riff("ABCD\x10\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00")} {
+		if _, err := Inspect(data); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("Inspect(%q) = %v, want ErrUnsupported", data, err)
+		}
+	}
+}
+
+func TestInfoDisplaySize(t *testing.T) {
+	for orientation := 1; orientation <= 8; orientation++ {
+		w, h := Info{Width: 4, Height: 2, Orientation: orientation}.DisplaySize()
+		if transposed := orientation >= 5; (w == 2 && h == 4) != transposed {
+			t.Errorf("orientation %d: DisplaySize = %d×%d", orientation, w, h)
+		}
+	}
+}
+
+func TestDecodeWebP(t *testing.T) {
+	img, format, err := image.Decode(bytes.NewReader(riff(tinyVP8L)))
+	if err != nil || format != "webp" {
+		t.Fatalf("Decode = %s, %v", format, err)
+	}
+	if img.Bounds() != image.Rect(0, 0, 1, 1) || Opaque(img) {
+		t.Errorf("decoded %v, opaque %v; want a transparent 1×1 image", img.Bounds(), Opaque(img))
+	}
+}
+
+func TestFallback(t *testing.T) {
+	tests := []struct {
+		format string
+		opaque bool
+		want   string
+	}{
+		{"jpeg", true, "jpeg"},
+		{"jpeg", false, "jpeg"},
+		{"png", true, "png"},
+		{"webp", true, "jpeg"},
+		{"webp", false, "png"},
+		{"gif", false, "png"},
+	}
+	for _, tt := range tests {
+		if got := Fallback(tt.format, tt.opaque); got != tt.want {
+			t.Errorf("Fallback(%q, %v) = %q, want %q", tt.format, tt.opaque, got, tt.want)
+		}
+	}
+}
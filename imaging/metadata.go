@@ -0,0 +1,213 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var errCorrupt = errors.New("corrupt image")
+
+// StripMetadata removes EXIF, XMP, IPTC and comment metadata, which can hold
+// GPS positions, camera serial numbers and the like, without re-encoding the
+// image. A JPEG keeps its orientation so it still displays upright. Colour
+// profiles are kept; formats without metadata support are returned as is.
+func StripMetadata(data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8}):
+		return stripJPEG(data)
+	case bytes.HasPrefix(data, pngSignature):
+		return stripPNG(data)
+	case isWebP(data):
+		return stripWebP(data)
+	}
+	return data, nil
+}
+
+// jpegSegments calls fn with each marker and payload up to the start of the
+// scan data, and returns the offset where the scan begins.
+func jpegSegments(data []byte, fn func(marker byte, payload []byte)) (int, error) {
+	i := 2
+	for {
+		for i+1 < len(data) && data[i] == 0xFF && data[i+1] == 0xFF {
+			i++ // fill bytes
+		}
+		if i+4 > len(data) || data[i] != 0xFF {
+			return 0, errCorrupt
+		}
+		marker := data[i+1]
+		if marker == 0xDA { // start of scan: the rest is image data
+			return i, nil
+		}
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			i += 2
+			continue
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return 0, errCorrupt
+		}
+		fn(marker, data[i+4:i+2+length])
+		i += 2 + length
+	}
+}
+
+var exifHeader = []byte("Exif\x00\x00")
+
+// exifOrientation reads the orientation tag from an APP1 EXIF payload.
+func exifOrientation(payload []byte) int {
+	tiff, ok := bytes.CutPrefix(payload, exifHeader)
+	if !ok || len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for e := ifd + 2; e+12 <= len(tiff) && count > 0; e, count = e+12, count-1 {
+		if order.Uint16(tiff[e:]) == 0x0112 && order.Uint16(tiff[e+2:]) == 3 {
+			if o := int(order.Uint16(tiff[e+8:])); o >= 1 && o <= 8 {
+				return o
+			}
+		}
+	}
+	return 0
+}
+
+// jpegOrientation returns the EXIF orientation of a JPEG, or 1 if it has none.
+func jpegOrientation(data []byte) int {
+	orientation := 1
+	jpegSegments(data, func(marker byte, payload []byte) {
+		if o := exifOrientation(payload); marker == 0xE1 && o != 0 {
+			orientation = o
+		}
+	})
+	return orientation
+}
+
+// orientationSegment is an APP1 segment with an EXIF block holding nothing
+// but the orientation tag.
+func orientationSegment(orientation int) []byte {
+	seg := []byte{0xFF, 0xE1, 0, 34}
+	seg = append(seg, exifHeader...)
+	seg = append(seg, 'M', 'M', 0, 42, 0, 0, 0, 8) // big-endian TIFF header, IFD at 8
+	seg = append(seg, 0, 1)                        // one entry
+	seg = append(seg, 0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, byte(orientation), 0, 0)
+	return append(seg, 0, 0, 0, 0) // no next IFD
+}
+
+// keepJPEGSegment reports whether a segment is needed to display the image.
+func keepJPEGSegment(marker byte, payload []byte) bool {
+	switch {
+	case marker == 0xE0: // JFIF
+		return bytes.HasPrefix(payload, []byte("JFIF\x00"))
+	case marker == 0xE2: // ICC colour profile
+		return bytes.HasPrefix(payload, []byte("ICC_PROFILE\x00"))
+	case marker == 0xEE: // Adobe colour transform
+		return true
+	case marker >= 0xE0 && marker <= 0xEF, marker == 0xFE: // other APPn and comments
+		return false
+	}
+	return true
+}
+
+func stripJPEG(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xFF, 0xD8)
+	orientationDone := false
+	scan, err := jpegSegments(data, func(marker byte, payload []byte) {
+		if marker == 0xE1 && !orientationDone {
+			if o := exifOrientation(payload); o > 1 {
+				out = append(out, orientationSegment(o)...)
+				orientationDone = true
+			}
+		}
+		if keepJPEGSegment(marker, payload) {
+			out = append(out, 0xFF, marker, byte((len(payload)+2)>>8), byte(len(payload)+2))
+			out = append(out, payload...)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(out, data[scan:]...), nil
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngAncillary are the optional PNG chunks that affect how the image looks;
+// text, time and EXIF chunks are dropped.
+var pngAncillary = map[string]bool{
+	"tRNS": true, "gAMA": true, "cHRM": true, "sRGB": true, "iCCP": true, "cICP": true,
+	"sBIT": true, "pHYs": true, "bKGD": true, "hIST": true, "sPLT": true,
+	"acTL": true, "fcTL": true, "fdAT": true, // animation
+}
+
+func stripPNG(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+	for i := len(pngSignature); i < len(data); {
+		if i+12 > len(data) {
+			return nil, errCorrupt
+		}
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		end := i + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, errCorrupt
+		}
+		kind := string(data[i+4 : i+8])
+		// critical chunks have an upper case first letter
+		if kind[0] < 'a' || pngAncillary[kind] {
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	return out, nil
+}
+
+func isWebP(data []byte) bool {
+	return len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP"
+}
+
+func stripWebP(data []byte) ([]byte, error) {
+	out := make([]byte, 12, len(data))
+	copy(out, data[:12])
+	for i := 12; i < len(data); {
+		if i+8 > len(data) {
+			return nil, errCorrupt
+		}
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		end := i + 8 + size + size%2 // chunks are padded to an even size
+		if size < 0 || end > len(data) {
+			if end == len(data)+1 { // tolerate a missing final pad byte
+				end = len(data)
+			} else {
+				return nil, errCorrupt
+			}
+		}
+		switch kind := string(data[i : i+4]); kind {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			start := len(out)
+			out = append(out, data[i:end]...)
+			if size > 0 {
+				out[start+8] &^= 0x08 | 0x04 // clear the EXIF and XMP flags
+			}
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, nil
+}
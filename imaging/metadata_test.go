@@ -0,0 +1,260 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// tinyVP8L is the image chunk of a 1×1 transparent lossless WebP.
+const tinyVP8L = "VP8L\x0d\x00\x00\x00\x2f\x00\x00\x00\x10\x07\x10\x11\x11\x88\x88\xfe\x07\x00"
+
+func riff(chunks ...string) []byte {
+	var body bytes.Buffer
+	body.WriteString("WEBP")
+	for _, c := range chunks {
+		body.WriteString(c)
+	}
+	out := []byte("RIFF\x00\x00\x00\x00")
+	binary.LittleEndian.PutUint32(out[4:], uint32(body.Len()))
+	return append(out, body.Bytes()...)
+}
+
+// riffChunk builds a WebP chunk, padded to an even size.
+func riffChunk(kind string, payload []byte) string {
+	c := []byte(kind + "\x00\x00\x00\x00")
+	binary.LittleEndian.PutUint32(c[4:], uint32(len(payload)))
+	c = append(c, payload...)
+	if len(payload)%2 == 1 {
+		c = append(c, 0)
+	}
+	return string(c)
+}
+
+// vp8x is a VP8X chunk for a width×height canvas with the given flags.
+func vp8x(flags byte, width, height int) string {
+	p := make([]byte, 10)
+	p[0] = flags
+	p[4], p[5], p[6] = byte(width-1), byte((width-1)>>8), byte((width-1)>>16)
+	p[7], p[8], p[9] = byte(height-1), byte((height-1)>>8), byte((height-1)>>16)
+	return riffChunk("VP8X", p)
+}
+
+// exifPayload is a TIFF block, without the "Exif" header, holding an
+// orientation tag and a GPS IFD pointer, in the given byte order.
+func exifPayload(order binary.ByteOrder, orientation int) []byte {
+	tiff := make([]byte, 8+2+2*12+4)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 2)
+	e := tiff[10:]
+	order.PutUint16(e[0:], 0x8825) // GPS IFD pointer
+	order.PutUint16(e[2:], 4)
+	order.PutUint32(e[4:], 1)
+	order.PutUint32(e[8:], 1234)
+	e = tiff[22:]
+	order.PutUint16(e[0:], 0x0112)
+	order.PutUint16(e[2:], 3)
+	order.PutUint32(e[4:], 1)
+	order.PutUint16(e[8:], uint16(orientation))
+	return tiff
+}
+
+func jpegSegment(marker byte, payload []byte) []byte {
+	seg := []byte{0xFF, marker, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}
+	return append(seg, payload...)
+}
+
+// testJPEG is a 4×2 JPEG with the given segments inserted after SOI.
+func testJPEG(t *testing.T, segments ...[]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 2)), nil); err != nil {
+		t.Fatal(err)
+	}
+	out := []byte{0xFF, 0xD8}
+	for _, s := range segments {
+		out = append(out, s...)
+	}
+	return append(out, buf.Bytes()[2:]...)
+}
+
+func pngChunk(kind string, data []byte) []byte {
+	c := make([]byte, 8, 12+len(data))
+	binary.BigEndian.PutUint32(c, uint32(len(data)))
+	copy(c[4:], kind)
+	c = append(c, data...)
+	return binary.BigEndian.AppendUint32(c, crc32.ChecksumIEEE(c[4:]))
+}
+
+// testPNG is a 3×3 PNG with the given chunks inserted after IHDR.
+func testPNG(t *testing.T, chunks ...[]byte) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 3))
+	img.Set(1, 1, color.NRGBA{R: 255, A: 128})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	ihdrEnd := len(pngSignature) + 12 + 13
+	out := append([]byte{}, data[:ihdrEnd]...)
+	for _, c := range chunks {
+		out = append(out, c...)
+	}
+	return append(out, data[ihdrEnd:]...)
+}
+
+func TestExifOrientation(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload []byte
+		want    int
+	}{
+		{"big endian", append([]byte("Exif\x00\x00"), exifPayload(binary.BigEndian, 6)...), 6},
+		{"little endian", append([]byte("Exif\x00\x00"), exifPayload(binary.LittleEndian, 3)...), 3},
+		{"out of range", append([]byte("Exif\x00\x00"), exifPayload(binary.BigEndian, 9)...), 0},
+		{"no header", exifPayload(binary.BigEndian, 6), 0},
+		{"bad byte order", []byte("Exif\x00\x00XX\x00\x2a\x00\x00\x00\x08"), 0},
+		{"IFD past the end", []byte("Exif\x00\x00MM\x00\x2a\x00\x00\xff\xff"), 0},
+		{"truncated entries", append([]byte("Exif\x00\x00"), exifPayload(binary.BigEndian, 6)[:20]...), 0},
+		{"XMP", []byte("http://ns.adobe.com/xap/1.0/\x00<x/>"), 0},
+	}
+	for _, tt := range tests {
+		if got := exifOrientation(tt.payload); got != tt.want {
+			t.Errorf("%s: exifOrientation = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStripJPEG(t *testing.T) {
+	exif := jpegSegment(0xE1, append([]byte("Exif\x00\x00"), exifPayload(binary.LittleEndian, 6)...))
+	xmp := jpegSegment(0xE1, []byte("http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta/>"))
+	iptc := jpegSegment(0xED, []byte("Photoshop 3.0\x00secret"))
+	comment := jpegSegment(0xFE, []byte("taken at home"))
+	icc := jpegSegment(0xE2, []byte("ICC_PROFILE\x00\x01\x01profile"))
+
+	tests := []struct {
+		name        string
+		in          []byte
+		orientation int
+		keep        [][]byte
+		drop        [][]byte
+	}{
+		{"exif and comments", testJPEG(t, exif, xmp, iptc, comment), 6, nil, [][]byte{[]byte("Photoshop"), []byte("taken at home"), []byte("xmpmeta"), {0x88, 0x25}}},
+		{"colour profile kept", testJPEG(t, icc, comment), 1, [][]byte{icc}, [][]byte{[]byte("taken at home")}},
+		{"upright exif dropped", testJPEG(t, jpegSegment(0xE1, append([]byte("Exif\x00\x00"), exifPayload(binary.BigEndian, 1)...))), 1, nil, [][]byte{[]byte("Exif")}},
+		{"nothing to strip", testJPEG(t), 1, nil, nil},
+	}
+	for _, tt := range tests {
+		out, err := StripMetadata(tt.in)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := jpegOrientation(out); got != tt.orientation {
+			t.Errorf("%s: orientation after stripping = %d, want %d", tt.name, got, tt.orientation)
+		}
+		for _, k := range tt.keep {
+			if !bytes.Contains(out, k) {
+				t.Errorf("%s: dropped %q", tt.name, k)
+			}
+		}
+		for _, d := range tt.drop {
+			if bytes.Contains(out, d) {
+				t.Errorf("%s: kept %q", tt.name, d)
+			}
+		}
+		if _, err := jpeg.Decode(bytes.NewReader(out)); err != nil {
+			t.Errorf("%s: stripped JPEG does not decode: %v", tt.name, err)
+		}
+	}
+}
+
+func TestStripPNG(t *testing.T) {
+	text := pngChunk("tEXt", []byte("Comment\x00taken at home"))
+	exif := pngChunk("eXIf", exifPayload(binary.BigEndian, 6))
+	gamma := pngChunk("gAMA", []byte{0, 0, 0xB1, 0x8F})
+	private := pngChunk("prVt", []byte("camera serial"))
+
+	out, err := StripMetadata(testPNG(t, text, exif, gamma, private))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range [][]byte{text, exif, private} {
+		if bytes.Contains(out, d) {
+			t.Errorf("kept %q", d[4:8])
+		}
+	}
+	if !bytes.Contains(out, gamma) {
+		t.Error("dropped gAMA")
+	}
+	img, err := png.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("stripped PNG does not decode: %v", err)
+	}
+	if got := color.NRGBAModel.Convert(img.At(1, 1)).(color.NRGBA); got != (color.NRGBA{R: 255, A: 128}) {
+		t.Errorf("pixel changed to %v", got)
+	}
+}
+
+func TestStripWebP(t *testing.T) {
+	exif := riffChunk("EXIF", exifPayload(binary.BigEndian, 6))
+	xmp := riffChunk("XMP ", []byte("<x:xmpmeta/>"))
+	in := riff(vp8x(0x08|0x04, 1, 1), tinyVP8L, exif, xmp)
+
+	out, err := StripMetadata(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(out, []byte("EXIF")) || bytes.Contains(out, []byte("xmpmeta")) {
+		t.Error("kept EXIF or XMP")
+	}
+	if want := riff(vp8x(0, 1, 1), tinyVP8L); !bytes.Equal(out, want) {
+		t.Errorf("StripMetadata =\n%q\nwant\n%q", out, want)
+	}
+	if _, _, err := image.Decode(bytes.NewReader(out)); err != nil {
+		t.Errorf("stripped WebP does not decode: %v", err)
+	}
+
+	// a writer that leaves out the final pad byte
+	unpadded := riff(tinyVP8L, riffChunk("EXIF", []byte("odd")))
+	unpadded = unpadded[:len(unpadded)-1]
+	if out, err := StripMetadata(unpadded); err != nil || !bytes.Equal(out, riff(tinyVP8L)) {
+		t.Errorf("StripMetadata without the final pad = %q, %v", out, err)
+	}
+}
+
+func TestStripMetadataCorrupt(t *testing.T) {
+	valid := testJPEG(t)
+	tests := []struct {
+		name string
+		in   []byte
+	}{
+		{"truncated JPEG", valid[:20]},
+		{"JPEG segment past the end", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0xFF, 0xFF, 0, 0}},
+		{"JPEG segment too short", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0, 1, 0, 0}},
+		{"truncated PNG chunk", append(append([]byte{}, pngSignature...), 0, 0, 0, 13, 'I', 'H')},
+		{"PNG chunk past the end", append(append([]byte{}, pngSignature...), 0, 0, 1, 0, 'I', 'H', 'D', 'R', 0, 0, 0, 0)},
+		{"WebP chunk past the end", riff("VP8L\xff\x00\x00\x00\x2f")},
+	}
+	for _, tt := range tests {
+		if _, err := StripMetadata(tt.in); !errors.Is(err, errCorrupt) {
+			t.Errorf("%s: err = %v, want errCorrupt", tt.name, err)
+		}
+	}
+	if out, err := StripMetadata([]byte("GIF89a")); err != nil || string(out) != "GIF89a" {
+		t.Errorf("StripMetadata of a GIF = %q, %v, want it unchanged", out, err)
+	}
+}
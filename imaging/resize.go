@@ -0,0 +1,118 @@
+package imaging
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// ToRGBA copies img into an RGBA image with its origin at 0,0.
+func ToRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba
+	}
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
+
+// span is one source pixel's share of a destination pixel.
+type span struct {
+	index  int
+	weight float32
+}
+
+// areaWeights gives, for each of dst pixels, the source pixels it covers
+// when src pixels are shrunk into dst, weighted by how much of each it covers.
+func areaWeights(src, dst int) [][]span {
+	scale := float64(src) / float64(dst)
+	weights := make([][]span, dst)
+	for i := range weights {
+		start, end := float64(i)*scale, float64(i+1)*scale
+		for j := int(start); j < src && float64(j) < end; j++ {
+			cover := math.Min(end, float64(j+1)) - math.Max(start, float64(j))
+			if cover > 0 {
+				weights[i] = append(weights[i], span{j, float32(cover / scale)})
+			}
+		}
+	}
+	return weights
+}
+
+// Resize shrinks src to width by height by averaging the source pixels each
+// destination pixel covers, which keeps downscaled photos smooth and free of
+// aliasing. It never enlarges: each side is capped at src's, and src is
+// returned unchanged if that leaves nothing to shrink.
+func Resize(src *image.RGBA, width, height int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	width, height = max(1, min(width, sw)), max(1, min(height, sh))
+	if width == sw && height == sh {
+		return src
+	}
+	cols, rows := areaWeights(sw, width), areaWeights(sh, height)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	row := make([]float32, width*4) // one source row, shrunk horizontally
+	acc := make([]float32, width*4)
+	for y, ys := range rows {
+		clear(acc)
+		for _, ry := range ys {
+			clear(row)
+			line := src.Pix[src.PixOffset(src.Rect.Min.X, src.Rect.Min.Y+ry.index):]
+			for x, xs := range cols {
+				for _, rx := range xs {
+					p := line[rx.index*4 : rx.index*4+4]
+					row[x*4] += float32(p[0]) * rx.weight
+					row[x*4+1] += float32(p[1]) * rx.weight
+					row[x*4+2] += float32(p[2]) * rx.weight
+					row[x*4+3] += float32(p[3]) * rx.weight
+				}
+			}
+			for i, v := range row {
+				acc[i] += v * ry.weight
+			}
+		}
+		out := dst.Pix[y*dst.Stride:]
+		for i, v := range acc {
+			out[i] = uint8(math.Min(255, float64(v)+0.5))
+		}
+	}
+	return dst
+}
+
+// Orient turns an image stored with the given EXIF orientation upright.
+func Orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirrored
+				dx, dy = w-1-x, y
+			case 3: // rotated 180°
+				dx, dy = w-1-x, h-1-y
+			case 4: // flipped
+				dx, dy = x, h-1-y
+			case 5: // transposed
+				dx, dy = y, x
+			case 6: // rotated 90° clockwise
+				dx, dy = h-1-y, x
+			case 7: // transversed
+				dx, dy = h-1-y, w-1-x
+			case 8: // rotated 90° anticlockwise
+				dx, dy = y, w-1-x
+			}
+			s := src.PixOffset(src.Rect.Min.X+x, src.Rect.Min.Y+y)
+			copy(dst.Pix[dst.PixOffset(dx, dy):], src.Pix[s:s+4])
+		}
+	}
+	return dst
+}
@@ -0,0 +1,102 @@
+// Package importer reads content exported from other blogging systems,
+// WordPress WXR files and ZIPs of Markdown files with YAML or TOML front
+// matter as used by Hugo and Jekyll, into a format-neutral description that
+// the caller maps onto its own posts and categories.
+package importer
+
+import (
+	"errors"
+	"time"
+)
+
+// Formats that can be imported
+const (
+	FormatWXR      = "wxr"
+	FormatMarkdown = "markdown"
+)
+
+// Statuses of imported posts
+const (
+	StatusPublished = "published"
+	StatusDraft     = "draft"
+)
+
+var (
+	ErrUnsupported = errors.New("unsupported import format")
+	// ErrNoSite is returned for a Markdown ZIP that does not say which site
+	// it comes from when the caller did not say either
+	ErrNoSite = errors.New("the ZIP does not name the site it comes from")
+)
+
+// Export is the content of one export file.
+type Export struct {
+	// Source names where the content came from, e.g. "wxr:https://old.example.com"
+	// or "markdown:https://old.example.com"; together with a post's SourceID
+	// it identifies the post across imports
+	Source     string
+	Authors    []Author
+	Categories []Category
+	Posts      []Post
+	// Warnings are problems with the file as a whole
+	Warnings []string
+}
+
+// Author is a user of the exporting site. Login is how posts refer to them.
+type Author struct {
+	Login string
+	Email string
+	Name  string
+}
+
+// Category is a category of the exporting site. Parent is the parent's
+// slug. Markdown front matter only names categories, so Slug may be empty
+// unless the ZIP has this blog's categories.json.
+type Category struct {
+	Slug        string
+	Name        string
+	Description string
+	Parent      string
+}
+
+// Post is one post. Body is HTML or Markdown as Format says. Author is an
+// Author's Login for WXR, or the name or email in the front matter. Skip,
+// when set, says why the post cannot be imported.
+type Post struct {
+	SourceID    string
+	Slug        string
+	Link        string
+	Title       string
+	Description string
+	Body        string
+	Format      string
+	Status      string
+	Author      string
+	Categories  []Category
+	Tags        []string
+	Published   time.Time
+	Modified    time.Time
+	Skip        string
+}
+
+// Detect guesses the format of an export from its first bytes.
+func Detect(data []byte) (string, error) {
+	switch {
+	case len(data) >= 4 && string(data[:4]) == "PK\x03\x04":
+		return FormatMarkdown, nil
+	case isXML(data):
+		return FormatWXR, nil
+	}
+	return "", ErrUnsupported
+}
+
+// Parse reads an export in format. site names the site a Markdown ZIP
+// comes from; WXR files name their own.
+func Parse(format string, data []byte, site string) (*Export, error) {
+	switch format {
+	case FormatWXR:
+		return ParseWXR(data)
+	case FormatMarkdown:
+		return ParseMarkdownZip(data, site)
+	}
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,438 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Limits on what a ZIP may expand to, so a small upload cannot take
+// gigabytes of memory: the size of one Markdown file once unzipped, the size
+// of all of them together, and the number of entries in the ZIP.
+const (
+	maxMarkdownFile  = 8 << 20
+	maxMarkdownTotal = 256 << 20
+	maxZipEntries    = 10000
+)
+
+var markdownExts = map[string]bool{".md": true, ".markdown": true, ".mdown": true}
+
+// jekyllName matches Jekyll's "2006-01-02-slug" post file names.
+var jekyllName = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})-(.+)$`)
+
+// ParseMarkdownZip reads a ZIP of Markdown files, each with optional YAML
+// (---) or TOML (+++) front matter. A post's SourceID is its "id" front
+// matter field, or else its path in the ZIP without the extension, so a
+// Hugo bundle's "posts/hello/index.md" is "posts/hello". Section pages
+// named _index.md are left out.
+//
+// The export's Source is "markdown:" and the site it comes from: site when
+// given, else the address in the ZIP's Hugo or Jekyll configuration or in
+// the manifest of an export made by this blog. ErrNoSite is returned when
+// there is none, so that ZIPs of different sites don't share source ids.
+//
+// Front matter names categories only. When the ZIP has the categories.json
+// of an export made by this blog, categories come with their slugs,
+// descriptions and parents from there.
+func ParseMarkdownZip(data []byte, site string) (*Export, error) {
+	zr, err := openZip(data)
+	if err != nil {
+		return nil, err
+	}
+	top := topFiles(zr)
+	if site = strings.TrimRight(strings.TrimSpace(site), "/"); site == "" {
+		site = zipSite(top)
+	}
+	if site == "" {
+		return nil, ErrNoSite
+	}
+
+	files := make([]*zip.File, 0, len(zr.File))
+	for _, f := range zr.File {
+		name := f.Name
+		base := path.Base(name)
+		if f.FileInfo().IsDir() || !markdownExts[strings.ToLower(path.Ext(name))] ||
+			strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(base, ".") || base == "_index.md" {
+			continue
+		}
+		files = append(files, f)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	exp := &Export{Source: FormatMarkdown + ":" + site}
+	if f := top["categories.json"]; f != nil {
+		if exp.Categories, err = zipCategories(f); err != nil {
+			exp.Warnings = append(exp.Warnings, "categories.json: "+err.Error())
+		}
+	}
+	slugs := map[string]string{}
+	for _, c := range exp.Categories {
+		slugs[strings.ToLower(c.Name)] = c.Slug
+	}
+	budget := int64(maxMarkdownTotal)
+	for _, f := range files {
+		content, err := readZipFile(f, &budget)
+		if errors.Is(err, errZipTooLarge) {
+			return nil, err
+		}
+		if err != nil {
+			exp.Posts = append(exp.Posts, Post{SourceID: sourcePath(f.Name), Skip: err.Error()})
+			continue
+		}
+		post := parseMarkdownPost(f.Name, content)
+		for i, c := range post.Categories {
+			post.Categories[i].Slug = slugs[strings.ToLower(c.Name)]
+		}
+		exp.Posts = append(exp.Posts, post)
+	}
+	if len(exp.Posts) == 0 {
+		exp.Warnings = append(exp.Warnings, "the ZIP holds no Markdown files")
+	}
+	return exp, nil
+}
+
+// MarkdownZipSite returns the site a ZIP of Markdown files names, as
+// ParseMarkdownZip finds it, or "" when it names none.
+func MarkdownZipSite(data []byte) (string, error) {
+	zr, err := openZip(data)
+	if err != nil {
+		return "", err
+	}
+	return zipSite(topFiles(zr)), nil
+}
+
+func openZip(data []byte) (*zip.Reader, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("reading ZIP: %w", err)
+	}
+	if len(zr.File) > maxZipEntries {
+		return nil, fmt.Errorf("the ZIP holds more than %d entries", maxZipEntries)
+	}
+	return zr, nil
+}
+
+// siteConfigs are the files that may name the exported site, most telling
+// first: this blog's export manifest, then Hugo's and Jekyll's configuration.
+var siteConfigs = []string{
+	"manifest.json",
+	"hugo.toml", "hugo.yaml", "hugo.yml", "hugo.json",
+	"config.toml", "config.yaml", "config.yml", "config.json",
+	"_config.yml",
+}
+
+// topFiles maps names to the files at the top of the ZIP, or in the folder
+// the ZIP holds everything in; a file at the top wins.
+func topFiles(zr *zip.Reader) map[string]*zip.File {
+	top := map[string]*zip.File{}
+	for _, f := range zr.File {
+		depth := strings.Count(f.Name, "/")
+		if f.FileInfo().IsDir() || depth > 1 || strings.HasPrefix(f.Name, "__MACOSX/") {
+			continue
+		}
+		base := path.Base(f.Name)
+		if prev := top[base]; prev == nil || strings.Count(prev.Name, "/") > depth {
+			top[base] = f
+		}
+	}
+	return top
+}
+
+// zipSite finds the address of the exported site in one of siteConfigs.
+func zipSite(top map[string]*zip.File) string {
+	budget := int64(maxMarkdownFile)
+	for _, name := range siteConfigs {
+		f := top[name]
+		if f == nil {
+			continue
+		}
+		content, err := readZipFile(f, &budget)
+		if err != nil {
+			continue
+		}
+		fields := map[string]interface{}{}
+		if path.Ext(name) == ".toml" {
+			err = toml.Unmarshal(content, &fields)
+		} else {
+			err = yaml.Unmarshal(content, &fields) // JSON is YAML too
+		}
+		if err != nil {
+			continue
+		}
+		var site string
+		switch name {
+		case "manifest.json":
+			site = stringField(fields, "site")
+		case "_config.yml":
+			if u := stringField(fields, "url"); u != "" {
+				site = strings.TrimRight(u, "/") + "/" + strings.Trim(stringField(fields, "baseurl"), "/")
+			}
+		default:
+			site = stringField(fields, "baseURL", "baseurl", "baseUrl")
+		}
+		if site = strings.TrimRight(site, "/"); site != "" {
+			return site
+		}
+	}
+	return ""
+}
+
+// zipCategories reads the categories.json of an export made by this blog.
+// Entries without a name or slug are left out.
+func zipCategories(f *zip.File) ([]Category, error) {
+	budget := int64(maxMarkdownFile)
+	content, err := readZipFile(f, &budget)
+	if err != nil {
+		return nil, err
+	}
+	var entries []struct {
+		Name        string `json:"name"`
+		Slug        string `json:"slug"`
+		Description string `json:"description"`
+		Parent      string `json:"parent"`
+	}
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return nil, err
+	}
+	var categories []Category
+	for _, e := range entries {
+		c := Category{
+			Slug:        strings.TrimSpace(e.Slug),
+			Name:        strings.TrimSpace(e.Name),
+			Description: strings.TrimSpace(e.Description),
+			Parent:      strings.TrimSpace(e.Parent),
+		}
+		if c.Slug != "" && c.Name != "" {
+			categories = append(categories, c)
+		}
+	}
+	return categories, nil
+}
+
+var errZipTooLarge = fmt.Errorf("the Markdown files in the ZIP add up to more than %d bytes", maxMarkdownTotal)
+
+// readZipFile unzips f, taking what it reads off budget. A file over
+// maxMarkdownFile is an error for that file alone; running out of budget
+// is errZipTooLarge.
+func readZipFile(f *zip.File, budget *int64) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	limit := min(maxMarkdownFile, *budget)
+	content, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	*budget -= int64(len(content))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > limit {
+		if limit < maxMarkdownFile {
+			return nil, errZipTooLarge
+		}
+		return nil, fmt.Errorf("larger than %d bytes", maxMarkdownFile)
+	}
+	return content, nil
+}
+
+// sourcePath is a file's path without its extension or a bundle's "/index".
+func sourcePath(name string) string {
+	id := strings.TrimSuffix(name, path.Ext(name))
+	if path.Base(id) == "index" && path.Dir(id) != "." {
+		id = path.Dir(id)
+	}
+	return id
+}
+
+// splitFrontMatter separates the front matter from the body and says
+// whether it is TOML.
+func splitFrontMatter(content string) (front, body string, isTOML bool) {
+	content = strings.TrimPrefix(strings.ReplaceAll(content, "\r\n", "\n"), "\uFEFF")
+	for _, fence := range []string{"---", "+++"} {
+		if !strings.HasPrefix(content, fence+"\n") {
+			continue
+		}
+		rest := content[len(fence)+1:]
+		end := strings.Index("\n"+rest, "\n"+fence+"\n")
+		if end < 0 {
+			if strings.HasSuffix(rest, "\n"+fence) {
+				return rest[:len(rest)-len(fence)-1], "", fence == "+++"
+			}
+			return "", content, false
+		}
+		// the closing fence starts at end in rest, which is preceded by "\n"
+		front = rest[:max(0, end-1)]
+		return front, rest[end+len(fence)+1:], fence == "+++"
+	}
+	return "", content, false
+}
+
+func parseMarkdownPost(name string, content []byte) Post {
+	post := Post{SourceID: sourcePath(name), Format: "markdown", Status: StatusPublished}
+	front, body, isTOML := splitFrontMatter(string(content))
+	post.Body = strings.TrimSpace(body)
+
+	fields := map[string]interface{}{}
+	if strings.TrimSpace(front) != "" {
+		var err error
+		if isTOML {
+			err = toml.Unmarshal([]byte(front), &fields)
+		} else {
+			err = yaml.Unmarshal([]byte(front), &fields)
+		}
+		if err != nil {
+			post.Skip = "front matter: " + err.Error()
+			return post
+		}
+	}
+
+	if id := stringField(fields, "id"); id != "" {
+		post.SourceID = id
+	}
+	post.Title = stringField(fields, "title")
+	post.Description = stringField(fields, "description", "summary", "excerpt")
+	post.Slug = stringField(fields, "slug")
+	post.Link = stringField(fields, "url", "permalink")
+	post.Author = firstNonEmpty(stringField(fields, "author_email"), firstOf(listField(fields, "author", "authors")))
+	post.Tags = listField(fields, "tags")
+	for _, c := range listField(fields, "categories", "category") {
+		post.Categories = append(post.Categories, Category{Name: c})
+	}
+	if format := stringField(fields, "content_format"); format != "" {
+		post.Format = format
+	}
+	post.Published = timeField(fields, "date", "publishDate", "published_at")
+	post.Modified = timeField(fields, "lastmod", "updated", "modified")
+	if draft, ok := fields["draft"].(bool); ok && draft {
+		post.Status = StatusDraft
+	}
+	if published, ok := fields["published"].(bool); ok && !published { // Jekyll
+		post.Status = StatusDraft
+	}
+
+	base := path.Base(post.SourceID)
+	if m := jekyllName.FindStringSubmatch(path.Base(sourcePath(name))); m != nil {
+		if post.Published.IsZero() {
+			post.Published, _ = time.Parse(time.DateOnly, m[1])
+		}
+		base = m[2]
+	}
+	if post.Slug == "" {
+		post.Slug = base
+	}
+	if post.Title == "" {
+		post.Title, post.Body = headingTitle(post.Body)
+	}
+	if post.Title == "" {
+		post.Title = strings.ReplaceAll(base, "-", " ")
+	}
+	if post.Body == "" {
+		post.Skip = "no content"
+	}
+	return post
+}
+
+// headingTitle takes a leading "# Title" line off body.
+func headingTitle(body string) (title, rest string) {
+	line, rest, _ := strings.Cut(body, "\n")
+	if strings.HasPrefix(line, "# ") {
+		return strings.TrimSpace(line[2:]), strings.TrimSpace(rest)
+	}
+	return "", body
+}
+
+// stringField returns the first of keys that front matter sets to a scalar.
+func stringField(fields map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		switch v := fields[key].(type) {
+		case string:
+			if v = strings.TrimSpace(v); v != "" {
+				return v
+			}
+		case int, int64, uint64, float64:
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
+
+// listField returns the first of keys that front matter sets, as a list;
+// a single string counts as a list of one, or of several when it holds
+// commas.
+func listField(fields map[string]interface{}, keys ...string) []string {
+	for _, key := range keys {
+		var list []string
+		switch v := fields[key].(type) {
+		case string:
+			for _, s := range strings.Split(v, ",") {
+				if s = strings.TrimSpace(s); s != "" {
+					list = append(list, s)
+				}
+			}
+		case []interface{}:
+			for _, item := range v {
+				if s := strings.TrimSpace(fmt.Sprint(item)); s != "" {
+					list = append(list, s)
+				}
+			}
+		}
+		if len(list) > 0 {
+			return list
+		}
+	}
+	return nil
+}
+
+// timeField returns the first of keys that front matter sets to a date.
+// YAML and TOML dates arrive parsed; quoted ones are parsed here.
+func timeField(fields map[string]interface{}, keys ...string) time.Time {
+	for _, key := range keys {
+		switch v := fields[key].(type) {
+		case time.Time:
+			return v
+		case toml.LocalDateTime:
+			return v.AsTime(time.UTC)
+		case toml.LocalDate:
+			return v.AsTime(time.UTC)
+		case string:
+			if t := parseDate(v); !t.IsZero() {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+var dateLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05 -0700", "2006-01-02 15:04:05", "2006-01-02 15:04", time.DateOnly}
+
+func parseDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	if unix, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(unix, 0).UTC()
+	}
+	return time.Time{}
+}
+
+func firstOf(list []string) string {
+	if len(list) == 0 {
+		return ""
+	}
+	return list[0]
+}
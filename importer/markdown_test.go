@@ -0,0 +1,289 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// zipOf builds a ZIP holding files, in order, by name.
+func zipOf(t *testing.T, files ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i := 0; i+1 < len(files); i += 2 {
+		w, err := zw.Create(files[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(files[i+1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestSplitFrontMatter(t *testing.T) {
+	tests := []struct {
+		content string
+		front   string
+		body    string
+		isTOML  bool
+	}{
+		{"---\ntitle: Hi\n---\nBody\n", "title: Hi", "Body\n", false},
+		{"+++\ntitle = 'Hi'\n+++\nBody", "title = 'Hi'", "Body", true},
+		{"\uFEFF---\r\ntitle: Hi\r\n---\r\nBody", "title: Hi", "Body", false},
+		{"---\ntitle: Hi\n---", "title: Hi", "", false},
+		{"---\n---\nBody", "", "Body", false},
+		{"---\ntitle: Hi\nno closing fence", "", "---\ntitle: Hi\nno closing fence", false},
+		{"Just a body\n---\nwith a rule", "", "Just a body\n---\nwith a rule", false},
+	}
+	for _, tt := range tests {
+		front, body, isTOML := splitFrontMatter(tt.content)
+		if front != tt.front || body != tt.body || isTOML != tt.isTOML {
+			t.Errorf("splitFrontMatter(%q) = %q, %q, %v, want %q, %q, %v", tt.content, front, body, isTOML, tt.front, tt.body, tt.isTOML)
+		}
+	}
+}
+
+func TestSourcePath(t *testing.T) {
+	tests := map[string]string{
+		"hello.md":                      "hello",
+		"posts/hello/index.md":          "posts/hello",
+		"index.md":                      "index",
+		"_posts/2020-01-02-hi.markdown": "_posts/2020-01-02-hi",
+	}
+	for name, want := range tests {
+		if got := sourcePath(name); got != want {
+			t.Errorf("sourcePath(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestParseMarkdownPost(t *testing.T) {
+	date := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    Post
+	}{
+		{
+			"hugo yaml", "posts/hello/index.md",
+			"---\ntitle: Hello\ndate: 2021-03-04T05:06:07Z\ntags: [go, web]\ncategories: News\nauthor: Ann\ndescription: Greeting\n---\n\nBody text\n",
+			Post{SourceID: "posts/hello", Slug: "hello", Title: "Hello", Description: "Greeting", Body: "Body text", Format: "markdown",
+				Status: StatusPublished, Author: "Ann", Categories: []Category{{Name: "News"}}, Tags: []string{"go", "web"}, Published: date},
+		},
+		{
+			"hugo toml draft", "posts/toml.md",
+			"+++\ntitle = \"Toml\"\nid = 42\nslug = \"custom\"\ndraft = true\ndate = 2021-03-04T05:06:07Z\ntags = \"a, b\"\n+++\nBody",
+			Post{SourceID: "42", Slug: "custom", Title: "Toml", Body: "Body", Format: "markdown", Status: StatusDraft,
+				Tags: []string{"a", "b"}, Published: date},
+		},
+		{
+			"jekyll name", "_posts/2020-01-02-first-post.md",
+			"---\npublished: false\nauthors: [Bo, Cy]\n---\n# From the heading\n\nBody",
+			Post{SourceID: "_posts/2020-01-02-first-post", Slug: "first-post", Title: "From the heading", Body: "Body", Format: "markdown",
+				Status: StatusDraft, Author: "Bo", Published: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			"no front matter", "notes/a-short-note.md", "Just text",
+			Post{SourceID: "notes/a-short-note", Slug: "a-short-note", Title: "a short note", Body: "Just text", Format: "markdown", Status: StatusPublished},
+		},
+		{
+			"quoted date and html", "x.md", "---\ndate: \"2021-03-04 05:06:07\"\ncontent_format: html\nauthor_email: a@example.com\n---\n<p>Hi</p>",
+			Post{SourceID: "x", Slug: "x", Title: "x", Body: "<p>Hi</p>", Format: "html", Status: StatusPublished, Author: "a@example.com", Published: date},
+		},
+		{
+			"empty", "empty.md", "---\ntitle: Empty\n---\n",
+			Post{SourceID: "empty", Slug: "empty", Title: "Empty", Format: "markdown", Status: StatusPublished, Skip: "no content"},
+		},
+	}
+	for _, tt := range tests {
+		got := parseMarkdownPost(tt.file, []byte(tt.content))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\ngot  %+v\nwant %+v", tt.name, got, tt.want)
+		}
+	}
+
+	bad := parseMarkdownPost("bad.md", []byte("---\ntitle: [unclosed\n---\nBody"))
+	if !strings.HasPrefix(bad.Skip, "front matter: ") {
+		t.Errorf("invalid front matter: Skip = %q", bad.Skip)
+	}
+}
+
+func TestParseMarkdownZip(t *testing.T) {
+	data := zipOf(t,
+		"b.md", "# B\n\nSecond",
+		"a.markdown", "# A\n\nFirst",
+		"content/_index.md", "# Section",
+		"__MACOSX/._a.markdown", "resource fork",
+		".hidden.md", "# Hidden",
+		"image.png", "not markdown",
+		"big.md", strings.Repeat("x", maxMarkdownFile+1),
+	)
+	exp, err := ParseMarkdownZip(data, "https://old.example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range exp.Posts {
+		got = append(got, p.SourceID+":"+p.Title+":"+p.Skip)
+	}
+	want := []string{"a:A:", "b:B:", fmt.Sprintf("big::larger than %d bytes", maxMarkdownFile)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("posts = %q, want %q", got, want)
+	}
+	if exp.Source != "markdown:https://old.example.com" || len(exp.Warnings) != 0 {
+		t.Errorf("Source = %q, Warnings = %q", exp.Source, exp.Warnings)
+	}
+
+	empty, err := ParseMarkdownZip(zipOf(t, "readme.txt", "hi"), "old")
+	if err != nil || len(empty.Posts) != 0 || len(empty.Warnings) != 1 {
+		t.Errorf("ZIP without Markdown = %+v, %v, want one warning", empty, err)
+	}
+
+	if _, err := ParseMarkdownZip([]byte("PK\x03\x04 not really"), "old"); err == nil {
+		t.Error("a corrupt ZIP parsed")
+	}
+	if _, err := ParseMarkdownZip(zipOf(t, "a.md", "# A"), ""); !errors.Is(err, ErrNoSite) {
+		t.Errorf("a ZIP naming no site = %v, want ErrNoSite", err)
+	}
+}
+
+func TestMarkdownZipSite(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{"hugo toml", []string{"config.toml", "baseURL = 'https://a.example.com/'\ntitle = 'A'"}, "https://a.example.com"},
+		{"hugo yaml in a folder", []string{"site/hugo.yaml", "baseurl: https://b.example.com/blog/"}, "https://b.example.com/blog"},
+		{"jekyll", []string{"_config.yml", "url: https://c.example.com\nbaseurl: /blog"}, "https://c.example.com/blog"},
+		{"jekyll without baseurl", []string{"_config.yml", "url: https://c.example.com/"}, "https://c.example.com"},
+		{"export manifest first", []string{"config.toml", "baseURL = 'https://a.example.com'", "manifest.json", `{"version": 1, "site": "https://d.example.com"}`}, "https://d.example.com"},
+		{"root before folder", []string{"x/config.yaml", "baseURL: https://x.example.com", "config.yaml", "baseURL: https://e.example.com"}, "https://e.example.com"},
+		{"too deep", []string{"themes/t/config.toml", "baseURL = 'https://t.example.com'"}, ""},
+		{"no address", []string{"config.toml", "title = 'A'", "manifest.json", `{"version": 1}`}, ""},
+		{"invalid config", []string{"config.toml", "baseURL = ", "a.md", "# A"}, ""},
+	}
+	for _, tt := range tests {
+		got, err := MarkdownZipSite(zipOf(t, tt.files...))
+		if err != nil || got != tt.want {
+			t.Errorf("%s: MarkdownZipSite = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+
+	exp, err := ParseMarkdownZip(zipOf(t, "config.toml", "baseURL = 'https://a.example.com'", "a.md", "# A"), "")
+	if err != nil || exp.Source != "markdown:https://a.example.com" {
+		t.Errorf("ParseMarkdownZip of a Hugo site = %+v, %v", exp, err)
+	}
+}
+
+func TestParseMarkdownZipCategories(t *testing.T) {
+	data := zipOf(t,
+		"blog/categories.json", `[{"id": 1, "name": "News", "slug": "news-1", "posts": 1},
+			{"id": 2, "name": "Local news", "slug": "local", "description": "Nearby", "parent": "news-1", "posts": 1},
+			{"id": 3, "name": "", "slug": "nameless"}]`,
+		"blog/content/posts/1-a.md", "---\ntitle: A\ncategories: [local news]\n---\nA",
+		"blog/content/posts/2-b.md", "---\ntitle: B\ncategories: [Other]\n---\nB",
+	)
+	exp, err := ParseMarkdownZip(data, "old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Category{{Slug: "news-1", Name: "News"}, {Slug: "local", Name: "Local news", Description: "Nearby", Parent: "news-1"}}
+	if !reflect.DeepEqual(exp.Categories, want) {
+		t.Errorf("Categories = %+v, want %+v", exp.Categories, want)
+	}
+	if got := exp.Posts[0].Categories; !reflect.DeepEqual(got, []Category{{Slug: "local", Name: "local news"}}) {
+		t.Errorf("a listed category = %+v, want its slug", got)
+	}
+	if got := exp.Posts[1].Categories; !reflect.DeepEqual(got, []Category{{Name: "Other"}}) {
+		t.Errorf("an unlisted category = %+v, want its name only", got)
+	}
+
+	bad, err := ParseMarkdownZip(zipOf(t, "categories.json", "{", "a.md", "# A"), "old")
+	if err != nil || len(bad.Categories) != 0 || len(bad.Warnings) != 1 || !strings.HasPrefix(bad.Warnings[0], "categories.json: ") {
+		t.Errorf("an invalid categories.json = %+v, %v, want a warning", bad, err)
+	}
+}
+
+func TestParseMarkdownZipTooManyEntries(t *testing.T) {
+	files := make([]string, 0, 2*(maxZipEntries+1))
+	for i := 0; i <= maxZipEntries; i++ {
+		files = append(files, fmt.Sprintf("%d.txt", i), "")
+	}
+	if _, err := ParseMarkdownZip(zipOf(t, files...), "old"); err == nil || !strings.Contains(err.Error(), "entries") {
+		t.Errorf("ParseMarkdownZip with %d entries = %v, want an error", maxZipEntries+1, err)
+	}
+}
+
+func TestReadZipFileBudget(t *testing.T) {
+	data := zipOf(t, "a.md", "0123456789", "b.md", "0123456789", "c.md", "")
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	budget := int64(15)
+	if content, err := readZipFile(zr.File[0], &budget); err != nil || string(content) != "0123456789" || budget != 5 {
+		t.Fatalf("first file = %q, %v, budget %d", content, err, budget)
+	}
+	if _, err := readZipFile(zr.File[1], &budget); !errors.Is(err, errZipTooLarge) {
+		t.Fatalf("second file = %v, want errZipTooLarge", err)
+	}
+
+	budget = 0
+	if content, err := readZipFile(zr.File[2], &budget); err != nil || len(content) != 0 {
+		t.Errorf("an empty file with no budget left = %q, %v", content, err)
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := map[string]time.Time{
+		"2021-03-04T05:06:07Z":      time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+		"2021-03-04T05:06:07":       time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+		"2021-03-04 05:06:07 +0000": time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+		"2021-03-04 05:06":          time.Date(2021, 3, 4, 5, 6, 0, 0, time.UTC),
+		" 2021-03-04 ":              time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC),
+		"1614834367":                time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+		"yesterday":                 {},
+	}
+	for s, want := range tests {
+		if got := parseDate(s); !got.Equal(want) {
+			t.Errorf("parseDate(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+		err  error
+	}{
+		{"PK\x03\x04rest", FormatMarkdown, nil},
+		{"\xEF\xBB\xBF  <?xml version=\"1.0\"?><rss/>", FormatWXR, nil},
+		{"<rss/>", FormatWXR, nil},
+		{"{\"posts\": []}", "", ErrUnsupported},
+		{"", "", ErrUnsupported},
+	}
+	for _, tt := range tests {
+		got, err := Detect([]byte(tt.data))
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("Detect(%q) = %q, %v, want %q, %v", tt.data, got, err, tt.want, tt.err)
+		}
+	}
+	if _, err := Parse("json", nil, ""); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Parse of an unknown format = %v, want ErrUnsupported", err)
+	}
+}
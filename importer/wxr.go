@@ -0,0 +1,214 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// The WXR elements read. Namespaces are left out of the tags because they
+// change between WXR versions; the two "encoded" elements are told apart by
+// their namespace instead.
+type wxrDocument struct {
+	Channel struct {
+		BaseSiteURL string        `xml:"base_site_url"`
+		BaseBlogURL string        `xml:"base_blog_url"`
+		Link        string        `xml:"link"`
+		Authors     []wxrAuthor   `xml:"author"`
+		Categories  []wxrCategory `xml:"category"`
+		Items       []wxrItem     `xml:"item"`
+	} `xml:"channel"`
+}
+
+type wxrAuthor struct {
+	Login       string `xml:"author_login"`
+	Email       string `xml:"author_email"`
+	DisplayName string `xml:"author_display_name"`
+}
+
+type wxrCategory struct {
+	Slug        string `xml:"category_nicename"`
+	Parent      string `xml:"category_parent"`
+	Name        string `xml:"cat_name"`
+	Description string `xml:"category_description"`
+}
+
+type wxrItem struct {
+	Title   string `xml:"title"`
+	Link    string `xml:"link"`
+	Creator string `xml:"creator"`
+	Encoded []struct {
+		XMLName xml.Name
+		Value   string `xml:",chardata"`
+	} `xml:"encoded"`
+	PostID      string `xml:"post_id"`
+	PostDate    string `xml:"post_date"`
+	PostDateGMT string `xml:"post_date_gmt"`
+	ModifiedGMT string `xml:"post_modified_gmt"`
+	Slug        string `xml:"post_name"`
+	Status      string `xml:"status"`
+	PostType    string `xml:"post_type"`
+	Terms       []struct {
+		Domain   string `xml:"domain,attr"`
+		Nicename string `xml:"nicename,attr"`
+		Name     string `xml:",chardata"`
+	} `xml:"category"`
+}
+
+func isXML(data []byte) bool {
+	data = bytes.TrimLeft(bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF")), " \t\r\n")
+	return bytes.HasPrefix(data, []byte("<"))
+}
+
+// ParseWXR reads a WordPress export. Posts are imported; pages,
+// attachments, menu items and the like are left out, and so are trashed
+// posts and auto-drafts. Posts that are pending review, private or
+// scheduled become drafts.
+func ParseWXR(data []byte) (*Export, error) {
+	var doc wxrDocument
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("reading WXR: %w", err)
+	}
+	ch := doc.Channel
+	site := firstNonEmpty(ch.BaseBlogURL, ch.BaseSiteURL, ch.Link)
+	if site == "" && len(ch.Items) == 0 {
+		return nil, fmt.Errorf("reading WXR: %w: no channel", ErrUnsupported)
+	}
+
+	exp := &Export{Source: FormatWXR + ":" + strings.TrimRight(strings.TrimSpace(site), "/")}
+	for _, a := range ch.Authors {
+		exp.Authors = append(exp.Authors, Author{
+			Login: strings.TrimSpace(a.Login),
+			Email: strings.TrimSpace(a.Email),
+			Name:  strings.TrimSpace(a.DisplayName),
+		})
+	}
+	for _, c := range ch.Categories {
+		exp.Categories = append(exp.Categories, Category{
+			Slug:        strings.TrimSpace(c.Slug),
+			Name:        termName(c.Name),
+			Description: strings.TrimSpace(c.Description),
+			Parent:      strings.TrimSpace(c.Parent),
+		})
+	}
+
+	for _, item := range ch.Items {
+		if item.PostType != "post" {
+			continue
+		}
+		post := Post{
+			SourceID: strings.TrimSpace(item.PostID),
+			Slug:     strings.TrimSpace(item.Slug),
+			Link:     strings.TrimSpace(item.Link),
+			Title:    strings.TrimSpace(item.Title),
+			Format:   "html",
+			Author:   strings.TrimSpace(item.Creator),
+		}
+		for _, e := range item.Encoded {
+			if strings.Contains(e.XMLName.Space, "excerpt") {
+				post.Description = strings.TrimSpace(e.Value)
+			} else {
+				post.Body = autop(e.Value)
+			}
+		}
+		for _, t := range item.Terms {
+			switch t.Domain {
+			case "category":
+				post.Categories = append(post.Categories, Category{Slug: t.Nicename, Name: termName(t.Name)})
+			case "post_tag":
+				post.Tags = append(post.Tags, termName(t.Name))
+			}
+		}
+
+		switch item.Status {
+		case "publish":
+			post.Status = StatusPublished
+		case "draft", "pending", "private", "future":
+			post.Status = StatusDraft
+		case "trash", "auto-draft":
+			continue
+		default:
+			post.Status = StatusDraft
+		}
+		if post.Published = wxrTime(item.PostDateGMT); post.Published.IsZero() {
+			post.Published = wxrTime(item.PostDate)
+		}
+		post.Modified = wxrTime(item.ModifiedGMT)
+
+		if post.SourceID == "" {
+			post.Skip = "no wp:post_id"
+		} else if post.Title == "" && strings.TrimSpace(post.Body) == "" {
+			post.Skip = "no title or content"
+		}
+		exp.Posts = append(exp.Posts, post)
+	}
+	return exp, nil
+}
+
+// termName decodes a category or tag name, which WordPress stores with HTML
+// entities such as "&amp;".
+func termName(name string) string {
+	return html.UnescapeString(strings.TrimSpace(name))
+}
+
+// wxrTime parses WordPress's "2006-01-02 15:04:05" dates; drafts carry
+// "0000-00-00 00:00:00", which gives the zero time.
+func wxrTime(s string) time.Time {
+	t, err := time.Parse(time.DateTime, strings.TrimSpace(s))
+	if err != nil || t.Year() < 1970 {
+		return time.Time{}
+	}
+	return t
+}
+
+var (
+	blankLines = regexp.MustCompile(`\n\s*\n`)
+	blockStart = regexp.MustCompile(`(?i)^<(?:p|div|h[1-6]|ul|ol|li|dl|blockquote|pre|table|figure|hr|section|article|aside|form|iframe|!--)[\s>/-]`)
+	preBlock   = regexp.MustCompile(`(?is)<pre[\s>].*?</pre>`)
+)
+
+// autop adds the paragraphs WordPress leaves out of stored post content and
+// adds when displaying it: text separated by blank lines becomes <p>
+// elements and single line breaks become <br>. Content from the block
+// editor already has its paragraphs and is kept as it is, and so are blocks
+// that start with a block-level element and the inside of <pre> elements.
+func autop(content string) string {
+	content = strings.TrimSpace(strings.ReplaceAll(content, "\r\n", "\n"))
+	if content == "" || strings.Contains(content, "<!-- wp:") {
+		return content
+	}
+	var pres []string
+	content = preBlock.ReplaceAllStringFunc(content, func(pre string) string {
+		pres = append(pres, pre)
+		return fmt.Sprintf("<pre>\x00%d\x00</pre>", len(pres)-1)
+	})
+	blocks := blankLines.Split(content, -1)
+	for i, block := range blocks {
+		block = strings.TrimSpace(block)
+		if !blockStart.MatchString(block) {
+			block = "<p>" + strings.ReplaceAll(block, "\n", "<br>\n") + "</p>"
+		}
+		blocks[i] = block
+	}
+	content = strings.Join(blocks, "\n\n")
+	for i, pre := range pres {
+		content = strings.Replace(content, fmt.Sprintf("<pre>\x00%d\x00</pre>", i), pre, 1)
+	}
+	return content
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
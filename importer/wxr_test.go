@@ -0,0 +1,119 @@
+package importer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testWXR = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+	xmlns:excerpt="http://wordpress.org/export/1.2/excerpt/"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:dc="http://purl.org/dc/elements/1.1/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<title>Old blog</title>
+	<link>https://old.example.com</link>
+	<wp:base_blog_url>https://old.example.com/</wp:base_blog_url>
+	<wp:author><wp:author_login>ann</wp:author_login><wp:author_email>ann@example.com</wp:author_email><wp:author_display_name><![CDATA[Ann A.]]></wp:author_display_name></wp:author>
+	<wp:category><wp:category_nicename>news</wp:category_nicename><wp:category_parent></wp:category_parent><wp:cat_name><![CDATA[News &amp; Views]]></wp:cat_name></wp:category>
+	<wp:category><wp:category_nicename>local</wp:category_nicename><wp:category_parent>news</wp:category_parent><wp:cat_name>Local</wp:cat_name></wp:category>
+	<item>
+		<title>Hello &amp; welcome</title>
+		<link>https://old.example.com/2021/03/hello/</link>
+		<dc:creator><![CDATA[ann]]></dc:creator>
+		<content:encoded><![CDATA[First line
+second line
+
+Another paragraph]]></content:encoded>
+		<excerpt:encoded><![CDATA[A greeting]]></excerpt:encoded>
+		<wp:post_id>7</wp:post_id>
+		<wp:post_date>2021-03-04 06:06:07</wp:post_date>
+		<wp:post_date_gmt>2021-03-04 05:06:07</wp:post_date_gmt>
+		<wp:post_modified_gmt>2021-03-05 00:00:00</wp:post_modified_gmt>
+		<wp:post_name>hello</wp:post_name>
+		<wp:status>publish</wp:status>
+		<wp:post_type>post</wp:post_type>
+		<category domain="category" nicename="news"><![CDATA[News &amp; Views]]></category>
+		<category domain="post_tag" nicename="go"><![CDATA[Go]]></category>
+	</item>
+	<item>
+		<title>Scheduled</title>
+		<content:encoded><![CDATA[<!-- wp:paragraph --><p>Later</p><!-- /wp:paragraph -->]]></content:encoded>
+		<wp:post_id>8</wp:post_id>
+		<wp:post_date>2030-01-01 00:00:00</wp:post_date>
+		<wp:post_date_gmt>0000-00-00 00:00:00</wp:post_date_gmt>
+		<wp:status>future</wp:status>
+		<wp:post_type>post</wp:post_type>
+	</item>
+	<item><title>About</title><wp:post_id>9</wp:post_id><wp:status>publish</wp:status><wp:post_type>page</wp:post_type></item>
+	<item><title>Gone</title><wp:post_id>10</wp:post_id><wp:status>trash</wp:status><wp:post_type>post</wp:post_type></item>
+	<item><title></title><wp:post_id>11</wp:post_id><wp:status>draft</wp:status><wp:post_type>post</wp:post_type></item>
+</channel>
+</rss>`
+
+func TestParseWXR(t *testing.T) {
+	exp, err := ParseWXR([]byte(testWXR))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp.Source != "wxr:https://old.example.com" {
+		t.Errorf("Source = %q", exp.Source)
+	}
+	if want := []Author{{Login: "ann", Email: "ann@example.com", Name: "Ann A."}}; !reflect.DeepEqual(exp.Authors, want) {
+		t.Errorf("Authors = %+v, want %+v", exp.Authors, want)
+	}
+	wantCategories := []Category{{Slug: "news", Name: "News & Views"}, {Slug: "local", Name: "Local", Parent: "news"}}
+	if !reflect.DeepEqual(exp.Categories, wantCategories) {
+		t.Errorf("Categories = %+v, want %+v", exp.Categories, wantCategories)
+	}
+
+	want := []Post{
+		{
+			SourceID: "7", Slug: "hello", Link: "https://old.example.com/2021/03/hello/", Title: "Hello & welcome",
+			Description: "A greeting", Body: "<p>First line<br>\nsecond line</p>\n\n<p>Another paragraph</p>",
+			Format: "html", Status: StatusPublished, Author: "ann",
+			Categories: []Category{{Slug: "news", Name: "News & Views"}}, Tags: []string{"Go"},
+			Published: time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC), Modified: time.Date(2021, 3, 5, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			SourceID: "8", Title: "Scheduled", Body: "<!-- wp:paragraph --><p>Later</p><!-- /wp:paragraph -->",
+			Format: "html", Status: StatusDraft, Published: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{SourceID: "11", Format: "html", Status: StatusDraft, Skip: "no title or content"},
+	}
+	if !reflect.DeepEqual(exp.Posts, want) {
+		t.Errorf("Posts =\n%+v\nwant\n%+v", exp.Posts, want)
+	}
+}
+
+func TestParseWXRInvalid(t *testing.T) {
+	if _, err := ParseWXR([]byte("<rss><channel></channel></rss>")); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("an empty channel = %v, want ErrUnsupported", err)
+	}
+	if _, err := ParseWXR([]byte("not xml")); err == nil {
+		t.Error("text that is not XML parsed")
+	}
+}
+
+func TestAutop(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"One\r\ntwo", "<p>One<br>\ntwo</p>"},
+		{"One\n\n  \nTwo", "<p>One</p>\n\n<p>Two</p>"},
+		{"<h2>Title</h2>\n\nText", "<h2>Title</h2>\n\n<p>Text</p>"},
+		{"<pre>a\n\nb</pre>\n\nAfter", "<pre>a\n\nb</pre>\n\n<p>After</p>"},
+		{"<!-- wp:paragraph -->\n<p>Block</p>\n<!-- /wp:paragraph -->", "<!-- wp:paragraph -->\n<p>Block</p>\n<!-- /wp:paragraph -->"},
+		{"<em>inline</em> start", "<p><em>inline</em> start</p>"},
+	}
+	for _, tt := range tests {
+		if got := autop(tt.in); got != tt.want {
+			t.Errorf("autop(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
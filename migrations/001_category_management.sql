@@ -0,0 +1,31 @@
+-- Category management: slugs, descriptions, hierarchy and unique names.
+-- Posts gain a publication status so categories can report published counts.
+--
+-- Existing categories with case-insensitively duplicated names must be merged
+-- before this migration is applied, otherwise the unique index below fails.
+
+ALTER TABLE categories
+    ADD COLUMN IF NOT EXISTS slug        TEXT,
+    ADD COLUMN IF NOT EXISTS description TEXT      NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS parent_id   INT       REFERENCES categories (id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    ADD COLUMN IF NOT EXISTS updated_at  TIMESTAMP NOT NULL DEFAULT NOW();
+
+UPDATE categories
+SET slug = TRIM(BOTH '-' FROM LOWER(REGEXP_REPLACE(name, '[^a-zA-Z0-9]+', '-', 'g'))) || '-' || id
+WHERE slug IS NULL;
+
+ALTER TABLE categories ALTER COLUMN slug SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS categories_name_lower_key ON categories (LOWER(name));
+CREATE UNIQUE INDEX IF NOT EXISTS categories_slug_key ON categories (slug);
+CREATE INDEX IF NOT EXISTS categories_parent_id_idx ON categories (parent_id);
+
+ALTER TABLE posts
+    ADD COLUMN IF NOT EXISTS status       TEXT      NOT NULL DEFAULT 'published'
+        CHECK (status IN ('draft', 'published')),
+    ADD COLUMN IF NOT EXISTS published_at TIMESTAMP;
+
+UPDATE posts SET published_at = created_at WHERE status = 'published' AND published_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS posts_category_id_idx ON posts (category_id);
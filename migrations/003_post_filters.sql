@@ -0,0 +1,10 @@
+-- Listing filters and extra sort fields: view counts for popularity and
+-- indexes backing the new sort orders.
+
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS view_count INT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS posts_created_at_id_idx ON posts (created_at, id);
+CREATE INDEX IF NOT EXISTS posts_updated_at_id_idx ON posts (updated_at, id);
+CREATE INDEX IF NOT EXISTS posts_published_at_id_idx ON posts (published_at, id);
+CREATE INDEX IF NOT EXISTS posts_view_count_id_idx ON posts (view_count, id);
+CREATE INDEX IF NOT EXISTS posts_status_idx ON posts (status);
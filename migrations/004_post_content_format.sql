@@ -0,0 +1,9 @@
+-- Post content formats with server-side rendered, sanitized HTML.
+-- body_html and toc are a cache of the rendered body; rows where body_html is
+-- NULL are rendered on first read.
+
+ALTER TABLE posts
+    ADD COLUMN IF NOT EXISTS content_format TEXT NOT NULL DEFAULT 'markdown'
+        CHECK (content_format IN ('markdown', 'html', 'plain')),
+    ADD COLUMN IF NOT EXISTS body_html TEXT,
+    ADD COLUMN IF NOT EXISTS toc JSONB NOT NULL DEFAULT '[]';
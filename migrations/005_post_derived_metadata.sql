@@ -0,0 +1,8 @@
+-- Metadata derived from the post body on every create/update. Existing rows
+-- are filled in by running: blog-backend backfill-post-metadata
+
+ALTER TABLE posts
+    ADD COLUMN IF NOT EXISTS excerpt              TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS word_count           INT  NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS reading_time_minutes INT  NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS first_image          TEXT;
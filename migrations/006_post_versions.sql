@@ -0,0 +1,4 @@
+-- Optimistic concurrency: every successful update bumps the version, which is
+-- exposed to clients as the post's ETag.
+
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
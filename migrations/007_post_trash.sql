@@ -0,0 +1,8 @@
+-- Soft deletion: deleted posts move to a trash bin until they are restored,
+-- purged by hand or purged automatically after TRASH_RETENTION.
+
+ALTER TABLE posts
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS deleted_by INT REFERENCES users (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS posts_deleted_at_idx ON posts (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,21 @@
+-- Threaded comments. depth is 0 for top-level comments; reply_count counts
+-- live direct replies and backs the "top" sort. Deleted comments keep their
+-- row (with the body cleared) while they still have replies.
+
+CREATE TABLE IF NOT EXISTS comments (
+    id          SERIAL PRIMARY KEY,
+    post_id     INT       NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    user_id     INT       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    parent_id   INT       REFERENCES comments (id) ON DELETE CASCADE,
+    depth       INT       NOT NULL DEFAULT 0,
+    body        TEXT      NOT NULL,
+    reply_count INT       NOT NULL DEFAULT 0,
+    edited_at   TIMESTAMP,
+    deleted_at  TIMESTAMP,
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS comments_post_created_idx ON comments (post_id, created_at, id);
+CREATE INDEX IF NOT EXISTS comments_post_replies_idx ON comments (post_id, reply_count, id);
+CREATE INDEX IF NOT EXISTS comments_parent_id_idx ON comments (parent_id);
@@ -0,0 +1,25 @@
+-- Comment moderation: every comment has a moderation status, and only
+-- approved comments are shown or counted. Existing comments are approved.
+-- spam_tokens / spam_corpus hold the Bayes filter's training counts.
+
+ALTER TABLE comments
+    ADD COLUMN IF NOT EXISTS status             TEXT      NOT NULL DEFAULT 'approved'
+        CHECK (status IN ('pending', 'approved', 'rejected', 'spam')),
+    ADD COLUMN IF NOT EXISTS spam_score         REAL,
+    ADD COLUMN IF NOT EXISTS moderation_reasons TEXT[]    NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS moderated_by       INT       REFERENCES users (id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS moderated_at       TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS comments_moderation_idx ON comments (status, created_at) WHERE status <> 'approved';
+CREATE INDEX IF NOT EXISTS comments_user_status_idx ON comments (user_id, status);
+
+CREATE TABLE IF NOT EXISTS spam_tokens (
+    token      TEXT PRIMARY KEY,
+    spam_count INT NOT NULL DEFAULT 0,
+    ham_count  INT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS spam_corpus (
+    label     TEXT PRIMARY KEY CHECK (label IN ('spam', 'ham')),
+    documents INT  NOT NULL DEFAULT 0
+);
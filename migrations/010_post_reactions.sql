@@ -0,0 +1,20 @@
+-- Per-user reactions on posts. post_reaction_counts is maintained in the same
+-- transaction as post_reactions so listings can read counts without grouping.
+
+CREATE TABLE IF NOT EXISTS post_reactions (
+    post_id    INT       NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    user_id    INT       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    reaction   TEXT      NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (post_id, user_id, reaction)
+);
+
+CREATE INDEX IF NOT EXISTS post_reactions_user_idx ON post_reactions (user_id, created_at);
+CREATE INDEX IF NOT EXISTS post_reactions_post_reaction_idx ON post_reactions (post_id, reaction, created_at);
+
+CREATE TABLE IF NOT EXISTS post_reaction_counts (
+    post_id  INT  NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    reaction TEXT NOT NULL,
+    count    INT  NOT NULL DEFAULT 0 CHECK (count >= 0),
+    PRIMARY KEY (post_id, reaction)
+);
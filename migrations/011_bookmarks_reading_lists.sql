@@ -0,0 +1,33 @@
+-- Reader bookmarks and named reading lists. Rows stay when a post is trashed
+-- or unpublished; such posts are filtered out when lists are read.
+
+CREATE TABLE IF NOT EXISTS bookmarks (
+    user_id    INT       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    post_id    INT       NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, post_id)
+);
+
+CREATE INDEX IF NOT EXISTS bookmarks_user_created_idx ON bookmarks (user_id, created_at);
+
+CREATE TABLE IF NOT EXISTS reading_lists (
+    id          SERIAL PRIMARY KEY,
+    user_id     INT       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name        TEXT      NOT NULL,
+    description TEXT      NOT NULL DEFAULT '',
+    share_token TEXT      UNIQUE,
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS reading_lists_user_id_idx ON reading_lists (user_id);
+
+CREATE TABLE IF NOT EXISTS reading_list_items (
+    list_id  INT       NOT NULL REFERENCES reading_lists (id) ON DELETE CASCADE,
+    post_id  INT       NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    position INT       NOT NULL,
+    added_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (list_id, post_id)
+);
+
+CREATE INDEX IF NOT EXISTS reading_list_items_position_idx ON reading_list_items (list_id, position);
@@ -0,0 +1,26 @@
+-- Users following authors, categories and tags; the personalised feed lists
+-- published posts from any of them.
+
+CREATE TABLE IF NOT EXISTS author_follows (
+    user_id    INT       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    author_id  INT       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, author_id),
+    CHECK (user_id <> author_id)
+);
+
+CREATE INDEX IF NOT EXISTS author_follows_author_id_idx ON author_follows (author_id);
+
+CREATE TABLE IF NOT EXISTS category_follows (
+    user_id     INT       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    category_id INT       NOT NULL REFERENCES categories (id) ON DELETE CASCADE,
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, category_id)
+);
+
+CREATE TABLE IF NOT EXISTS tag_follows (
+    user_id    INT       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    tag_id     INT       NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, tag_id)
+);
@@ -0,0 +1,28 @@
+-- In-app notifications. Events about the same thing (reactions to a post,
+-- comments on a post, new followers) are folded into one unread row per
+-- group_key, so actor_ids lists everyone involved, most recent first. Once
+-- read, the next event starts a new row.
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id         SERIAL PRIMARY KEY,
+    user_id    INT       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    type       TEXT      NOT NULL,
+    group_key  TEXT      NOT NULL,
+    post_id    INT       REFERENCES posts (id) ON DELETE CASCADE,
+    comment_id INT       REFERENCES comments (id) ON DELETE CASCADE,
+    actor_ids  INT[]     NOT NULL DEFAULT '{}',
+    read_at    TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS notifications_unread_group_idx ON notifications (user_id, group_key) WHERE read_at IS NULL;
+CREATE INDEX IF NOT EXISTS notifications_user_updated_idx ON notifications (user_id, updated_at DESC);
+
+-- Types a user turned off; a missing row means enabled.
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id INT     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    type    TEXT    NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    PRIMARY KEY (user_id, type)
+);
@@ -0,0 +1,17 @@
+-- Log of public post and comment events for the Server-Sent Events stream.
+-- Ids are what clients send back as Last-Event-ID to resume; each insert is
+-- also broadcast with NOTIFY blog_stream so every instance can fan it out.
+-- Old rows are pruned after STREAM_RETENTION.
+
+CREATE TABLE IF NOT EXISTS stream_events (
+    id          BIGSERIAL PRIMARY KEY,
+    type        TEXT      NOT NULL,
+    post_id     INT       NOT NULL,
+    author_id   INT       NOT NULL,
+    category_id INT       NOT NULL,
+    comment_id  INT,
+    title       TEXT      NOT NULL DEFAULT '',
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS stream_events_created_at_idx ON stream_events (created_at);
@@ -0,0 +1,36 @@
+-- Admin-managed outgoing webhooks. Every event a webhook subscribes to gets
+-- a delivery row, which doubles as the retry queue (pending rows are picked
+-- up once next_attempt_at passes) and the delivery log.
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id            SERIAL PRIMARY KEY,
+    url           TEXT      NOT NULL,
+    description   TEXT      NOT NULL DEFAULT '',
+    events        TEXT[]    NOT NULL,
+    secret        TEXT      NOT NULL,
+    active        BOOLEAN   NOT NULL DEFAULT TRUE,
+    -- consecutive failed attempts; the webhook is disabled when it reaches WEBHOOK_DISABLE_AFTER
+    failure_count INT       NOT NULL DEFAULT 0,
+    disabled_at   TIMESTAMP,
+    created_by    INT       REFERENCES users (id) ON DELETE SET NULL,
+    created_at    TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id               BIGSERIAL PRIMARY KEY,
+    webhook_id       INT       NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_type       TEXT      NOT NULL,
+    payload          JSONB     NOT NULL,
+    status           TEXT      NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts         INT       NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_status_code INT,
+    last_error       TEXT      NOT NULL DEFAULT '',
+    replay_of        BIGINT    REFERENCES webhook_deliveries (id) ON DELETE SET NULL,
+    created_at       TIMESTAMP NOT NULL DEFAULT NOW(),
+    delivered_at     TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_idx ON webhook_deliveries (webhook_id, created_at DESC);
@@ -0,0 +1,15 @@
+-- Uploaded media. The file itself lives in the configured storage backend
+-- under storage_key; private media is only served through signed URLs.
+
+CREATE TABLE IF NOT EXISTS media (
+    id           SERIAL PRIMARY KEY,
+    user_id      INT       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    storage_key  TEXT      NOT NULL UNIQUE,
+    filename     TEXT      NOT NULL,
+    content_type TEXT      NOT NULL,
+    size_bytes   BIGINT    NOT NULL,
+    private      BOOLEAN   NOT NULL DEFAULT FALSE,
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS media_user_created_idx ON media (user_id, created_at DESC);
@@ -0,0 +1,25 @@
+-- Image processing for uploaded media. Images are stored stripped of their
+-- metadata and queued as 'pending'; a worker pool claims them, records a
+-- blurhash placeholder and writes resized variants next to the original.
+
+ALTER TABLE media
+    ADD COLUMN IF NOT EXISTS width            INT,
+    ADD COLUMN IF NOT EXISTS height           INT,
+    ADD COLUMN IF NOT EXISTS blurhash         TEXT      NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS status           TEXT      NOT NULL DEFAULT 'ready'
+        CHECK (status IN ('pending', 'processing', 'ready', 'failed')),
+    ADD COLUMN IF NOT EXISTS processing_error TEXT      NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS lease_until      TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS media_queue_idx ON media (id) WHERE status IN ('pending', 'processing');
+
+CREATE TABLE IF NOT EXISTS media_variants (
+    media_id     INT    NOT NULL REFERENCES media (id) ON DELETE CASCADE,
+    storage_key  TEXT   NOT NULL UNIQUE,
+    format       TEXT   NOT NULL,
+    content_type TEXT   NOT NULL,
+    width        INT    NOT NULL,
+    height       INT    NOT NULL,
+    size_bytes   BIGINT NOT NULL,
+    PRIMARY KEY (media_id, format, width)
+);
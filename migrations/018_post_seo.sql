@@ -0,0 +1,12 @@
+-- Featured images and per-post SEO settings. The featured image is an upload
+-- from the media library; deleting it is refused while a post features it.
+
+ALTER TABLE posts
+    ADD COLUMN IF NOT EXISTS featured_media_id  INT     REFERENCES media (id) ON DELETE RESTRICT,
+    ADD COLUMN IF NOT EXISTS featured_image_alt TEXT    NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS meta_title         TEXT    NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS meta_description   TEXT    NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS canonical_url      TEXT    NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS noindex            BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS posts_featured_media_idx ON posts (featured_media_id) WHERE featured_media_id IS NOT NULL;
@@ -0,0 +1,32 @@
+-- Imports from WordPress (WXR) and Markdown exports. Uploads are kept on the
+-- job until a worker has run it; import_items remembers which post each
+-- source post became, so running an import again updates posts instead of
+-- duplicating them.
+
+CREATE TABLE IF NOT EXISTS import_jobs (
+    id          SERIAL PRIMARY KEY,
+    user_id     INT       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    format      TEXT      NOT NULL CHECK (format IN ('wxr', 'markdown')),
+    filename    TEXT      NOT NULL DEFAULT '',
+    dry_run     BOOLEAN   NOT NULL DEFAULT FALSE,
+    status      TEXT      NOT NULL DEFAULT 'queued'
+        CHECK (status IN ('queued', 'running', 'done', 'failed')),
+    payload     BYTEA,
+    report      JSONB,
+    error       TEXT      NOT NULL DEFAULT '',
+    lease_until TIMESTAMP,
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    started_at  TIMESTAMP,
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS import_jobs_queue_idx ON import_jobs (id) WHERE status IN ('queued', 'running');
+
+CREATE TABLE IF NOT EXISTS import_items (
+    source      TEXT      NOT NULL,
+    source_id   TEXT      NOT NULL,
+    post_id     INT       NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    checksum    TEXT      NOT NULL,
+    imported_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (source, source_id)
+);
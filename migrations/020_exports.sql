@@ -0,0 +1,20 @@
+-- Exports of the blog as a ZIP of Markdown posts, metadata and media. A
+-- worker writes the ZIP to the media storage backend under storage_key.
+
+CREATE TABLE IF NOT EXISTS export_jobs (
+    id          SERIAL PRIMARY KEY,
+    user_id     INT       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    filter      JSONB     NOT NULL DEFAULT '{}',
+    status      TEXT      NOT NULL DEFAULT 'queued'
+        CHECK (status IN ('queued', 'running', 'done', 'failed')),
+    storage_key TEXT      NOT NULL DEFAULT '',
+    size_bytes  BIGINT    NOT NULL DEFAULT 0,
+    counts      JSONB,
+    error       TEXT      NOT NULL DEFAULT '',
+    lease_until TIMESTAMP,
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    started_at  TIMESTAMP,
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS export_jobs_queue_idx ON export_jobs (id) WHERE status IN ('queued', 'running');
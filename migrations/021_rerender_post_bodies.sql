@@ -0,0 +1,6 @@
+-- Bodies rendered by the first markdown renderer can hold markup its heading
+-- anchors unescaped. Forgetting the cached HTML renders them again on their
+-- next read; to render them all at once, run:
+-- blog-backend backfill-post-metadata
+
+UPDATE posts SET body_html = NULL WHERE body_html IS NOT NULL;
@@ -0,0 +1,7 @@
+-- The label each comment last trained the spam filter with and the tokens it
+-- was trained on, so that a reversed moderation decision can be taken back.
+-- Decisions made before this migration are not tracked and stay trained.
+
+ALTER TABLE comments
+    ADD COLUMN IF NOT EXISTS trained_as     TEXT CHECK (trained_as IN ('spam', 'ham')),
+    ADD COLUMN IF NOT EXISTS trained_tokens TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,33 @@
+-- A unique, lower-case handle per user that @mentions refer to. The display
+-- name (users.name) stays free-form and need not be unique. Existing users
+-- get a handle derived from their name, numbered when it is already taken.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS handle TEXT;
+
+DO $$
+DECLARE
+    u         RECORD;
+    base      TEXT;
+    candidate TEXT;
+    n         INT;
+BEGIN
+    FOR u IN SELECT id, name FROM users WHERE handle IS NULL ORDER BY id LOOP
+        base := RTRIM(LEFT(TRIM(BOTH '._-' FROM REGEXP_REPLACE(LOWER(u.name), '[^a-z0-9_.-]+', '', 'g')), 30), '._-');
+        IF base = '' THEN
+            base := 'user';
+        END IF;
+        candidate := base;
+        n := 1;
+        WHILE EXISTS (SELECT 1 FROM users WHERE handle = candidate) LOOP
+            n := n + 1;
+            candidate := RTRIM(LEFT(base, 29 - LENGTH(n::TEXT)), '._-') || '-' || n;
+        END LOOP;
+        UPDATE users SET handle = candidate WHERE id = u.id;
+    END LOOP;
+END $$;
+
+ALTER TABLE users
+    ALTER COLUMN handle SET NOT NULL,
+    ADD CONSTRAINT users_handle_format CHECK (handle ~ '^[a-z0-9_]([a-z0-9_.-]{0,28}[a-z0-9_])?$');
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_handle_idx ON users (handle);
@@ -0,0 +1,6 @@
+-- The site a Markdown import comes from, as given when it was started.
+-- Markdown ZIPs used to share the source "markdown" whatever site they came
+-- from; their import_items are left as they are, so importing such a ZIP
+-- again creates new posts instead of updating the old ones.
+
+ALTER TABLE import_jobs ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT '';
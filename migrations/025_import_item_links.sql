@@ -0,0 +1,8 @@
+-- Where an imported post lived on its old site. Posts here are addressed by
+-- id, so the old slug and permalink are kept beside the import item, for
+-- exports and redirects. Items imported earlier get them the next time
+-- their post changes in the export.
+
+ALTER TABLE import_items
+    ADD COLUMN IF NOT EXISTS slug TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS link TEXT NOT NULL DEFAULT '';
@@ -1,9 +1,21 @@
 package model
 
+import "time"
+
 type Category struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+	ID          int         `json:"id"`
+	Name        string      `json:"name"`
+	Slug        string      `json:"slug"`
+	Description string      `json:"description"`
+	ParentID    *int        `json:"parent_id"`
+	PostCount   int         `json:"post_count"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+	Children    []*Category `json:"children,omitempty"`
 }
 type CategoryRequest struct {
-	Name string `json:"name" example:"Electronics"`
+	Name        string `json:"name" example:"Electronics"`
+	Slug        string `json:"slug" example:"electronics"`
+	Description string `json:"description" example:"Gadgets, reviews and teardowns"`
+	ParentID    *int   `json:"parent_id" example:"1"`
 }
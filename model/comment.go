@@ -0,0 +1,75 @@
+package model
+
+import "time"
+
+// Comment sort orders
+const (
+	CommentSortOldest = "oldest"
+	CommentSortNewest = "newest"
+	CommentSortTop    = "top"
+)
+
+// Comment moderation statuses
+const (
+	CommentStatusPending  = "pending"
+	CommentStatusApproved = "approved"
+	CommentStatusRejected = "rejected"
+	CommentStatusSpam     = "spam"
+)
+
+// Comment is a reader comment on a post. Deleted comments that still have
+// replies are kept as placeholders with an empty body.
+type Comment struct {
+	ID         int        `json:"comment_id"`
+	PostID     int        `json:"post_id"`
+	UserID     int        `json:"user_id"`
+	AuthorName string     `json:"author_name"`
+	ParentID   *int       `json:"parent_id"`
+	Depth      int        `json:"depth"`
+	Body       string     `json:"body"`
+	ReplyCount int        `json:"reply_count"`
+	Deleted    bool       `json:"deleted"`
+	Status     string     `json:"status"`
+	SpamScore  *float64   `json:"spam_score,omitempty"`
+	Reasons    []string   `json:"moderation_reasons,omitempty"`
+	EditedAt   *time.Time `json:"edited_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	Replies    []*Comment `json:"replies,omitempty"`
+}
+
+type CommentRequest struct {
+	Body     string `json:"body" example:"Great post!"`
+	ParentID *int   `json:"parent_id" example:"12"`
+}
+
+// CommentListParams describes one page of top-level comments on a post.
+type CommentListParams struct {
+	PostID int
+	Sort   string
+	Limit  int
+	Cursor *CommentCursor
+}
+
+// CommentCursor marks the last top-level comment of a page.
+type CommentCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// CommentPage is one page of top-level comments with their replies nested.
+type CommentPage struct {
+	Comments   []*Comment `json:"comments"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+	// Total counts the top-level comments the pages walk through, not replies
+	Total int `json:"total"`
+}
+
+// ModerationPage is one page of the moderation queue, oldest first.
+type ModerationPage struct {
+	Comments []*Comment `json:"comments"`
+	Total    int        `json:"total"`
+	Page     int        `json:"page"`
+	Limit    int        `json:"limit"`
+}
@@ -0,0 +1,98 @@
+package model
+
+import "time"
+
+// Export job statuses
+const (
+	ExportQueued  = "queued"
+	ExportRunning = "running"
+	ExportDone    = "done"
+	ExportFailed  = "failed"
+)
+
+// ExportFilter narrows an export to one author, one category or posts
+// created within a date range. The zero filter exports everything.
+type ExportFilter struct {
+	AuthorID   int        `json:"author_id,omitempty"`
+	CategoryID int        `json:"category_id,omitempty"`
+	From       *time.Time `json:"from,omitempty"`
+	To         *time.Time `json:"to,omitempty"`
+}
+
+// IsZero reports whether the filter lets every post through.
+func (f ExportFilter) IsZero() bool {
+	return f.AuthorID == 0 && f.CategoryID == 0 && f.From == nil && f.To == nil
+}
+
+type ExportRequest struct {
+	AuthorID   int    `json:"author_id" example:"3"`
+	CategoryID int    `json:"category_id" example:"2"`
+	From       string `json:"from" example:"2024-01-01"`
+	To         string `json:"to" example:"2024-12-31"`
+}
+
+// ExportJob is an export being written in the background. DownloadPath is
+// set once the ZIP is ready.
+type ExportJob struct {
+	ID           int           `json:"export_id"`
+	UserID       int           `json:"user_id"`
+	Filter       ExportFilter  `json:"filter"`
+	Status       string        `json:"status"`
+	Key          string        `json:"-"`
+	Size         int64         `json:"size_bytes"`
+	Counts       *ExportCounts `json:"counts,omitempty"`
+	DownloadPath string        `json:"download_path,omitempty"`
+	Error        string        `json:"error,omitempty"`
+	CreatedAt    time.Time     `json:"created_at"`
+	StartedAt    *time.Time    `json:"started_at"`
+	FinishedAt   *time.Time    `json:"finished_at"`
+}
+
+type ExportCounts struct {
+	Posts      int `json:"posts"`
+	Categories int `json:"categories"`
+	Tags       int `json:"tags"`
+	Authors    int `json:"authors"`
+	Media      int `json:"media"`
+}
+
+// ExportManifest is manifest.json at the root of an export ZIP. Site is
+// the blog's public address, when configured; importing the ZIP uses it to
+// tell this blog's posts from another's.
+type ExportManifest struct {
+	Version   int             `json:"version"`
+	Site      string          `json:"site,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	Filter    ExportFilter    `json:"filter"`
+	Counts    ExportCounts    `json:"counts"`
+	Posts     []ExportedPost  `json:"posts"`
+	Media     []ExportedMedia `json:"media"`
+}
+
+// ExportedPost says where in the ZIP a post was written.
+type ExportedPost struct {
+	ID     int    `json:"id"`
+	Path   string `json:"path"`
+	Title  string `json:"title"`
+	Status string `json:"status"`
+}
+
+// ExportedMedia says where in the ZIP a media file was written. Missing
+// media is listed but could not be read from storage.
+type ExportedMedia struct {
+	ID          int    `json:"id"`
+	Key         string `json:"key"`
+	Path        string `json:"path,omitempty"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size_bytes"`
+	Private     bool   `json:"private"`
+	Missing     bool   `json:"missing,omitempty"`
+}
+
+type ExportPage struct {
+	Exports []*ExportJob `json:"exports"`
+	Total   int          `json:"total"`
+	Page    int          `json:"page"`
+	Limit   int          `json:"limit"`
+}
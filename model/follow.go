@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// What a user can follow
+const (
+	FollowAuthor   = "author"
+	FollowCategory = "category"
+	FollowTag      = "tag"
+)
+
+// Follow is one author, category or tag a user follows. Slug is set for
+// categories and tags.
+type Follow struct {
+	Kind       string    `json:"kind"`
+	TargetID   int       `json:"target_id"`
+	Name       string    `json:"name"`
+	Slug       string    `json:"slug,omitempty"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+// AuthorProfile is the public view of a user who writes posts. Following
+// counts the authors this user follows.
+type AuthorProfile struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"username"`
+	PostCount    int       `json:"post_count"`
+	Followers    int       `json:"followers"`
+	Following    int       `json:"following"`
+	FollowedByMe *bool     `json:"followed_by_me,omitempty"`
+	JoinedAt     time.Time `json:"joined_at"`
+}
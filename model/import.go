@@ -0,0 +1,76 @@
+package model
+
+import "time"
+
+// Import job statuses
+const (
+	ImportQueued  = "queued"
+	ImportRunning = "running"
+	ImportDone    = "done"
+	ImportFailed  = "failed"
+)
+
+// What an import does with each post
+const (
+	ImportCreate    = "create"
+	ImportUpdate    = "update"
+	ImportUnchanged = "unchanged"
+	ImportSkip      = "skip"
+	ImportFail      = "fail"
+)
+
+// ImportJob is an uploaded export being imported in the background. Source
+// names the site a Markdown ZIP comes from when the ZIP doesn't. Report is
+// filled in once the job is done; Error says why a failed job failed.
+type ImportJob struct {
+	ID         int           `json:"import_id"`
+	UserID     int           `json:"user_id"`
+	Format     string        `json:"format"`
+	Filename   string        `json:"filename"`
+	Source     string        `json:"source,omitempty"`
+	DryRun     bool          `json:"dry_run"`
+	Status     string        `json:"status"`
+	Report     *ImportReport `json:"report,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	CreatedAt  time.Time     `json:"created_at"`
+	StartedAt  *time.Time    `json:"started_at"`
+	FinishedAt *time.Time    `json:"finished_at"`
+}
+
+// ImportReport tells what an import did, or in a dry run what it would do.
+type ImportReport struct {
+	Source    string `json:"source"`
+	DryRun    bool   `json:"dry_run"`
+	Total     int    `json:"total"`
+	Created   int    `json:"created"`
+	Updated   int    `json:"updated"`
+	Unchanged int    `json:"unchanged"`
+	Skipped   int    `json:"skipped"`
+	Failed    int    `json:"failed"`
+	// CategoriesCreated are the slugs of categories that did not exist yet
+	CategoriesCreated []string `json:"categories_created"`
+	// UnmatchedAuthors matched no user by email; their posts belong to whoever started the import
+	UnmatchedAuthors []string       `json:"unmatched_authors"`
+	Posts            []ImportedPost `json:"posts"`
+	Warnings         []string       `json:"warnings"`
+}
+
+// ImportedPost is the outcome for one post of an export. Slug and Link are
+// where the post lived before, for setting up redirects.
+type ImportedPost struct {
+	SourceID string   `json:"source_id"`
+	Title    string   `json:"title"`
+	Slug     string   `json:"slug,omitempty"`
+	Link     string   `json:"link,omitempty"`
+	Action   string   `json:"action"`
+	PostID   int      `json:"post_id,omitempty"`
+	Reason   string   `json:"reason,omitempty"`
+	Notes    []string `json:"notes,omitempty"`
+}
+
+type ImportPage struct {
+	Imports []*ImportJob `json:"imports"`
+	Total   int          `json:"total"`
+	Page    int          `json:"page"`
+	Limit   int          `json:"limit"`
+}
@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+// Processing states of uploaded media
+const (
+	MediaPending    = "pending"
+	MediaProcessing = "processing"
+	MediaReady      = "ready"
+	MediaFailed     = "failed"
+)
+
+// Media is an uploaded file. URL is where public media is served; private
+// media has none and is reached through a signed, expiring URL instead.
+// Images also get their displayed size, a blurhash placeholder and, once
+// processed, smaller variants; SrcSet lists them for an <img srcset>.
+type Media struct {
+	ID              int            `json:"media_id"`
+	UserID          int            `json:"user_id"`
+	Key             string         `json:"key"`
+	Filename        string         `json:"filename"`
+	ContentType     string         `json:"content_type"`
+	Size            int64          `json:"size_bytes"`
+	Private         bool           `json:"private"`
+	URL             string         `json:"url,omitempty"`
+	Width           int            `json:"width,omitempty"`
+	Height          int            `json:"height,omitempty"`
+	BlurHash        string         `json:"blurhash,omitempty"`
+	Status          string         `json:"status"`
+	ProcessingError string         `json:"processing_error,omitempty"`
+	Variants        []MediaVariant `json:"variants,omitempty"`
+	SrcSet          string         `json:"srcset,omitempty"`
+	CreatedAt       time.Time      `json:"created_at"`
+}
+
+// MediaVariant is a resized copy of an uploaded image.
+type MediaVariant struct {
+	Key         string `json:"key"`
+	Format      string `json:"format"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Size        int64  `json:"size_bytes"`
+	URL         string `json:"url,omitempty"`
+}
+
+type MediaPage struct {
+	Media []*Media `json:"media"`
+	Total int      `json:"total"`
+	Page  int      `json:"page"`
+	Limit int      `json:"limit"`
+}
+
+// SignedMediaURL is a temporary link to private media and its variants.
+type SignedMediaURL struct {
+	URL       string         `json:"url"`
+	Variants  []MediaVariant `json:"variants,omitempty"`
+	SrcSet    string         `json:"srcset,omitempty"`
+	ExpiresAt time.Time      `json:"expires_at"`
+}
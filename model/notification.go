@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+// Notification types
+const (
+	NotificationComment  = "comment"
+	NotificationReply    = "reply"
+	NotificationReaction = "reaction"
+	NotificationMention  = "mention"
+	NotificationFollow   = "follow"
+	NotificationNewPost  = "new_post"
+)
+
+// NotificationTypes lists every notification type, in the order preferences are shown.
+var NotificationTypes = []string{
+	NotificationComment,
+	NotificationReply,
+	NotificationReaction,
+	NotificationMention,
+	NotificationFollow,
+	NotificationNewPost,
+}
+
+type NotificationActor struct {
+	UserID int    `json:"user_id"`
+	Name   string `json:"username"`
+}
+
+// Notification is one inbox entry. Grouped events share an entry; Actors
+// holds the most recent few of ActorCount users involved.
+type Notification struct {
+	ID         int                  `json:"notification_id"`
+	Type       string               `json:"type"`
+	Message    string               `json:"message"`
+	PostID     *int                 `json:"post_id,omitempty"`
+	PostTitle  string               `json:"post_title,omitempty"`
+	CommentID  *int                 `json:"comment_id,omitempty"`
+	Actors     []*NotificationActor `json:"actors"`
+	ActorCount int                  `json:"actor_count"`
+	Read       bool                 `json:"read"`
+	CreatedAt  time.Time            `json:"created_at"`
+	UpdatedAt  time.Time            `json:"updated_at"`
+}
+
+type NotificationPage struct {
+	Notifications []*Notification `json:"notifications"`
+	Unread        int             `json:"unread"`
+	Total         int             `json:"total"`
+	Page          int             `json:"page"`
+	Limit         int             `json:"limit"`
+}
+
+// NotificationPreferences maps notification types to whether they are delivered.
+type NotificationPreferences map[string]bool
@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+// Listing status filter values besides the post statuses themselves
+const PostStatusAll = "all"
+
+// PostFilter narrows the post listing. Zero values mean "no filter", except
+// Status which defaults to published posts only.
+type PostFilter struct {
+	Search        string
+	CategoryID    int
+	AuthorID      int
+	Tag           string
+	Status        string
+	CreatedFrom   *time.Time
+	CreatedTo     *time.Time
+	UpdatedFrom   *time.Time
+	UpdatedTo     *time.Time
+	PublishedFrom *time.Time
+	PublishedTo   *time.Time
+	HasBody       *bool
+	// FollowedBy keeps posts by authors, in categories or with tags this user follows
+	FollowedBy int
+}
+
+// PostListParams describes one page of the post listing.
+type PostListParams struct {
+	Filter PostFilter
+	Sort   string
+	Order  string
+	Limit  int
+	Cursor *PostCursor
+}
+
+// PostCursor marks the position of the last (or, when Backward is set, the
+// first) post of a page. It is handed to clients as an opaque token.
+type PostCursor struct {
+	Sort     string `json:"s"`
+	Order    string `json:"o"`
+	Value    string `json:"v"`
+	ID       int    `json:"id"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+type Pagination struct {
+	Limit         int    `json:"limit"`
+	NextCursor    string `json:"next_cursor,omitempty"`
+	PrevCursor    string `json:"prev_cursor,omitempty"`
+	TotalEstimate int64  `json:"total_estimate"`
+}
+
+type PostPage struct {
+	Posts      []*Post    `json:"posts"`
+	Pagination Pagination `json:"pagination"`
+}
+
+// TrashPage is one page of trashed posts, most recently deleted first.
+type TrashPage struct {
+	Posts []*Post `json:"posts"`
+	Total int     `json:"total"`
+	Page  int     `json:"page"`
+	Limit int     `json:"limit"`
+}
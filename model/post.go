@@ -2,25 +2,151 @@ package model
 
 import "time"
 
+// Post statuses
+const (
+	PostStatusDraft     = "draft"
+	PostStatusPublished = "published"
+)
+
+// Post is a blog post. FeaturedMediaID picks a public image from the media
+// library as its featured image, which needs alt text; FeaturedImage
+// describes it on reads. The Meta* fields, CanonicalURL and NoIndex override
+// what search engines and link previews are told about the post.
 type Post struct {
-	ID          int       `json:"post_id"`
-	UserID      int       `json:"user_id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Body        *string   `json:"body"`
-	CategoryID  int       `json:"category_id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID               int            `json:"post_id"`
+	UserID           int            `json:"user_id"`
+	Title            string         `json:"title"`
+	Description      string         `json:"description"`
+	Body             *string        `json:"body"`
+	ContentFormat    string         `json:"content_format"`
+	BodyHTML         *string        `json:"body_html"`
+	TOC              []TOCEntry     `json:"toc"`
+	Excerpt          string         `json:"excerpt"`
+	WordCount        int            `json:"word_count"`
+	ReadingTime      int            `json:"reading_time_minutes"`
+	FirstImage       *string        `json:"first_image"`
+	FeaturedMediaID  *int           `json:"featured_media_id"`
+	FeaturedImageAlt string         `json:"featured_image_alt"`
+	FeaturedImage    *FeaturedImage `json:"featured_image,omitempty"`
+	MetaTitle        string         `json:"meta_title"`
+	MetaDescription  string         `json:"meta_description"`
+	CanonicalURL     string         `json:"canonical_url"`
+	NoIndex          bool           `json:"noindex"`
+	CategoryID       int            `json:"category_id"`
+	Status           string         `json:"status"`
+	Tags             []string       `json:"tags"`
+	ViewCount        int            `json:"view_count"`
+	CommentCount     int            `json:"comment_count"`
+	Reactions        map[string]int `json:"reactions"`
+	Version          int            `json:"version"`
+	PublishedAt      *time.Time     `json:"published_at"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        *time.Time     `json:"deleted_at,omitempty"`
+	DeletedBy        *int           `json:"deleted_by,omitempty"`
+}
+
+// FeaturedImage is a post's featured image; Path is where it is served on the site.
+type FeaturedImage struct {
+	MediaID int    `json:"media_id"`
+	Path    string `json:"path"`
+	Alt     string `json:"alt"`
+	Width   int    `json:"width,omitempty"`
+	Height  int    `json:"height,omitempty"`
 }
+
 type CreatePostRequest struct {
-	Title       string `json:"title" example:"How to Build a hi"`
-	Description string `json:"description" example:"This post explains how to make microservices."`
-	CategoryID  int    `json:"category_id" example:"2"`
-	Body        string `json:"body" example:"hello"`
+	Title            string   `json:"title" example:"How to Build a hi"`
+	Description      string   `json:"description" example:"This post explains how to make microservices."`
+	CategoryID       int      `json:"category_id" example:"2"`
+	Body             string   `json:"body" example:"hello"`
+	ContentFormat    string   `json:"content_format" example:"markdown"`
+	Status           string   `json:"status" example:"published"`
+	Tags             []string `json:"tags" example:"go,postgres"`
+	FeaturedMediaID  *int     `json:"featured_media_id" example:"12"`
+	FeaturedImageAlt string   `json:"featured_image_alt" example:"Gophers building a service"`
+	MetaTitle        string   `json:"meta_title" example:"Building microservices in Go"`
+	MetaDescription  string   `json:"meta_description" example:"A practical guide to microservices with Go."`
+	CanonicalURL     string   `json:"canonical_url" example:"https://example.com/original-post"`
+	NoIndex          bool     `json:"noindex" example:"false"`
 }
 type UpdatePostRequest struct {
-	Title       string `json:"title" example:"How to Build a"`
-	Description string `json:"description" example:"This post explains how to design and build a RESTful API using Golang."`
-	CategoryID  int    `json:"category_id" example:"2"`
-	Body        string `json:"body" example:"hi"`
+	Title            string   `json:"title" example:"How to Build a"`
+	Description      string   `json:"description" example:"This post explains how to design and build a RESTful API using Golang."`
+	CategoryID       int      `json:"category_id" example:"2"`
+	Body             string   `json:"body" example:"hi"`
+	ContentFormat    string   `json:"content_format" example:"markdown"`
+	Status           string   `json:"status" example:"draft"`
+	Tags             []string `json:"tags" example:"go"`
+	FeaturedMediaID  *int     `json:"featured_media_id" example:"12"`
+	FeaturedImageAlt string   `json:"featured_image_alt" example:"Gophers building a service"`
+	MetaTitle        string   `json:"meta_title" example:"Building RESTful APIs in Go"`
+	MetaDescription  string   `json:"meta_description" example:"How to design a RESTful API with Go."`
+	CanonicalURL     string   `json:"canonical_url" example:""`
+	NoIndex          bool     `json:"noindex" example:"false"`
+}
+
+// PostPatch holds the fields of a post a PATCH may change. Patches are
+// applied to the JSON form of this struct, so members that are not listed
+// here (ids, derived fields, timestamps) cannot be patched.
+type PostPatch struct {
+	Title            string   `json:"title"`
+	Description      string   `json:"description"`
+	CategoryID       int      `json:"category_id"`
+	Body             *string  `json:"body"`
+	ContentFormat    string   `json:"content_format"`
+	Status           string   `json:"status"`
+	Tags             []string `json:"tags"`
+	FeaturedMediaID  *int     `json:"featured_media_id"`
+	FeaturedImageAlt string   `json:"featured_image_alt"`
+	MetaTitle        string   `json:"meta_title"`
+	MetaDescription  string   `json:"meta_description"`
+	CanonicalURL     string   `json:"canonical_url"`
+	NoIndex          bool     `json:"noindex"`
+}
+
+// NewPostPatch returns the patchable fields of p.
+func NewPostPatch(p *Post) PostPatch {
+	tags := p.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return PostPatch{
+		Title:            p.Title,
+		Description:      p.Description,
+		CategoryID:       p.CategoryID,
+		Body:             p.Body,
+		ContentFormat:    p.ContentFormat,
+		Status:           p.Status,
+		Tags:             tags,
+		FeaturedMediaID:  p.FeaturedMediaID,
+		FeaturedImageAlt: p.FeaturedImageAlt,
+		MetaTitle:        p.MetaTitle,
+		MetaDescription:  p.MetaDescription,
+		CanonicalURL:     p.CanonicalURL,
+		NoIndex:          p.NoIndex,
+	}
+}
+
+// TOCEntry is one heading of a rendered post body; ID is its anchor.
+type TOCEntry struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	ID    string `json:"id"`
+}
+
+type PreviewRequest struct {
+	Body          string `json:"body" example:"# Hello\n\nSome *markdown*"`
+	ContentFormat string `json:"content_format" example:"markdown"`
+}
+
+// RenderedBody is the sanitized HTML produced from a post body.
+type RenderedBody struct {
+	HTML string     `json:"html"`
+	TOC  []TOCEntry `json:"toc"`
+}
+
+// IsValidPostStatus reports whether status is one of the known post statuses.
+func IsValidPostStatus(status string) bool {
+	return status == PostStatusDraft || status == PostStatusPublished
 }
@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// ReactionLike is always available, whatever the configured emoji set.
+const ReactionLike = "like"
+
+// ReactionType is one reaction readers can leave on a post.
+type ReactionType struct {
+	Name  string `json:"name"`
+	Emoji string `json:"emoji"`
+}
+
+// ReactionSummary holds a post's reaction counts and, for a signed-in
+// reader, the reactions they left.
+type ReactionSummary struct {
+	PostID int            `json:"post_id"`
+	Counts map[string]int `json:"counts"`
+	Mine   []string       `json:"mine,omitempty"`
+}
+
+// Reactor is one user who left a given reaction.
+type Reactor struct {
+	UserID    int       `json:"user_id"`
+	Name      string    `json:"name"`
+	ReactedAt time.Time `json:"reacted_at"`
+}
+
+type ReactorPage struct {
+	Reactors []*Reactor `json:"reactors"`
+	Total    int        `json:"total"`
+	Page     int        `json:"page"`
+	Limit    int        `json:"limit"`
+}
+
+// UserReaction is a post the user reacted to, with their reactions.
+type UserReaction struct {
+	PostID    int       `json:"post_id"`
+	Title     string    `json:"title"`
+	Reactions []string  `json:"reactions"`
+	ReactedAt time.Time `json:"reacted_at"`
+}
+
+type UserReactionPage struct {
+	Posts []*UserReaction `json:"posts"`
+	Total int             `json:"total"`
+	Page  int             `json:"page"`
+	Limit int             `json:"limit"`
+}
@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// Bookmark is a post a reader saved for later.
+type Bookmark struct {
+	Post         *Post     `json:"post"`
+	BookmarkedAt time.Time `json:"bookmarked_at"`
+}
+
+type BookmarkPage struct {
+	Bookmarks []*Bookmark `json:"bookmarks"`
+	Total     int         `json:"total"`
+	Page      int         `json:"page"`
+	Limit     int         `json:"limit"`
+}
+
+// ReadingList is a named, ordered list of posts. A list is private unless it
+// has a share token, in which case anyone with the link can read it.
+type ReadingList struct {
+	ID          int       `json:"list_id"`
+	UserID      int       `json:"user_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Shared      bool      `json:"shared"`
+	ShareToken  *string   `json:"share_token,omitempty"`
+	PostCount   int       `json:"post_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Posts       []*Post   `json:"posts,omitempty"`
+}
+
+// ReadingListRequest creates a list or, as a PATCH, changes the fields sent.
+type ReadingListRequest struct {
+	Name        *string `json:"name" example:"Weekend reads"`
+	Description *string `json:"description" example:"Long posts to read later"`
+	Shared      *bool   `json:"shared" example:"false"`
+}
+
+// ReorderRequest lists post ids in their new order. Posts left out keep
+// their relative order after the listed ones.
+type ReorderRequest struct {
+	PostIDs []int `json:"post_ids" example:"3,1,2"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// PostSearchParams holds the query and filters accepted by the search endpoint.
+type PostSearchParams struct {
+	Query      string
+	CategoryID int
+	Tag        string
+	AuthorID   int
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+// PostSearchResult is a post matched by full-text search, with its relevance
+// rank and HTML-escaped snippets where matches are wrapped in <mark>.
+type PostSearchResult struct {
+	*Post
+	Rank           float64 `json:"rank"`
+	TitleHighlight string  `json:"title_highlight"`
+	Snippet        string  `json:"snippet"`
+}
+
+type PostSearchResponse struct {
+	Results []*PostSearchResult `json:"results"`
+	Total   int                 `json:"total"`
+	Page    int                 `json:"page"`
+	Limit   int                 `json:"limit"`
+}
@@ -0,0 +1,55 @@
+package model
+
+// MetaTag is one <meta> tag. Open Graph tags use Property, Twitter Card tags
+// use Name.
+type MetaTag struct {
+	Property string `json:"property,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Content  string `json:"content"`
+}
+
+// PostMetadata is what a page showing a post puts in its <head>: the title,
+// description, canonical link and robots directive, Open Graph and Twitter
+// Card tags, and a schema.org BlogPosting for JSON-LD.
+type PostMetadata struct {
+	Title        string      `json:"title"`
+	Description  string      `json:"description"`
+	CanonicalURL string      `json:"canonical_url"`
+	Robots       string      `json:"robots"`
+	OpenGraph    []MetaTag   `json:"open_graph"`
+	Twitter      []MetaTag   `json:"twitter"`
+	JSONLD       BlogPosting `json:"json_ld"`
+}
+
+// BlogPosting is a schema.org BlogPosting.
+type BlogPosting struct {
+	Context          string       `json:"@context"`
+	Type             string       `json:"@type"`
+	Headline         string       `json:"headline"`
+	Description      string       `json:"description,omitempty"`
+	Image            *SchemaImage `json:"image,omitempty"`
+	DatePublished    string       `json:"datePublished"`
+	DateModified     string       `json:"dateModified"`
+	Author           SchemaThing  `json:"author"`
+	Publisher        SchemaThing  `json:"publisher"`
+	MainEntityOfPage string       `json:"mainEntityOfPage"`
+	Keywords         string       `json:"keywords,omitempty"`
+	ArticleSection   string       `json:"articleSection,omitempty"`
+	WordCount        int          `json:"wordCount,omitempty"`
+}
+
+// SchemaThing is a schema.org Person or Organization.
+type SchemaThing struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+// SchemaImage is a schema.org ImageObject.
+type SchemaImage struct {
+	Type    string `json:"@type"`
+	URL     string `json:"url"`
+	Width   int    `json:"width,omitempty"`
+	Height  int    `json:"height,omitempty"`
+	Caption string `json:"caption,omitempty"`
+}
@@ -0,0 +1,78 @@
+package model
+
+// SitePage is what every template of the public site's theme is rendered
+// with. Only the fields of the page being shown are set: Post on a post's
+// page, Listing on the home page and archives, Search on the search page and
+// Error on error pages.
+type SitePage struct {
+	Site         SiteInfo
+	Title        string
+	Description  string
+	CanonicalURL string
+	Robots       string
+	FeedPath     string
+	// Meta is the head metadata of the post shown
+	Meta    *PostMetadata
+	Post    *SitePost
+	Listing *SiteListing
+	Search  *SiteSearch
+	Error   *SiteError
+}
+
+// SiteInfo describes the site on every page. Categories are the top-level
+// categories, for navigation.
+type SiteInfo struct {
+	Title       string
+	Description string
+	URL         string
+	Year        int
+	Categories  []SiteLink
+}
+
+// SiteLink is a link to a page of the site.
+type SiteLink struct {
+	Name string
+	Path string
+}
+
+// SitePost is a post as the public site shows it, with links to its page,
+// author, category and tags. Search results also have TitleHighlight and
+// Snippet, HTML-escaped with matches wrapped in <mark>.
+type SitePost struct {
+	*Post
+	Path           string
+	Author         SiteLink
+	Category       *SiteLink
+	TagLinks       []SiteLink
+	TitleHighlight string
+	Snippet        string
+}
+
+// SiteListing is one page of posts, newest first: the home page or the
+// archive of a category, tag or author. PrevPath and NextPath link to the
+// neighbouring pages.
+type SiteListing struct {
+	Heading     string
+	Description string
+	Path        string
+	FeedPath    string
+	Posts       []*SitePost
+	PrevPath    string
+	NextPath    string
+}
+
+// SiteSearch is one page of search results.
+type SiteSearch struct {
+	Query    string
+	Posts    []*SitePost
+	Total    int
+	Page     int
+	PrevPath string
+	NextPath string
+}
+
+// SiteError is shown on error pages.
+type SiteError struct {
+	Status  int
+	Message string
+}
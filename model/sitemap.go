@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// Kinds of public page listed in the sitemap
+const (
+	SitemapPost     = "post"
+	SitemapCategory = "category"
+	SitemapTag      = "tag"
+	SitemapAuthor   = "author"
+)
+
+// SitemapEntry is one public page: a published post, or a category, tag or
+// author with published posts. Ref is the post or author id, or the category
+// or tag slug. LastMod is when the page's content last changed.
+type SitemapEntry struct {
+	Kind    string
+	Ref     string
+	LastMod time.Time
+}
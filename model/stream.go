@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// StreamEvent is one public change sent to Server-Sent Events subscribers.
+type StreamEvent struct {
+	ID         int64     `json:"id"`
+	Type       string    `json:"type"`
+	PostID     int       `json:"post_id"`
+	AuthorID   int       `json:"author_id"`
+	CategoryID int       `json:"category_id"`
+	CommentID  *int      `json:"comment_id,omitempty"`
+	Title      string    `json:"title,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+// Stream topic kinds
+const (
+	TopicAll      = "all"
+	TopicCategory = "category"
+	TopicAuthor   = "author"
+	TopicPost     = "post"
+)
+
+// StreamTopic selects the events a subscriber receives. ID is unused for TopicAll.
+type StreamTopic struct {
+	Kind string
+	ID   int
+}
+
+// Matches reports whether e belongs to the topic.
+func (t StreamTopic) Matches(e *StreamEvent) bool {
+	switch t.Kind {
+	case TopicAll:
+		return true
+	case TopicCategory:
+		return e.CategoryID == t.ID
+	case TopicAuthor:
+		return e.AuthorID == t.ID
+	case TopicPost:
+		return e.PostID == t.ID
+	}
+	return false
+}
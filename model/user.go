@@ -5,6 +5,7 @@ import "time"
 type User struct {
 	ID        int       `json:"id"`
 	Name      string    `json:"username"`
+	Handle    string    `json:"handle"` // unique; what @mentions refer to
 	Email     string    `json:"email"`
 	Password  string    `json:"-"` // hide password in JSON
 	Role      string    `json:"role"`
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook is an outgoing subscription to events. Secret is only shown when
+// the webhook is created or its secret rotated.
+type Webhook struct {
+	ID           int        `json:"webhook_id"`
+	URL          string     `json:"url"`
+	Description  string     `json:"description"`
+	Events       []string   `json:"events"`
+	Secret       string     `json:"secret,omitempty"`
+	Active       bool       `json:"active"`
+	FailureCount int        `json:"failure_count"`
+	DisabledAt   *time.Time `json:"disabled_at,omitempty"`
+	CreatedBy    *int       `json:"created_by"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// WebhookRequest creates a webhook or, as a PATCH, changes the fields sent.
+// Setting active to true re-enables a webhook that was disabled for failing.
+type WebhookRequest struct {
+	URL          *string  `json:"url" example:"https://hooks.example.com/blog"`
+	Description  *string  `json:"description" example:"Search indexer"`
+	Events       []string `json:"events" example:"post.published,post.deleted"`
+	Active       *bool    `json:"active" example:"true"`
+	RotateSecret bool     `json:"rotate_secret" example:"false"`
+}
+
+// WebhookPayload is the JSON body POSTed to webhooks. ID identifies the
+// event and is the same for every webhook it is sent to.
+type WebhookPayload struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+// WebhookDelivery is one event queued for, or sent to, a webhook.
+type WebhookDelivery struct {
+	ID             int64           `json:"delivery_id"`
+	WebhookID      int             `json:"webhook_id"`
+	EventType      string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	LastStatusCode *int            `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	ReplayOf       *int64          `json:"replay_of,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
+
+type WebhookDeliveryPage struct {
+	Deliveries []*WebhookDelivery `json:"deliveries"`
+	Total      int                `json:"total"`
+	Page       int                `json:"page"`
+	Limit      int                `json:"limit"`
+}
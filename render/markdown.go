@@ -0,0 +1,41 @@
+package render
+
+import (
+	"bytes"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/extension"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+// markdown is a CommonMark parser with the GitHub Flavored Markdown
+// extensions. Fenced code blocks in a language chroma knows are highlighted
+// with classes rather than inline styles, so that Sanitize can keep them.
+var markdown = goldmark.New(
+	goldmark.WithExtensions(
+		extension.NewTable(extension.WithTableCellAlignMethod(extension.TableCellAlignAttribute)),
+		extension.Strikethrough,
+		extension.TaskList,
+		extension.Linkify,
+		highlighting.NewHighlighting(
+			highlighting.WithGuessLanguage(false),
+			highlighting.WithFormatOptions(chromahtml.WithClasses(true)),
+		),
+	),
+	// raw HTML is kept here and cleaned up by Sanitize
+	goldmark.WithRendererOptions(gmhtml.WithUnsafe()),
+)
+
+// Markdown converts CommonMark with the GitHub Flavored Markdown extensions
+// (tables, strikethrough, task lists and bare-URL autolinks) into HTML.
+// Raw HTML is passed through untouched; run the result through Sanitize
+// before serving it.
+func Markdown(src string) string {
+	var b bytes.Buffer
+	// goldmark only fails when writing the output does, which a
+	// bytes.Buffer never does
+	markdown.Convert([]byte(src), &b)
+	return b.String()
+}
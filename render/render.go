@@ -0,0 +1,58 @@
+// Package render turns post bodies written in markdown, HTML or plain text
+// into sanitized HTML with heading anchors and a table of contents.
+package render
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// Content formats a post body can be written in
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatPlain    = "plain"
+)
+
+// Result is the rendered form of a body.
+type Result struct {
+	HTML string
+	TOC  []Heading
+}
+
+// IsValidFormat reports whether format is one of the supported content formats.
+func IsValidFormat(format string) bool {
+	return format == FormatMarkdown || format == FormatHTML || format == FormatPlain
+}
+
+// Render converts src from the given format into sanitized HTML.
+func Render(format, src string) (Result, error) {
+	var raw string
+	switch format {
+	case FormatMarkdown:
+		raw = Markdown(src)
+	case FormatHTML:
+		raw = src
+	case FormatPlain:
+		raw = Plain(src)
+	default:
+		return Result{}, fmt.Errorf("unsupported content format %q", format)
+	}
+	out, toc := AnchorHeadings(Sanitize(raw))
+	return Result{HTML: out, TOC: toc}, nil
+}
+
+// Plain renders plain text as HTML paragraphs, keeping single line breaks.
+func Plain(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	var b strings.Builder
+	for _, para := range strings.Split(s, "\n\n") {
+		para = strings.TrimSpace(para)
+		if para == "" {
+			continue
+		}
+		b.WriteString("<p>" + strings.ReplaceAll(html.EscapeString(para), "\n", "<br />\n") + "</p>\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,191 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		// examples from the CommonMark spec
+		{"emphasis", "*foo bar*", "<p><em>foo bar</em></p>\n"},
+		{"strong", "**foo bar**", "<p><strong>foo bar</strong></p>\n"},
+		{"nested emphasis", "**foo *bar* baz**", "<p><strong>foo <em>bar</em> baz</strong></p>\n"},
+		{"closer after space", "**bold *it* **", "<p>**bold <em>it</em> **</p>\n"},
+		{"intraword underscore", "foo_bar_", "<p>foo_bar_</p>\n"},
+		{"escaped", `# foo *bar* \*baz\*`, "<h1>foo <em>bar</em> *baz*</h1>\n"},
+		{"setext heading", "Foo *bar*\n=========", "<h1>Foo <em>bar</em></h1>\n"},
+		{"code span", "`` foo ` bar ``", "<p><code>foo ` bar</code></p>\n"},
+		{"indented code", "    a simple\n      indented code block", "<pre><code>a simple\n  indented code block\n</code></pre>\n"},
+		{"fenced code", "```\n<\n >\n```", "<pre><code>&lt;\n &gt;\n</code></pre>\n"},
+		{"inline link", `[link](/uri "title")`, "<p><a href=\"/uri\" title=\"title\">link</a></p>\n"},
+		{"reference link", "[foo]\n\n[foo]: /url \"title\"", "<p><a href=\"/url\" title=\"title\">foo</a></p>\n"},
+		{"image", `![foo](/url "title")`, "<p><img src=\"/url\" alt=\"foo\" title=\"title\"></p>\n"},
+		{"autolink", "<https://foo.bar.baz>", "<p><a href=\"https://foo.bar.baz\">https://foo.bar.baz</a></p>\n"},
+		{"hard break", "foo  \nbar", "<p>foo<br>\nbar</p>\n"},
+		{"blockquote", "> # Foo\n> bar\n> baz", "<blockquote>\n<h1>Foo</h1>\n<p>bar\nbaz</p>\n</blockquote>\n"},
+		{"loose list", "- a\n\n- b", "<ul>\n<li>\n<p>a</p>\n</li>\n<li>\n<p>b</p>\n</li>\n</ul>\n"},
+		{"ordered list start", "3. a\n4. b", "<ol start=\"3\">\n<li>a</li>\n<li>b</li>\n</ol>\n"},
+		{"thematic break", "***\n---\n___", "<hr>\n<hr>\n<hr>\n"},
+
+		// GitHub Flavored Markdown extensions
+		{"strikethrough", "~~Hi~~ Hello, world!", "<p><del>Hi</del> Hello, world!</p>\n"},
+		{"bare url", "Visit www.commonmark.org/help for more.", "<p>Visit <a href=\"http://www.commonmark.org/help\">www.commonmark.org/help</a> for more.</p>\n"},
+		{"table", "| a | b |\n|:--|--:|\n| 1 | 2 |", "<table>\n<thead>\n<tr>\n<th align=\"left\">a</th>\n<th align=\"right\">b</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td align=\"left\">1</td>\n<td align=\"right\">2</td>\n</tr>\n</tbody>\n</table>\n"},
+		{"task list", "- [x] done\n- [ ] todo", "<ul>\n<li><input checked=\"\" disabled=\"\" type=\"checkbox\"> done</li>\n<li><input disabled=\"\" type=\"checkbox\"> todo</li>\n</ul>\n"},
+
+		// bodies that are just a list marker
+		{"bare bullet", "-", "<ul>\n<li></li>\n</ul>\n"},
+		{"bare star", "*", "<ul>\n<li></li>\n</ul>\n"},
+		{"bare ordered", "1.", "<ol>\n<li></li>\n</ol>\n"},
+		{"bare marker after item", "- a\n-", "<ul>\n<li>a</li>\n<li></li>\n</ul>\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Markdown(tt.src); got != tt.want {
+				t.Errorf("Markdown(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownHighlightsCode(t *testing.T) {
+	got, err := Render(FormatMarkdown, "```go\nfunc main() {}\n```")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`<pre class="chroma">`, `<span class="kd">func</span>`, `<span class="nf">main</span>`} {
+		if !strings.Contains(got.HTML, want) {
+			t.Errorf("highlighted code %q does not contain %q", got.HTML, want)
+		}
+	}
+
+	got, err = Render(FormatMarkdown, "```nosuchlanguage\nx < y\n```")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<pre><code class=\"language-nosuchlanguage\">x &lt; y\n</code></pre>\n"; got.HTML != want {
+		t.Errorf("unknown language = %q, want %q", got.HTML, want)
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"script", `<p>a<script>alert(1)</script>b</p>`, "<p>ab</p>"},
+		{"event handler", `<img src="/a.png" onerror="alert(1)">`, `<img src="/a.png"/>`},
+		{"style attribute", `<p style="color:red" onclick="x()">hi</p>`, "<p>hi</p>"},
+		{"javascript link", `<a href="javascript:alert(1)">x</a>`, "x"},
+		{"obfuscated javascript link", `<a href="jav&#x09;ascript:alert(1)">x</a>`, "x"},
+		{"data image", `<img src="data:image/svg+xml;base64,PHN2Zz4=" alt="a">`, `<img alt="a"/>`},
+		{"relative link", `<a href="/posts/1">p</a>`, `<a href="/posts/1">p</a>`},
+		{"external link", `<a href="https://example.com">e</a>`, `<a href="https://example.com" rel="nofollow">e</a>`},
+		{"protocol-relative link", `<a href="//example.com">e</a>`, `<a href="//example.com" rel="nofollow">e</a>`},
+		{"iframe", `<iframe src="https://example.com">inner</iframe>x`, "x"},
+		{"svg", `<svg><script>alert(1)</script><text>t</text></svg>x`, "x"},
+		{"unknown element keeps text", `<blink>hi</blink>`, "hi"},
+		{"unclosed elements", `<div><p>hi`, "<div><p>hi</p></div>"},
+		{"stray end tag", `a</div>b`, "ab"},
+		{"escaped text", `<p>&lt;img src=x onerror=alert(1)&gt;</p>`, "<p>&lt;img src=x onerror=alert(1)&gt;</p>"},
+		{"heading id", `<h2 id="intro">x</h2><h2 id="a b">y</h2>`, `<h2 id="intro">x</h2><h2>y</h2>`},
+		{"code classes", `<code class="language-go">x</code><span class="nf">y</span>`, `<code class="language-go">x</code><span class="nf">y</span>`},
+		{"other classes", `<span class="site-header">x</span><p class="nf">y</p>`, "<span>x</span><p>y</p>"},
+		{"checkbox", `<input type="checkbox" checked disabled><input type="text" value="x">`, `<input type="checkbox" checked="" disabled=""/>`},
+		{"image size", `<img src="/a.png" width="10" height="10%">`, `<img src="/a.png" width="10"/>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sanitize(tt.src); got != tt.want {
+				t.Errorf("Sanitize(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderHeadings(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		src    string
+		want   string
+		toc    []Heading
+	}{
+		{
+			"markup in code span", FormatMarkdown, "# `<img src=x onerror=alert(1)>`",
+			"<h1 id=\"img-srcx-onerroralert1\"><code>&lt;img src=x onerror=alert(1)&gt;</code></h1>\n",
+			[]Heading{{1, "<img src=x onerror=alert(1)>", "img-srcx-onerroralert1"}},
+		},
+		{
+			"escaped markup", FormatMarkdown, "# &lt;img src=x onerror=alert(1)&gt;",
+			"<h1 id=\"img-srcx-onerroralert1\">&lt;img src=x onerror=alert(1)&gt;</h1>\n",
+			[]Heading{{1, "<img src=x onerror=alert(1)>", "img-srcx-onerroralert1"}},
+		},
+		{
+			"escaped markup in html", FormatHTML, "<h2>&lt;img src=x onerror=alert(1)&gt;</h2>",
+			"<h2 id=\"img-srcx-onerroralert1\">&lt;img src=x onerror=alert(1)&gt;</h2>",
+			[]Heading{{2, "<img src=x onerror=alert(1)>", "img-srcx-onerroralert1"}},
+		},
+		{
+			"entities and tags", FormatMarkdown, `# <b>h</b> & "x"`,
+			"<h1 id=\"h--x\"><b>h</b> &amp; &#34;x&#34;</h1>\n",
+			[]Heading{{1, `h & "x"`, "h--x"}},
+		},
+		{
+			"less than", FormatMarkdown, "## a < b",
+			"<h2 id=\"a--b\">a &lt; b</h2>\n",
+			[]Heading{{2, "a < b", "a--b"}},
+		},
+		{
+			"duplicates and existing ids", FormatHTML, `<h2>Intro</h2><h3 id="keep">Intro</h3><h2>Intro</h2><h2>!</h2>`,
+			`<h2 id="intro">Intro</h2><h3 id="keep">Intro</h3><h2 id="intro-1">Intro</h2><h2 id="section">!</h2>`,
+			[]Heading{{2, "Intro", "intro"}, {3, "Intro", "keep"}, {2, "Intro", "intro-1"}, {2, "!", "section"}},
+		},
+		{
+			"other scripts", FormatMarkdown, "## Привет, мир",
+			"<h2 id=\"привет-мир\">Привет, мир</h2>\n",
+			[]Heading{{2, "Привет, мир", "привет-мир"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.format, tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.HTML != tt.want {
+				t.Errorf("HTML = %q, want %q", got.HTML, tt.want)
+			}
+			if len(got.TOC) != len(tt.toc) {
+				t.Fatalf("TOC = %+v, want %+v", got.TOC, tt.toc)
+			}
+			for i := range tt.toc {
+				if got.TOC[i] != tt.toc[i] {
+					t.Errorf("TOC[%d] = %+v, want %+v", i, got.TOC[i], tt.toc[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRenderPlain(t *testing.T) {
+	got, err := Render(FormatPlain, "one <b>\ntwo\n\n\nthree & four")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<p>one &lt;b&gt;<br/>\ntwo</p>\n<p>three &amp; four</p>\n"; got.HTML != want {
+		t.Errorf("HTML = %q, want %q", got.HTML, want)
+	}
+}
+
+func TestRenderUnknownFormat(t *testing.T) {
+	if _, err := Render("rst", "x"); err == nil {
+		t.Error("Render with an unknown format succeeded")
+	}
+}
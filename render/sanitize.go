@@ -0,0 +1,84 @@
+package render
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// policy allow-lists the elements and attributes a rendered body may keep.
+// Other elements are dropped but their text is kept, except for scripts,
+// styles, embeds and the like, which go with everything inside them. Links
+// and images may only point at http(s), mailto and relative URLs, and links
+// to other hosts are marked nofollow.
+var policy = newPolicy()
+
+var (
+	numeric   = regexp.MustCompile(`^[0-9]+$`)
+	anchor    = regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)
+	cellAlign = regexp.MustCompile(`^(?:left|center|right)$`)
+	checkbox  = regexp.MustCompile(`^checkbox$`)
+	flag      = regexp.MustCompile(`^(?:|checked|disabled)$`)
+	codeClass = regexp.MustCompile(`^language-[\w#+.-]+$`)
+	preClass  = regexp.MustCompile(`^chroma$`)
+	// the short token classes of chroma, such as k, nf, s1 and err
+	tokenClass = regexp.MustCompile(`^[a-z][a-z0-9]{0,3}$`)
+)
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowElements("b", "blockquote", "br", "dd", "del", "details", "div", "dl", "dt", "em",
+		"hr", "i", "kbd", "li", "mark", "p", "s", "strong", "sub", "summary", "sup",
+		"table", "tbody", "thead", "tr", "ul")
+	p.AllowAttrs("href", "title").OnElements("a")
+	p.AllowAttrs("title").OnElements("abbr")
+	p.AllowAttrs("id").Matching(anchor).OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+	p.AllowElements("h1", "h2", "h3", "h4", "h5", "h6")
+	p.AllowAttrs("src", "alt", "title").OnElements("img")
+	p.AllowAttrs("width", "height").Matching(numeric).OnElements("img")
+	p.AllowAttrs("start").Matching(numeric).OnElements("ol")
+	p.AllowElements("ol")
+	p.AllowAttrs("align").Matching(cellAlign).OnElements("td", "th")
+	p.AllowElements("td", "th")
+	// task list checkboxes
+	p.AllowAttrs("type").Matching(checkbox).OnElements("input")
+	p.AllowAttrs("checked", "disabled").Matching(flag).OnElements("input")
+	// highlighted code
+	p.AllowAttrs("class").Matching(codeClass).OnElements("code")
+	p.AllowAttrs("class").Matching(preClass).OnElements("pre")
+	p.AllowAttrs("class").Matching(tokenClass).OnElements("span")
+	p.AllowElements("code", "pre", "span")
+
+	p.AllowURLSchemes("http", "https", "mailto")
+	p.AllowRelativeURLs(true)
+	p.RequireParseableURLs(true)
+	p.RequireNoFollowOnFullyQualifiedLinks(true)
+	p.SkipElementsContent("script", "style", "iframe", "object", "embed", "noscript", "template", "svg", "math")
+	return p
+}
+
+// Sanitize parses s as an HTML fragment and re-serialises only allow-listed
+// elements and attributes. Every open element is closed.
+func Sanitize(s string) string {
+	return policy.Sanitize(balance(s))
+}
+
+// balance parses s the way a browser parses the inside of <body> and writes
+// it out again, so that elements left open are closed where a browser would
+// close them.
+func balance(s string) string {
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(s), body)
+	if err != nil {
+		// only reading s can fail, and a strings.Reader does not
+		return ""
+	}
+	var b strings.Builder
+	for _, n := range nodes {
+		html.Render(&b, n)
+	}
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package render
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Summary holds metadata derived from rendered HTML.
+type Summary struct {
+	Text       string
+	WordCount  int
+	FirstImage string
+}
+
+// inlineTags are the elements that can sit inside a word.
+var inlineTags = map[string]bool{
+	"a": true, "abbr": true, "b": true, "code": true, "del": true, "em": true, "i": true, "kbd": true,
+	"mark": true, "s": true, "span": true, "strong": true, "sub": true, "sup": true,
+}
+
+// Summarize extracts the visible text, its word count and the first image
+// source from sanitized HTML.
+func Summarize(s string) Summary {
+	z := html.NewTokenizer(strings.NewReader(s))
+	var b strings.Builder
+	var summary Summary
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			summary.Text = strings.Join(strings.Fields(b.String()), " ")
+			summary.WordCount = len(strings.Fields(summary.Text))
+			return summary
+		case html.TextToken:
+			b.Write(z.Text())
+		case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+			tok := z.Token()
+			if tok.Data == "img" && summary.FirstImage == "" {
+				for _, a := range tok.Attr {
+					if a.Key == "src" {
+						summary.FirstImage = a.Val
+					}
+				}
+			}
+			// keep words in adjacent blocks and cells apart, but not a word
+			// that is only partly marked up
+			if !inlineTags[tok.Data] {
+				b.WriteByte(' ')
+			}
+		}
+	}
+}
+
+// Excerpt returns the first maxWords words of text, marking a cut with an ellipsis.
+func Excerpt(text string, maxWords int) string {
+	words := strings.Fields(text)
+	if len(words) <= maxWords {
+		return strings.Join(words, " ")
+	}
+	return strings.TrimRight(strings.Join(words[:maxWords], " "), ".,;:!?") + "…"
+}
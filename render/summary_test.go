@@ -0,0 +1,55 @@
+package render
+
+import "testing"
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want Summary
+	}{
+		{"empty", "", Summary{}},
+		{
+			"blocks and entities", "<h1>Title</h1><p>One &amp; two</p><ul><li>three</li><li>four</li></ul>",
+			Summary{Text: "Title One & two three four", WordCount: 6},
+		},
+		{
+			"table cells", "<table><tr><td>a</td><td>b</td></tr></table>",
+			Summary{Text: "a b", WordCount: 2},
+		},
+		{
+			"first image", `<p>x</p><img src="/media/1.png" alt="one"><img src="/media/2.png">`,
+			Summary{Text: "x", WordCount: 1, FirstImage: "/media/1.png"},
+		},
+		{
+			"inline markup", "<p>in<em>line</em> <a href=\"/x\">words</a><br>here</p>",
+			Summary{Text: "inline words here", WordCount: 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Summarize(tt.html); got != tt.want {
+				t.Errorf("Summarize(%q) = %+v, want %+v", tt.html, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	tests := []struct {
+		text     string
+		maxWords int
+		want     string
+	}{
+		{"", 3, ""},
+		{"one two three", 3, "one two three"},
+		{"  one\ttwo\n three ", 3, "one two three"},
+		{"one two three four", 3, "one two three…"},
+		{"one two, three four", 2, "one two…"},
+	}
+	for _, tt := range tests {
+		if got := Excerpt(tt.text, tt.maxWords); got != tt.want {
+			t.Errorf("Excerpt(%q, %d) = %q, want %q", tt.text, tt.maxWords, got, tt.want)
+		}
+	}
+}
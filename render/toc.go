@@ -0,0 +1,104 @@
+package render
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// Heading is one entry of a document's table of contents.
+type Heading struct {
+	Level int
+	Text  string
+	ID    string
+}
+
+var headingLevels = map[string]int{"h1": 1, "h2": 2, "h3": 3, "h4": 4, "h5": 5, "h6": 6}
+
+// AnchorHeadings gives every h1-h6 in s a unique id derived from its text
+// (keeping ids that are already present) and returns the headings in
+// document order. s must already be sanitized.
+func AnchorHeadings(s string) (string, []Heading) {
+	z := html.NewTokenizer(strings.NewReader(s))
+	var b strings.Builder
+	var toc []Heading
+	seen := map[string]int{}
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return b.String(), toc
+		}
+		raw := string(z.Raw())
+		if tt != html.StartTagToken {
+			b.WriteString(raw)
+			continue
+		}
+		tok := z.Token()
+		level, ok := headingLevels[tok.Data]
+		if !ok {
+			b.WriteString(raw)
+			continue
+		}
+
+		// buffer the heading's content up to its end tag
+		var inner, text strings.Builder
+		for {
+			tt := z.Next()
+			if tt == html.ErrorToken {
+				break
+			}
+			if tt == html.EndTagToken {
+				if name, _ := z.TagName(); string(name) == tok.Data {
+					break
+				}
+			}
+			// Text unescapes the tokenizer's buffer in place, so the raw
+			// markup has to be copied out first
+			inner.Write(z.Raw())
+			if tt == html.TextToken {
+				text.Write(z.Text())
+			}
+		}
+
+		id := ""
+		for _, a := range tok.Attr {
+			if a.Key == "id" {
+				id = a.Val
+			}
+		}
+		if id == "" {
+			id = anchorID(text.String())
+			if id == "" {
+				id = "section"
+			}
+		}
+		if n := seen[id]; n > 0 {
+			seen[id] = n + 1
+			id += "-" + strconv.Itoa(n)
+		} else {
+			seen[id] = 1
+		}
+
+		headingText := strings.TrimSpace(text.String())
+		toc = append(toc, Heading{Level: level, Text: headingText, ID: id})
+		b.WriteString("<" + tok.Data + ` id="` + html.EscapeString(id) + `">` + inner.String() + "</" + tok.Data + ">")
+	}
+}
+
+// anchorID derives a GitHub-style fragment id from heading text: lowercased
+// letters and digits (in any script) with spaces and dashes turned into dashes.
+func anchorID(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(text)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			b.WriteRune(r)
+		case r == ' ' || r == '-':
+			b.WriteByte('-')
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
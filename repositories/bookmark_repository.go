@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+)
+
+type BookmarkRepository struct {
+	db *sql.DB
+}
+
+func NewBookmarkRepository(db *sql.DB) *BookmarkRepository {
+	return &BookmarkRepository{db: db}
+}
+
+// AddBookmark saves a post for a user; saving it again changes nothing.
+func (r *BookmarkRepository) AddBookmark(userID, postID int) error {
+	_, err := r.db.Exec(`INSERT INTO bookmarks (user_id, post_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, userID, postID)
+	return err
+}
+
+// RemoveBookmark forgets a saved post; removing a missing bookmark changes nothing.
+func (r *BookmarkRepository) RemoveBookmark(userID, postID int) error {
+	_, err := r.db.Exec(`DELETE FROM bookmarks WHERE user_id = $1 AND post_id = $2`, userID, postID)
+	return err
+}
+
+// ListBookmarks returns one page of a user's bookmarked post ids, newest
+// first, with the time each was saved. Posts that are trashed or not
+// published are skipped and not counted in the total.
+func (r *BookmarkRepository) ListBookmarks(userID, limit, offset int) ([]int, map[int]time.Time, int, error) {
+	query := `SELECT b.post_id, b.created_at, COUNT(*) OVER ()
+FROM bookmarks b
+JOIN posts p ON p.id = b.post_id
+WHERE b.user_id = $1 AND p.status = 'published' AND p.deleted_at IS NULL
+ORDER BY b.created_at DESC, b.post_id DESC
+LIMIT $2 OFFSET $3`
+	rows, err := r.db.Query(query, userID, limit, offset)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	savedAt := map[int]time.Time{}
+	total := 0
+	for rows.Next() {
+		var id int
+		var at time.Time
+		if err := rows.Scan(&id, &at, &total); err != nil {
+			return nil, nil, 0, err
+		}
+		ids = append(ids, id)
+		savedAt[id] = at
+	}
+	return ids, savedAt, total, rows.Err()
+}
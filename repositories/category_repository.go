@@ -14,13 +14,43 @@ func NewCategoryRepository(db *sql.DB) *CategoryRepository {
 	return &CategoryRepository{DB: db}
 }
 
+// categorySelect selects every category column plus its count of published,
+// non-trashed posts.
+const categorySelect = `
+	SELECT c.id, c.name, c.slug, c.description, c.parent_id, c.created_at, c.updated_at,
+	       COUNT(p.id) FILTER (WHERE p.status = 'published' AND p.deleted_at IS NULL)
+	FROM categories c
+	LEFT JOIN posts p ON p.category_id = c.id`
+
+func scanCategory(row rowScanner) (*model.Category, error) {
+	var c model.Category
+	err := row.Scan(&c.ID, &c.Name, &c.Slug, &c.Description, &c.ParentID, &c.CreatedAt, &c.UpdatedAt, &c.PostCount)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
 func (r *CategoryRepository) CreateCategory(category *model.Category) error {
-	query := `INSERT INTO categories (name) VALUES ($1) RETURNING id` // $1 to avoid sql injection
-	return r.DB.QueryRow(query, category.Name).Scan(&category.ID)
+	query := `INSERT INTO categories (name, slug, description, parent_id, created_at, updated_at)
+VALUES ($1, $2, $3, $4, NOW(), NOW())
+RETURNING id, created_at, updated_at` // $1 to avoid sql injection
+	return r.DB.QueryRow(query, category.Name, category.Slug, category.Description, category.ParentID).
+		Scan(&category.ID, &category.CreatedAt, &category.UpdatedAt)
+}
+
+func (r *CategoryRepository) GetCategoryByID(id int) (*model.Category, error) {
+	query := categorySelect + ` WHERE c.id = $1 GROUP BY c.id`
+	return scanCategory(r.DB.QueryRow(query, id))
+}
+
+func (r *CategoryRepository) GetCategoryBySlug(slug string) (*model.Category, error) {
+	query := categorySelect + ` WHERE c.slug = $1 GROUP BY c.id`
+	return scanCategory(r.DB.QueryRow(query, slug))
 }
 
 func (r *CategoryRepository) GetAllCategories() ([]*model.Category, error) {
-	query := `SELECT id, name FROM categories`
+	query := categorySelect + ` GROUP BY c.id ORDER BY c.name`
 	rows, err := r.DB.Query(query)
 	if err != nil {
 		return nil, err
@@ -29,11 +59,100 @@ func (r *CategoryRepository) GetAllCategories() ([]*model.Category, error) {
 
 	var categories []*model.Category
 	for rows.Next() {
-		var c model.Category
-		if err := rows.Scan(&c.ID, &c.Name); err != nil {
+		c, err := scanCategory(rows)
+		if err != nil {
 			return nil, err
 		}
-		categories = append(categories, &c)
+		categories = append(categories, c)
 	}
-	return categories, nil
+	return categories, rows.Err()
+}
+
+func (r *CategoryRepository) UpdateCategory(category *model.Category) error {
+	query := `UPDATE categories
+SET name = $1, slug = $2, description = $3, parent_id = $4, updated_at = NOW()
+WHERE id = $5
+RETURNING updated_at`
+	return r.DB.QueryRow(query, category.Name, category.Slug, category.Description, category.ParentID, category.ID).
+		Scan(&category.UpdatedAt)
+}
+
+// DeleteCategory removes a category. Posts in it are moved to reassignTo when
+// given, and child categories are re-parented to the deleted category's parent.
+func (r *CategoryRepository) DeleteCategory(id int, reassignTo *int) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if reassignTo != nil {
+		if _, err := tx.Exec(`UPDATE posts SET category_id = $1 WHERE category_id = $2`, *reassignTo, id); err != nil {
+			return err
+		}
+	}
+
+	query := `UPDATE categories SET parent_id = (SELECT parent_id FROM categories WHERE id = $1), updated_at = NOW() WHERE parent_id = $1`
+	if _, err := tx.Exec(query, id); err != nil {
+		return err
+	}
+
+	res, err := tx.Exec(`DELETE FROM categories WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return tx.Commit()
+}
+
+// CountPosts returns how many posts, of any status and including trashed
+// ones, reference the category.
+func (r *CategoryRepository) CountPosts(id int) (int, error) {
+	var count int
+	err := r.DB.QueryRow(`SELECT COUNT(*) FROM posts WHERE category_id = $1`, id).Scan(&count)
+	return count, err
+}
+
+func (r *CategoryRepository) IsNameTaken(name string, excludeID int) (bool, error) {
+	var existingID int
+	query := `SELECT id FROM categories WHERE LOWER(name) = LOWER($1) AND id != $2`
+	err := r.DB.QueryRow(query, name, excludeID).Scan(&existingID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *CategoryRepository) IsSlugTaken(slug string, excludeID int) (bool, error) {
+	var existingID int
+	query := `SELECT id FROM categories WHERE slug = $1 AND id != $2`
+	err := r.DB.QueryRow(query, slug, excludeID).Scan(&existingID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// IsDescendant reports whether candidateID is id itself or sits anywhere below it.
+func (r *CategoryRepository) IsDescendant(id, candidateID int) (bool, error) {
+	query := `
+	WITH RECURSIVE subtree AS (
+		SELECT id FROM categories WHERE id = $1
+		UNION
+		SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+	)
+	SELECT EXISTS (SELECT 1 FROM subtree WHERE id = $2)`
+	var found bool
+	err := r.DB.QueryRow(query, id, candidateID).Scan(&found)
+	return found, err
 }
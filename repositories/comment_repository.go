@@ -0,0 +1,270 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/wikasdude/blog-backend/model"
+)
+
+type CommentRepository struct {
+	db *sql.DB
+}
+
+func NewCommentRepository(db *sql.DB) *CommentRepository {
+	return &CommentRepository{db: db}
+}
+
+// commentColumns lists every comment column, in scanComment order, plus the
+// author's name; commentSelect selects them.
+const (
+	commentColumns = `c.id, c.post_id, c.user_id, COALESCE(u.name, ''), c.parent_id, c.depth, c.body, c.reply_count,
+	       c.deleted_at IS NOT NULL, c.status, c.spam_score, c.moderation_reasons, c.edited_at, c.created_at, c.updated_at`
+	commentFrom = `
+	FROM comments c
+	LEFT JOIN users u ON u.id = c.user_id`
+	commentSelect = `
+	SELECT ` + commentColumns + commentFrom
+)
+
+// commentSortColumns maps comment sort orders onto the column they page by
+// and whether it runs descending.
+var commentSortColumns = map[string]struct {
+	column string
+	desc   bool
+}{
+	model.CommentSortOldest: {"c.created_at", false},
+	model.CommentSortNewest: {"c.created_at", true},
+	model.CommentSortTop:    {"c.reply_count", true},
+}
+
+// scanComment scans commentColumns into a new comment; extra receives any
+// columns selected after them.
+func scanComment(row rowScanner, extra ...interface{}) (*model.Comment, error) {
+	var c model.Comment
+	dest := []interface{}{&c.ID, &c.PostID, &c.UserID, &c.AuthorName, &c.ParentID, &c.Depth, &c.Body, &c.ReplyCount,
+		&c.Deleted, &c.Status, &c.SpamScore, pq.Array(&c.Reasons), &c.EditedAt, &c.CreatedAt, &c.UpdatedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func scanComments(rows *sql.Rows) ([]*model.Comment, error) {
+	comments := []*model.Comment{}
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+	return comments, rows.Err()
+}
+
+// CreateComment inserts a comment and, when it is approved, bumps its
+// parent's reply count.
+func (r *CommentRepository) CreateComment(comment *model.Comment) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO comments (post_id, user_id, parent_id, depth, body, status, spam_score, moderation_reasons, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW())
+RETURNING id, created_at, updated_at`
+	err = tx.QueryRow(query, comment.PostID, comment.UserID, comment.ParentID, comment.Depth, comment.Body,
+		comment.Status, comment.SpamScore, pq.Array(comment.Reasons)).
+		Scan(&comment.ID, &comment.CreatedAt, &comment.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if comment.ParentID != nil && comment.Status == model.CommentStatusApproved {
+		if _, err := tx.Exec(`UPDATE comments SET reply_count = reply_count + 1 WHERE id = $1`, *comment.ParentID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetCommentByID returns a comment, including deleted placeholders.
+func (r *CommentRepository) GetCommentByID(id int) (*model.Comment, error) {
+	return scanComment(r.db.QueryRow(commentSelect+` WHERE c.id = $1`, id))
+}
+
+// UpdateCommentBody replaces the body of a live comment and marks it edited.
+func (r *CommentRepository) UpdateCommentBody(comment *model.Comment) error {
+	query := `UPDATE comments SET body = $1, edited_at = NOW(), updated_at = NOW()
+WHERE id = $2 AND deleted_at IS NULL
+RETURNING edited_at, updated_at`
+	return r.db.QueryRow(query, comment.Body, comment.ID).Scan(&comment.EditedAt, &comment.UpdatedAt)
+}
+
+// DeleteComment clears a comment's body, marks it deleted and takes it off
+// its parent's reply count. The row stays so replies keep their thread.
+func (r *CommentRepository) DeleteComment(id int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var parentID *int
+	var status string
+	query := `UPDATE comments SET body = '', deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING parent_id, status`
+	if err := tx.QueryRow(query, id).Scan(&parentID, &status); err != nil {
+		return err
+	}
+	if parentID != nil && status == model.CommentStatusApproved {
+		if _, err := tx.Exec(`UPDATE comments SET reply_count = reply_count - 1 WHERE id = $1`, *parentID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// visibleComment shows approved comments only, and hides deleted ones
+// unless replies still hang off them.
+const visibleComment = `c.status = 'approved' AND (c.deleted_at IS NULL OR c.reply_count > 0)`
+
+// ListComments returns up to params.Limit+1 top-level comments of a post
+// after the cursor, in the requested sort order with id as tie-breaker.
+func (r *CommentRepository) ListComments(params model.CommentListParams) ([]*model.Comment, error) {
+	sort, ok := commentSortColumns[params.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unsupported comment sort %q", params.Sort)
+	}
+
+	var q queryArgs
+	conditions := []string{"c.post_id = " + q.add(params.PostID), "c.parent_id IS NULL", visibleComment}
+	op, direction := ">", "ASC"
+	if sort.desc {
+		op, direction = "<", "DESC"
+	}
+	if params.Cursor != nil {
+		conditions = append(conditions, "("+sort.column+", c.id) "+op+" ("+q.add(params.Cursor.Value)+", "+q.add(params.Cursor.ID)+")")
+	}
+
+	query := commentSelect + `
+WHERE ` + strings.Join(conditions, " AND ") + `
+ORDER BY ` + sort.column + ` ` + direction + `, c.id ` + direction + `
+LIMIT ` + q.add(params.Limit+1)
+
+	rows, err := r.db.Query(query, q.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanComments(rows)
+}
+
+// CountTopLevelComments counts the visible top-level comments of a post,
+// which are what ListComments pages through.
+func (r *CommentRepository) CountTopLevelComments(postID int) (int, error) {
+	var total int
+	query := `SELECT COUNT(*) FROM comments c WHERE c.post_id = $1 AND c.parent_id IS NULL AND ` + visibleComment
+	err := r.db.QueryRow(query, postID).Scan(&total)
+	return total, err
+}
+
+// ListReplies returns every visible descendant of the given comments,
+// oldest first.
+func (r *CommentRepository) ListReplies(rootIDs []int) ([]*model.Comment, error) {
+	if len(rootIDs) == 0 {
+		return []*model.Comment{}, nil
+	}
+	query := `
+	WITH RECURSIVE thread AS (
+		SELECT id FROM comments WHERE parent_id = ANY($1)
+		UNION ALL
+		SELECT c.id FROM comments c JOIN thread t ON c.parent_id = t.id
+	)` + commentSelect + `
+	WHERE c.id IN (SELECT id FROM thread) AND ` + visibleComment + `
+	ORDER BY c.created_at, c.id`
+
+	rows, err := r.db.Query(query, pq.Array(rootIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanComments(rows)
+}
+
+// SetCommentStatus records a moderation decision and keeps the parent's
+// reply count in step when the comment enters or leaves the approved state.
+// It returns the previous status.
+func (r *CommentRepository) SetCommentStatus(id int, status string, moderatorID *int) (string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var previous string
+	var parentID *int
+	err = tx.QueryRow(`SELECT status, parent_id FROM comments WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id).
+		Scan(&previous, &parentID)
+	if err != nil {
+		return "", err
+	}
+	query := `UPDATE comments SET status = $1, moderated_by = $2, moderated_at = NOW(), updated_at = NOW() WHERE id = $3`
+	if _, err := tx.Exec(query, status, moderatorID, id); err != nil {
+		return "", err
+	}
+
+	if parentID != nil && (previous == model.CommentStatusApproved) != (status == model.CommentStatusApproved) {
+		delta := 1
+		if previous == model.CommentStatusApproved {
+			delta = -1
+		}
+		if _, err := tx.Exec(`UPDATE comments SET reply_count = reply_count + $1 WHERE id = $2`, delta, *parentID); err != nil {
+			return "", err
+		}
+	}
+	return previous, tx.Commit()
+}
+
+// HasApprovedComment reports whether the user has ever had a comment approved.
+func (r *CommentRepository) HasApprovedComment(userID int) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM comments WHERE user_id = $1 AND status = 'approved')`, userID).Scan(&exists)
+	return exists, err
+}
+
+// ListByStatus returns one page of live comments with the given moderation
+// status, oldest first, optionally only those on one author's posts, and the
+// total number of matches.
+func (r *CommentRepository) ListByStatus(status string, postAuthorID, limit, offset int) ([]*model.Comment, int, error) {
+	var q queryArgs
+	conditions := []string{"c.status = " + q.add(status), "c.deleted_at IS NULL"}
+	if postAuthorID != 0 {
+		conditions = append(conditions, "c.post_id IN (SELECT id FROM posts WHERE user_id = "+q.add(postAuthorID)+")")
+	}
+	query := `
+	SELECT ` + commentColumns + `, COUNT(*) OVER ()` + commentFrom + `
+	WHERE ` + strings.Join(conditions, " AND ") + `
+	ORDER BY c.created_at, c.id
+	LIMIT ` + q.add(limit) + ` OFFSET ` + q.add(offset)
+
+	rows, err := r.db.Query(query, q.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	comments := []*model.Comment{}
+	total := 0
+	for rows.Next() {
+		c, err := scanComment(rows, &total)
+		if err != nil {
+			return nil, 0, err
+		}
+		comments = append(comments, c)
+	}
+	return comments, total, rows.Err()
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/wikasdude/blog-backend/model"
+)
+
+type ExportRepository struct {
+	db *sql.DB
+}
+
+func NewExportRepository(db *sql.DB) *ExportRepository {
+	return &ExportRepository{db: db}
+}
+
+const exportColumns = `id, user_id, filter, status, storage_key, size_bytes, counts, error, created_at, started_at, finished_at`
+
+func scanExportJob(row rowScanner, extra ...interface{}) (*model.ExportJob, error) {
+	var job model.ExportJob
+	var filter, counts []byte
+	dest := []interface{}{&job.ID, &job.UserID, &filter, &job.Status, &job.Key, &job.Size, &counts, &job.Error,
+		&job.CreatedAt, &job.StartedAt, &job.FinishedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(filter, &job.Filter); err != nil {
+		return nil, err
+	}
+	if counts != nil {
+		if err := json.Unmarshal(counts, &job.Counts); err != nil {
+			return nil, err
+		}
+	}
+	return &job, nil
+}
+
+// CreateJob queues an export.
+func (r *ExportRepository) CreateJob(job *model.ExportJob) error {
+	filter, err := json.Marshal(job.Filter)
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO export_jobs (user_id, filter) VALUES ($1, $2) RETURNING id, status, created_at`
+	return r.db.QueryRow(query, job.UserID, filter).Scan(&job.ID, &job.Status, &job.CreatedAt)
+}
+
+func (r *ExportRepository) GetJob(id int) (*model.ExportJob, error) {
+	return scanExportJob(r.db.QueryRow(`SELECT `+exportColumns+` FROM export_jobs WHERE id = $1`, id))
+}
+
+// ListJobs returns one page of export jobs, newest first.
+func (r *ExportRepository) ListJobs(limit, offset int) ([]*model.ExportJob, int, error) {
+	query := `SELECT ` + exportColumns + `, COUNT(*) OVER ()
+FROM export_jobs
+ORDER BY id DESC
+LIMIT $1 OFFSET $2`
+	rows, err := r.db.Query(query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	jobs := []*model.ExportJob{}
+	total := 0
+	for rows.Next() {
+		job, err := scanExportJob(rows, &total)
+		if err != nil {
+			return nil, 0, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, total, rows.Err()
+}
+
+// ClaimJob picks the oldest queued job, or a running one whose worker's
+// lease ran out, and leases it for lease. It returns sql.ErrNoRows when
+// there is nothing to run.
+func (r *ExportRepository) ClaimJob(lease time.Duration) (*model.ExportJob, error) {
+	query := `UPDATE export_jobs SET status = 'running', started_at = NOW(), lease_until = NOW() + $1::float8 * INTERVAL '1 second'
+WHERE id = (
+    SELECT id FROM export_jobs
+    WHERE status = 'queued' OR (status = 'running' AND lease_until < NOW())
+    ORDER BY id
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED)
+RETURNING ` + exportColumns
+	return scanExportJob(r.db.QueryRow(query, lease.Seconds()))
+}
+
+// FinishJob records how a job ended and, when it succeeded, where its ZIP
+// was stored.
+func (r *ExportRepository) FinishJob(id int, status, key string, size int64, counts *model.ExportCounts, errText string) error {
+	var countsJSON []byte
+	if counts != nil {
+		var err error
+		if countsJSON, err = json.Marshal(counts); err != nil {
+			return err
+		}
+	}
+	query := `UPDATE export_jobs
+SET status = $1, storage_key = $2, size_bytes = $3, counts = $4, error = $5, lease_until = NULL, finished_at = NOW()
+WHERE id = $6`
+	return expectOneRow(r.db.Exec(query, status, key, size, countsJSON, errText, id))
+}
+
+func (r *ExportRepository) DeleteJob(id int) error {
+	return expectOneRow(r.db.Exec(`DELETE FROM export_jobs WHERE id = $1`, id))
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/wikasdude/blog-backend/model"
+)
+
+type FollowRepository struct {
+	db *sql.DB
+}
+
+func NewFollowRepository(db *sql.DB) *FollowRepository {
+	return &FollowRepository{db: db}
+}
+
+// followTables maps each follow kind onto its table and target column. Only
+// these constant names ever reach the SQL.
+var followTables = map[string]struct{ table, column string }{
+	model.FollowAuthor:   {"author_follows", "author_id"},
+	model.FollowCategory: {"category_follows", "category_id"},
+	model.FollowTag:      {"tag_follows", "tag_id"},
+}
+
+func followTable(kind string) (string, string, error) {
+	t, ok := followTables[kind]
+	if !ok {
+		return "", "", fmt.Errorf("unsupported follow kind %q", kind)
+	}
+	return t.table, t.column, nil
+}
+
+// Follow records that a user follows a target and reports whether it is
+// new; following it again changes nothing.
+func (r *FollowRepository) Follow(userID int, kind string, targetID int) (bool, error) {
+	table, column, err := followTable(kind)
+	if err != nil {
+		return false, err
+	}
+	res, err := r.db.Exec(`INSERT INTO `+table+` (user_id, `+column+`) VALUES ($1, $2) ON CONFLICT DO NOTHING`, userID, targetID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// Unfollow removes a follow; removing one that is not there changes nothing.
+func (r *FollowRepository) Unfollow(userID int, kind string, targetID int) error {
+	table, column, err := followTable(kind)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`DELETE FROM `+table+` WHERE user_id = $1 AND `+column+` = $2`, userID, targetID)
+	return err
+}
+
+func (r *FollowRepository) IsFollowing(userID int, kind string, targetID int) (bool, error) {
+	table, column, err := followTable(kind)
+	if err != nil {
+		return false, err
+	}
+	var following bool
+	err = r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM `+table+` WHERE user_id = $1 AND `+column+` = $2)`, userID, targetID).Scan(&following)
+	return following, err
+}
+
+// ListFollows returns everything a user follows, most recently followed first.
+func (r *FollowRepository) ListFollows(userID int) ([]*model.Follow, error) {
+	query := `SELECT 'author', u.id, u.name, '', f.created_at
+FROM author_follows f JOIN users u ON u.id = f.author_id WHERE f.user_id = $1
+UNION ALL
+SELECT 'category', c.id, c.name, c.slug, f.created_at
+FROM category_follows f JOIN categories c ON c.id = f.category_id WHERE f.user_id = $1
+UNION ALL
+SELECT 'tag', t.id, t.name, t.slug, f.created_at
+FROM tag_follows f JOIN tags t ON t.id = f.tag_id WHERE f.user_id = $1
+ORDER BY 5 DESC`
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	follows := []*model.Follow{}
+	for rows.Next() {
+		var f model.Follow
+		if err := rows.Scan(&f.Kind, &f.TargetID, &f.Name, &f.Slug, &f.FollowedAt); err != nil {
+			return nil, err
+		}
+		follows = append(follows, &f)
+	}
+	return follows, rows.Err()
+}
+
+// GetAuthorProfile returns a user's public profile with their published post
+// count and follower and following counts.
+func (r *FollowRepository) GetAuthorProfile(id int) (*model.AuthorProfile, error) {
+	query := `SELECT u.id, u.name, u.created_at,
+       (SELECT COUNT(*) FROM posts p WHERE p.user_id = u.id AND p.status = 'published' AND p.deleted_at IS NULL),
+       (SELECT COUNT(*) FROM author_follows f WHERE f.author_id = u.id),
+       (SELECT COUNT(*) FROM author_follows f WHERE f.user_id = u.id)
+FROM users u WHERE u.id = $1`
+	var p model.AuthorProfile
+	err := r.db.QueryRow(query, id).Scan(&p.ID, &p.Name, &p.JoinedAt, &p.PostCount, &p.Followers, &p.Following)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
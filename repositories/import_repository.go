@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/wikasdude/blog-backend/model"
+)
+
+type ImportRepository struct {
+	db *sql.DB
+}
+
+func NewImportRepository(db *sql.DB) *ImportRepository {
+	return &ImportRepository{db: db}
+}
+
+const importColumns = `id, user_id, format, filename, source, dry_run, status, report, error, created_at, started_at, finished_at`
+
+func scanImportJob(row rowScanner, extra ...interface{}) (*model.ImportJob, error) {
+	var job model.ImportJob
+	var report []byte
+	dest := []interface{}{&job.ID, &job.UserID, &job.Format, &job.Filename, &job.Source, &job.DryRun, &job.Status, &report, &job.Error,
+		&job.CreatedAt, &job.StartedAt, &job.FinishedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	if report != nil {
+		if err := json.Unmarshal(report, &job.Report); err != nil {
+			return nil, err
+		}
+	}
+	return &job, nil
+}
+
+// CreateJob queues an import of payload.
+func (r *ImportRepository) CreateJob(job *model.ImportJob, payload []byte) error {
+	query := `INSERT INTO import_jobs (user_id, format, filename, source, dry_run, payload)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, status, created_at`
+	return r.db.QueryRow(query, job.UserID, job.Format, job.Filename, job.Source, job.DryRun, payload).
+		Scan(&job.ID, &job.Status, &job.CreatedAt)
+}
+
+func (r *ImportRepository) GetJob(id int) (*model.ImportJob, error) {
+	return scanImportJob(r.db.QueryRow(`SELECT `+importColumns+` FROM import_jobs WHERE id = $1`, id))
+}
+
+// ListJobs returns one page of import jobs, newest first.
+func (r *ImportRepository) ListJobs(limit, offset int) ([]*model.ImportJob, int, error) {
+	query := `SELECT ` + importColumns + `, COUNT(*) OVER ()
+FROM import_jobs
+ORDER BY id DESC
+LIMIT $1 OFFSET $2`
+	rows, err := r.db.Query(query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	jobs := []*model.ImportJob{}
+	total := 0
+	for rows.Next() {
+		job, err := scanImportJob(rows, &total)
+		if err != nil {
+			return nil, 0, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, total, rows.Err()
+}
+
+// ClaimJob picks the oldest queued job, or a running one whose worker's
+// lease ran out, leases it for lease and returns it with its upload. It
+// returns sql.ErrNoRows when there is nothing to run.
+func (r *ImportRepository) ClaimJob(lease time.Duration) (*model.ImportJob, []byte, error) {
+	query := `UPDATE import_jobs SET status = 'running', started_at = NOW(), lease_until = NOW() + $1::float8 * INTERVAL '1 second'
+WHERE id = (
+    SELECT id FROM import_jobs
+    WHERE status = 'queued' OR (status = 'running' AND lease_until < NOW())
+    ORDER BY id
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED)
+RETURNING ` + importColumns + `, payload`
+	var payload []byte
+	job, err := scanImportJob(r.db.QueryRow(query, lease.Seconds()), &payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	return job, payload, nil
+}
+
+// FinishJob records how a job ended and drops its upload.
+func (r *ImportRepository) FinishJob(id int, status string, report *model.ImportReport, errText string) error {
+	var reportJSON []byte
+	if report != nil {
+		var err error
+		if reportJSON, err = json.Marshal(report); err != nil {
+			return err
+		}
+	}
+	query := `UPDATE import_jobs
+SET status = $1, report = $2, error = $3, payload = NULL, lease_until = NULL, finished_at = NOW()
+WHERE id = $4`
+	return expectOneRow(r.db.Exec(query, status, reportJSON, errText, id))
+}
+
+// ImportedPost returns the post an earlier import made of a source post and
+// the checksum of what was imported, or sql.ErrNoRows.
+func (r *ImportRepository) ImportedPost(source, sourceID string) (postID int, checksum string, err error) {
+	query := `SELECT post_id, checksum FROM import_items WHERE source = $1 AND source_id = $2`
+	err = r.db.QueryRow(query, source, sourceID).Scan(&postID, &checksum)
+	return postID, checksum, err
+}
+
+// RecordImportedPost remembers which post a source post became, and the
+// slug and link it had on its old site.
+func (r *ImportRepository) RecordImportedPost(source string, entry model.ImportedPost, checksum string) error {
+	query := `INSERT INTO import_items (source, source_id, post_id, checksum, slug, link) VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (source, source_id) DO UPDATE
+SET post_id = EXCLUDED.post_id, checksum = EXCLUDED.checksum, slug = EXCLUDED.slug, link = EXCLUDED.link, imported_at = NOW()`
+	_, err := r.db.Exec(query, source, entry.SourceID, entry.PostID, checksum, entry.Slug, entry.Link)
+	return err
+}
+
+// ImportedLinks returns, by post id, where the given posts lived on the
+// site they were imported from. A post imported from several sources gets
+// the slug and link of its latest import; posts that were not imported are
+// left out.
+func (r *ImportRepository) ImportedLinks(postIDs []int) (map[int]model.ImportedPost, error) {
+	query := `SELECT DISTINCT ON (post_id) post_id, source_id, slug, link
+FROM import_items
+WHERE post_id = ANY($1)
+ORDER BY post_id, imported_at DESC`
+	rows, err := r.db.Query(query, pq.Array(postIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := map[int]model.ImportedPost{}
+	for rows.Next() {
+		var item model.ImportedPost
+		if err := rows.Scan(&item.PostID, &item.SourceID, &item.Slug, &item.Link); err != nil {
+			return nil, err
+		}
+		links[item.PostID] = item
+	}
+	return links, rows.Err()
+}
@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/wikasdude/blog-backend/model"
+)
+
+type MediaRepository struct {
+	db *sql.DB
+}
+
+func NewMediaRepository(db *sql.DB) *MediaRepository {
+	return &MediaRepository{db: db}
+}
+
+const mediaColumns = `m.id, m.user_id, m.storage_key, m.filename, m.content_type, m.size_bytes, m.private,
+    COALESCE(m.width, 0), COALESCE(m.height, 0), m.blurhash, m.status, m.processing_error, m.created_at`
+
+func mediaDest(m *model.Media) []interface{} {
+	return []interface{}{&m.ID, &m.UserID, &m.Key, &m.Filename, &m.ContentType, &m.Size, &m.Private,
+		&m.Width, &m.Height, &m.BlurHash, &m.Status, &m.ProcessingError, &m.CreatedAt}
+}
+
+func scanMedia(row rowScanner) (*model.Media, error) {
+	var m model.Media
+	if err := row.Scan(mediaDest(&m)...); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *MediaRepository) CreateMedia(m *model.Media) error {
+	query := `INSERT INTO media (user_id, storage_key, filename, content_type, size_bytes, private, width, height, status)
+VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, 0), NULLIF($8, 0), $9)
+RETURNING id, created_at`
+	return r.db.QueryRow(query, m.UserID, m.Key, m.Filename, m.ContentType, m.Size, m.Private, m.Width, m.Height, m.Status).
+		Scan(&m.ID, &m.CreatedAt)
+}
+
+func (r *MediaRepository) GetMediaByID(id int) (*model.Media, error) {
+	return scanMedia(r.db.QueryRow(`SELECT `+mediaColumns+` FROM media m WHERE m.id = $1`, id))
+}
+
+func (r *MediaRepository) GetMediaByKey(key string) (*model.Media, error) {
+	return scanMedia(r.db.QueryRow(`SELECT `+mediaColumns+` FROM media m WHERE m.storage_key = $1`, key))
+}
+
+// GetVariantByKey returns the variant stored under key and the media it belongs to.
+func (r *MediaRepository) GetVariantByKey(key string) (*model.Media, *model.MediaVariant, error) {
+	query := `SELECT ` + mediaColumns + `, v.storage_key, v.format, v.content_type, v.width, v.height, v.size_bytes
+FROM media_variants v
+JOIN media m ON m.id = v.media_id
+WHERE v.storage_key = $1`
+	var m model.Media
+	var v model.MediaVariant
+	dest := append(mediaDest(&m), &v.Key, &v.Format, &v.ContentType, &v.Width, &v.Height, &v.Size)
+	if err := r.db.QueryRow(query, key).Scan(dest...); err != nil {
+		return nil, nil, err
+	}
+	return &m, &v, nil
+}
+
+// ListUserMedia returns one page of a user's uploads, newest first.
+func (r *MediaRepository) ListUserMedia(userID, limit, offset int) ([]*model.Media, int, error) {
+	query := `SELECT ` + mediaColumns + `, COUNT(*) OVER ()
+FROM media m
+WHERE m.user_id = $1
+ORDER BY m.created_at DESC, m.id DESC
+LIMIT $2 OFFSET $3`
+	rows, err := r.db.Query(query, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	media := []*model.Media{}
+	total := 0
+	for rows.Next() {
+		var m model.Media
+		if err := rows.Scan(append(mediaDest(&m), &total)...); err != nil {
+			return nil, 0, err
+		}
+		media = append(media, &m)
+	}
+	return media, total, rows.Err()
+}
+
+// Variants returns the variants of the given media by media id, smallest first.
+func (r *MediaRepository) Variants(mediaIDs []int) (map[int][]model.MediaVariant, error) {
+	query := `SELECT media_id, storage_key, format, content_type, width, height, size_bytes
+FROM media_variants
+WHERE media_id = ANY($1)
+ORDER BY media_id, format, width`
+	rows, err := r.db.Query(query, pq.Array(mediaIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	variants := map[int][]model.MediaVariant{}
+	for rows.Next() {
+		var id int
+		var v model.MediaVariant
+		if err := rows.Scan(&id, &v.Key, &v.Format, &v.ContentType, &v.Width, &v.Height, &v.Size); err != nil {
+			return nil, err
+		}
+		variants[id] = append(variants[id], v)
+	}
+	return variants, rows.Err()
+}
+
+// ClaimPendingMedia picks the oldest image waiting to be processed, or one
+// whose worker's lease ran out, and leases it for lease. It returns
+// sql.ErrNoRows when the queue is empty.
+func (r *MediaRepository) ClaimPendingMedia(lease time.Duration) (*model.Media, error) {
+	query := `UPDATE media m SET status = 'processing', lease_until = NOW() + $1::float8 * INTERVAL '1 second'
+WHERE m.id = (
+    SELECT id FROM media
+    WHERE status = 'pending' OR (status = 'processing' AND lease_until < NOW())
+    ORDER BY id
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED)
+RETURNING ` + mediaColumns
+	return scanMedia(r.db.QueryRow(query, lease.Seconds()))
+}
+
+// SaveProcessed stores the variants and placeholder of a processed image and
+// marks it ready. It returns sql.ErrNoRows if the media was deleted meanwhile.
+func (r *MediaRepository) SaveProcessed(id int, blurHash string, variants []model.MediaVariant) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = expectOneRow(tx.Exec(`UPDATE media SET status = 'ready', blurhash = $1, processing_error = '', lease_until = NULL
+WHERE id = $2`, blurHash, id))
+	if err != nil {
+		return err
+	}
+	for _, v := range variants {
+		_, err := tx.Exec(`INSERT INTO media_variants (media_id, storage_key, format, content_type, width, height, size_bytes)
+VALUES ($1, $2, $3, $4, $5, $6, $7)`, id, v.Key, v.Format, v.ContentType, v.Width, v.Height, v.Size)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *MediaRepository) MarkMediaFailed(id int, errText string) error {
+	return expectOneRow(r.db.Exec(`UPDATE media SET status = 'failed', processing_error = $1, lease_until = NULL WHERE id = $2`, errText, id))
+}
+
+// ListMediaAfterID walks all media in id order.
+func (r *MediaRepository) ListMediaAfterID(afterID, limit int) ([]*model.Media, error) {
+	return r.queryMedia(`SELECT `+mediaColumns+` FROM media m WHERE m.id > $1 ORDER BY m.id LIMIT $2`, afterID, limit)
+}
+
+// FindMedia returns the media with the given ids or whose storage keys,
+// without their extensions, are among stems, in id order.
+func (r *MediaRepository) FindMedia(ids []int64, stems []string) ([]*model.Media, error) {
+	query := `SELECT ` + mediaColumns + ` FROM media m
+WHERE m.id = ANY($1) OR regexp_replace(m.storage_key, '\.[^./]*$', '') = ANY($2)
+ORDER BY m.id`
+	return r.queryMedia(query, pq.Array(ids), pq.Array(stems))
+}
+
+func (r *MediaRepository) queryMedia(query string, args ...interface{}) ([]*model.Media, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	media := []*model.Media{}
+	for rows.Next() {
+		m, err := scanMedia(rows)
+		if err != nil {
+			return nil, err
+		}
+		media = append(media, m)
+	}
+	return media, rows.Err()
+}
+
+// PostsReferencing returns the ids of the posts, trashed ones included, that
+// feature the media or whose body mentions ref.
+func (r *MediaRepository) PostsReferencing(mediaID int, ref string) ([]int, error) {
+	query := `SELECT id FROM posts
+WHERE featured_media_id = $1 OR STRPOS(body, $2) > 0 OR STRPOS(body_html, $2) > 0
+ORDER BY id`
+	rows, err := r.db.Query(query, mediaID, ref)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *MediaRepository) DeleteMedia(id int) error {
+	return expectOneRow(r.db.Exec(`DELETE FROM media WHERE id = $1`, id))
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/lib/pq"
+	"github.com/wikasdude/blog-backend/model"
+)
+
+type NotificationRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationRepository(db *sql.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// notificationEnabled is true unless the recipient, bound as $1, turned the
+// type, bound as $2, off.
+const notificationEnabled = `NOT EXISTS (SELECT 1 FROM notification_preferences np WHERE np.user_id = $1 AND np.type = $2 AND NOT np.enabled)`
+
+// Notify adds actorID to the recipient's unread notification for groupKey,
+// creating it if there is none. The actor moves to the front if they are
+// already listed. Nothing is stored when the recipient turned the type off.
+func (r *NotificationRepository) Notify(userID int, kind, groupKey string, actorID int, postID, commentID *int) error {
+	query := `INSERT INTO notifications (user_id, type, group_key, post_id, comment_id, actor_ids)
+SELECT $1, $2, $3, $4, $5, ARRAY[$6::int]
+WHERE ` + notificationEnabled + `
+ON CONFLICT (user_id, group_key) WHERE read_at IS NULL DO UPDATE SET
+    actor_ids = array_prepend($6::int, array_remove(notifications.actor_ids, $6::int)),
+    comment_id = EXCLUDED.comment_id,
+    updated_at = NOW()`
+	_, err := r.db.Exec(query, userID, kind, groupKey, postID, commentID, actorID)
+	return err
+}
+
+// NotifyFollowers notifies everyone following authorID who has the type
+// enabled, once per groupKey.
+func (r *NotificationRepository) NotifyFollowers(authorID int, kind, groupKey string, postID *int) error {
+	query := `INSERT INTO notifications (user_id, type, group_key, post_id, actor_ids)
+SELECT af.user_id, $2, $3, $4, ARRAY[$1::int]
+FROM author_follows af
+WHERE af.author_id = $1
+  AND NOT EXISTS (SELECT 1 FROM notification_preferences np WHERE np.user_id = af.user_id AND np.type = $2 AND NOT np.enabled)
+ON CONFLICT (user_id, group_key) WHERE read_at IS NULL DO NOTHING`
+	_, err := r.db.Exec(query, authorID, kind, groupKey, postID)
+	return err
+}
+
+// UserIDsByHandles returns the ids of the users with the given handles,
+// which are unique and stored lower-cased.
+func (r *NotificationRepository) UserIDsByHandles(handles []string) ([]int, error) {
+	rows, err := r.db.Query(`SELECT id FROM users WHERE handle = ANY($1)`, pq.Array(handles))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// visibleNotification hides notifications about posts that are in the trash.
+const visibleNotification = `(n.post_id IS NULL OR p.deleted_at IS NULL)`
+
+// ListNotifications returns one page of a user's notifications, most recently
+// updated first, with up to actorLimit of the latest actors on each.
+func (r *NotificationRepository) ListNotifications(userID int, unreadOnly bool, actorLimit, limit, offset int) ([]*model.Notification, int, error) {
+	var q queryArgs
+	where := `n.user_id = ` + q.add(userID) + ` AND ` + visibleNotification
+	if unreadOnly {
+		where += ` AND n.read_at IS NULL`
+	}
+	query := `SELECT n.id, n.type, n.post_id, COALESCE(p.title, ''), n.comment_id, n.read_at IS NOT NULL, n.created_at, n.updated_at,
+       CARDINALITY(n.actor_ids),
+       COALESCE((SELECT JSON_AGG(JSON_BUILD_OBJECT('user_id', u.id, 'username', u.name) ORDER BY a.ord)
+                 FROM UNNEST(n.actor_ids[1:` + q.add(actorLimit) + `]) WITH ORDINALITY a(id, ord)
+                 JOIN users u ON u.id = a.id), '[]'),
+       COUNT(*) OVER ()
+FROM notifications n
+LEFT JOIN posts p ON p.id = n.post_id
+WHERE ` + where + `
+ORDER BY n.updated_at DESC, n.id DESC
+LIMIT ` + q.add(limit) + ` OFFSET ` + q.add(offset)
+	rows, err := r.db.Query(query, q.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	notifications := []*model.Notification{}
+	total := 0
+	for rows.Next() {
+		var n model.Notification
+		var actors []byte
+		err := rows.Scan(&n.ID, &n.Type, &n.PostID, &n.PostTitle, &n.CommentID, &n.Read, &n.CreatedAt, &n.UpdatedAt,
+			&n.ActorCount, &actors, &total)
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := json.Unmarshal(actors, &n.Actors); err != nil {
+			return nil, 0, err
+		}
+		notifications = append(notifications, &n)
+	}
+	return notifications, total, rows.Err()
+}
+
+func (r *NotificationRepository) CountUnread(userID int) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM notifications n LEFT JOIN posts p ON p.id = n.post_id
+WHERE n.user_id = $1 AND n.read_at IS NULL AND `+visibleNotification, userID).Scan(&count)
+	return count, err
+}
+
+// MarkRead marks one of a user's notifications read; marking it again changes
+// nothing. It returns sql.ErrNoRows when the user has no such notification.
+func (r *NotificationRepository) MarkRead(userID, id int) error {
+	return expectOneRow(r.db.Exec(`UPDATE notifications SET read_at = COALESCE(read_at, NOW()) WHERE id = $1 AND user_id = $2`, id, userID))
+}
+
+// MarkAllRead marks every unread notification of a user read and returns how many there were.
+func (r *NotificationRepository) MarkAllRead(userID int) (int64, error) {
+	res, err := r.db.Exec(`UPDATE notifications SET read_at = NOW() WHERE user_id = $1 AND read_at IS NULL`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// GetPreferences returns the types a user has set explicitly.
+func (r *NotificationRepository) GetPreferences(userID int) (model.NotificationPreferences, error) {
+	rows, err := r.db.Query(`SELECT type, enabled FROM notification_preferences WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prefs := model.NotificationPreferences{}
+	for rows.Next() {
+		var kind string
+		var enabled bool
+		if err := rows.Scan(&kind, &enabled); err != nil {
+			return nil, err
+		}
+		prefs[kind] = enabled
+	}
+	return prefs, rows.Err()
+}
+
+// SetPreferences stores the given types; types not in prefs are left as they are.
+func (r *NotificationRepository) SetPreferences(userID int, prefs model.NotificationPreferences) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO notification_preferences (user_id, type, enabled) VALUES ($1, $2, $3)
+ON CONFLICT (user_id, type) DO UPDATE SET enabled = EXCLUDED.enabled`
+	for kind, enabled := range prefs {
+		if _, err := tx.Exec(query, userID, kind, enabled); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -2,9 +2,15 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"html"
+	"strings"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/wikasdude/blog-backend/model"
+	"github.com/wikasdude/blog-backend/utils"
 )
 
 type PostRepository struct {
@@ -18,103 +24,716 @@ func NewPostRepository(db *sql.DB) *PostRepository {
 	}
 }
 
-// Create a new post
-func (r *PostRepository) CreatePost(post *model.Post) error {
-	query := `INSERT INTO posts (user_id, title, description, category_id, body, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
-RETURNING id, created_at, updated_at`
+// postColumns is the column list every post query selects, in scanPost order.
+const postColumns = `id, user_id, title, description, category_id, body, content_format, body_html, toc, excerpt, word_count, reading_time_minutes, first_image,
+	featured_media_id, featured_image_alt, meta_title, meta_description, canonical_url, noindex, status, view_count, version, published_at, created_at, updated_at, deleted_at, deleted_by,
+	(SELECT COUNT(*) FROM comments cm WHERE cm.post_id = posts.id AND cm.status = 'approved' AND cm.deleted_at IS NULL) AS comment_count`
 
-	return r.db.QueryRow(query, post.UserID, post.Title, post.Description, post.CategoryID, post.Body).
-		Scan(&post.ID, &post.CreatedAt, &post.UpdatedAt)
+type rowScanner interface {
+	Scan(dest ...interface{}) error
 }
 
-// Get a post by ID
-func (r *PostRepository) GetPostByID(id int) (*model.Post, error) {
-	query := `SELECT id, user_id, title, description, category_id, body, created_at, updated_at FROM posts WHERE id = $1`
-
+// scanPost scans postColumns into a new post; extra receives any columns
+// selected after them.
+func scanPost(row rowScanner, extra ...interface{}) (*model.Post, error) {
 	post := &model.Post{}
-	err := r.db.QueryRow(query, id).Scan(
+	var toc []byte
+	dest := []interface{}{
 		&post.ID,
 		&post.UserID,
 		&post.Title,
 		&post.Description,
 		&post.CategoryID,
 		&post.Body,
+		&post.ContentFormat,
+		&post.BodyHTML,
+		&toc,
+		&post.Excerpt,
+		&post.WordCount,
+		&post.ReadingTime,
+		&post.FirstImage,
+		&post.FeaturedMediaID,
+		&post.FeaturedImageAlt,
+		&post.MetaTitle,
+		&post.MetaDescription,
+		&post.CanonicalURL,
+		&post.NoIndex,
+		&post.Status,
+		&post.ViewCount,
+		&post.Version,
+		&post.PublishedAt,
 		&post.CreatedAt,
 		&post.UpdatedAt,
-	)
+		&post.DeletedAt,
+		&post.DeletedBy,
+		&post.CommentCount,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(toc, &post.TOC); err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+func scanPosts(rows *sql.Rows) ([]*model.Post, error) {
+	var posts []*model.Post
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+	return posts, rows.Err()
+}
+
+// Create a new post
+func (r *PostRepository) CreatePost(post *model.Post) error {
+	toc, err := json.Marshal(post.TOC)
 	if err != nil {
+		return err
+	}
+	query := `INSERT INTO posts (user_id, title, description, category_id, body, content_format, body_html, toc, excerpt, word_count, reading_time_minutes, first_image, status,
+    featured_media_id, featured_image_alt, meta_title, meta_description, canonical_url, noindex, published_at, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, CASE WHEN $13 = 'published' THEN NOW() END, NOW(), NOW())
+RETURNING id, version, published_at, created_at, updated_at`
+
+	return r.db.QueryRow(query, post.UserID, post.Title, post.Description, post.CategoryID, post.Body, post.ContentFormat, post.BodyHTML, toc,
+		post.Excerpt, post.WordCount, post.ReadingTime, post.FirstImage, post.Status,
+		post.FeaturedMediaID, post.FeaturedImageAlt, post.MetaTitle, post.MetaDescription, post.CanonicalURL, post.NoIndex).
+		Scan(&post.ID, &post.Version, &post.PublishedAt, &post.CreatedAt, &post.UpdatedAt)
+}
+
+// ImportPost stores a post brought in from another blog with the dates it
+// had there. A post with an ID is overwritten, apart from the fields that
+// only exist here, such as its featured image and SEO settings; it returns
+// sql.ErrNoRows if that post is gone or in the trash.
+func (r *PostRepository) ImportPost(post *model.Post) error {
+	toc, err := json.Marshal(post.TOC)
+	if err != nil {
+		return err
+	}
+	if post.ID == 0 {
+		query := `INSERT INTO posts (user_id, title, description, category_id, body, content_format, body_html, toc, excerpt, word_count, reading_time_minutes, first_image, status,
+    published_at, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+RETURNING id, version`
+		return r.db.QueryRow(query, post.UserID, post.Title, post.Description, post.CategoryID, post.Body, post.ContentFormat, post.BodyHTML, toc,
+			post.Excerpt, post.WordCount, post.ReadingTime, post.FirstImage, post.Status, post.PublishedAt, post.CreatedAt, post.UpdatedAt).
+			Scan(&post.ID, &post.Version)
+	}
+	query := `UPDATE posts
+SET user_id = $1, title = $2, description = $3, category_id = $4, body = $5, content_format = $6, body_html = $7, toc = $8,
+    excerpt = $9, word_count = $10, reading_time_minutes = $11, first_image = $12, status = $13,
+    published_at = $14, created_at = $15, updated_at = $16, version = version + 1
+WHERE id = $17 AND deleted_at IS NULL
+RETURNING version`
+	return r.db.QueryRow(query, post.UserID, post.Title, post.Description, post.CategoryID, post.Body, post.ContentFormat, post.BodyHTML, toc,
+		post.Excerpt, post.WordCount, post.ReadingTime, post.FirstImage, post.Status, post.PublishedAt, post.CreatedAt, post.UpdatedAt, post.ID).
+		Scan(&post.Version)
+}
+
+// Get a post by ID; trashed posts are not found
+func (r *PostRepository) GetPostByID(id int) (*model.Post, error) {
+	return r.getPost(`id = $1 AND deleted_at IS NULL`, id)
+}
+
+// GetTrashedPost returns a post only while it is in the trash
+func (r *PostRepository) GetTrashedPost(id int) (*model.Post, error) {
+	return r.getPost(`id = $1 AND deleted_at IS NOT NULL`, id)
+}
+
+func (r *PostRepository) getPost(where string, args ...interface{}) (*model.Post, error) {
+	query := `SELECT ` + postColumns + ` FROM posts WHERE ` + where
+	post, err := scanPost(r.db.QueryRow(query, args...))
+	if err != nil {
+		return nil, err
+	}
+	if err := r.attachRelations([]*model.Post{post}); err != nil {
 		return nil, err
 	}
 	return post, nil
 }
 
-// Update a post
-func (r *PostRepository) UpdatePost(post *model.Post) error {
-	query := `UPDATE posts SET title = $1, description = $2, category_id = $3, body = $4, updated_at = NOW() WHERE id = $5`
-	_, err := r.db.Exec(query, post.Title, post.Description, post.CategoryID, post.Body, post.ID)
-	return err
+// GetPublishedPostsByIDs returns the published, non-trashed posts among ids
+// in the order the ids were given; missing or hidden posts are skipped.
+func (r *PostRepository) GetPublishedPostsByIDs(ids []int) ([]*model.Post, error) {
+	if len(ids) == 0 {
+		return []*model.Post{}, nil
+	}
+	query := `SELECT ` + postColumns + ` FROM posts
+WHERE id = ANY($1) AND status = 'published' AND deleted_at IS NULL
+ORDER BY array_position($1, id)`
+	rows, err := r.db.Query(query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts, err := scanPosts(rows)
+	if err != nil {
+		return nil, err
+	}
+	return posts, r.attachRelations(posts)
 }
 
-// Delete a post
-func (r *PostRepository) DeletePost(id int) error {
-	query := `DELETE FROM posts WHERE id = $1`
-	_, err := r.db.Exec(query, id)
+// Update a post; published_at is stamped the first time a post becomes published.
+// A non-zero expectedVersion makes the update conditional on the stored
+// version, returning sql.ErrNoRows when it no longer matches.
+func (r *PostRepository) UpdatePost(post *model.Post, expectedVersion int) error {
+	toc, err := json.Marshal(post.TOC)
+	if err != nil {
+		return err
+	}
+	query := `UPDATE posts
+SET title = $1, description = $2, category_id = $3, body = $4, content_format = $5, body_html = $6, toc = $7,
+    excerpt = $8, word_count = $9, reading_time_minutes = $10, first_image = $11, status = $12,
+    featured_media_id = $15, featured_image_alt = $16, meta_title = $17, meta_description = $18, canonical_url = $19, noindex = $20,
+    published_at = CASE WHEN $12 = 'published' THEN COALESCE(published_at, NOW()) END,
+    version = version + 1, updated_at = NOW()
+WHERE id = $13 AND deleted_at IS NULL AND ($14 = 0 OR version = $14)
+RETURNING version, published_at, created_at, updated_at`
+	return r.db.QueryRow(query, post.Title, post.Description, post.CategoryID, post.Body, post.ContentFormat, post.BodyHTML, toc,
+		post.Excerpt, post.WordCount, post.ReadingTime, post.FirstImage, post.Status, post.ID, expectedVersion,
+		post.FeaturedMediaID, post.FeaturedImageAlt, post.MetaTitle, post.MetaDescription, post.CanonicalURL, post.NoIndex).
+		Scan(&post.Version, &post.PublishedAt, &post.CreatedAt, &post.UpdatedAt)
+}
+
+// UpdateDerivedFields stores the rendered body and the metadata computed
+// from it without touching updated_at.
+func (r *PostRepository) UpdateDerivedFields(post *model.Post) error {
+	toc, err := json.Marshal(post.TOC)
+	if err != nil {
+		return err
+	}
+	query := `UPDATE posts
+SET body_html = $1, toc = $2, excerpt = $3, word_count = $4, reading_time_minutes = $5, first_image = $6
+WHERE id = $7`
+	_, err = r.db.Exec(query, post.BodyHTML, toc, post.Excerpt, post.WordCount, post.ReadingTime, post.FirstImage, post.ID)
 	return err
 }
 
-// Get all posts (optional utility method)
-func (r *PostRepository) GetAllPosts() ([]*model.Post, error) {
-	query := `SELECT id, user_id, title, description, category_id, body, created_at, updated_at FROM posts ORDER BY created_at DESC`
+// ListPostsAfterID walks every post, whatever its status, in id order.
+func (r *PostRepository) ListPostsAfterID(afterID, limit int) ([]*model.Post, error) {
+	query := `SELECT ` + postColumns + ` FROM posts WHERE id > $1 ORDER BY id LIMIT $2`
+	rows, err := r.db.Query(query, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	rows, err := r.db.Query(query)
+	return scanPosts(rows)
+}
+
+// ListFilteredPostsAfterID walks the posts that pass filter in id order,
+// with their relations attached. Trashed posts are left out.
+func (r *PostRepository) ListFilteredPostsAfterID(filter model.PostFilter, afterID, limit int) ([]*model.Post, error) {
+	var q queryArgs
+	conditions := append(postFilterConditions(&q, filter), "id > "+q.add(afterID))
+	query := `SELECT ` + postColumns + ` FROM posts WHERE ` + strings.Join(conditions, " AND ") + ` ORDER BY id LIMIT ` + q.add(limit)
+	rows, err := r.db.Query(query, q.args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var posts []*model.Post
+	posts, err := scanPosts(rows)
+	if err != nil {
+		return nil, err
+	}
+	return posts, r.attachRelations(posts)
+}
+
+// SoftDeletePost moves a post to the trash; a non-zero expectedVersion
+// behaves as in UpdatePost
+func (r *PostRepository) SoftDeletePost(id, deletedBy, expectedVersion int) error {
+	query := `UPDATE posts SET deleted_at = NOW(), deleted_by = $2
+WHERE id = $1 AND deleted_at IS NULL AND ($3 = 0 OR version = $3)`
+	return expectOneRow(r.db.Exec(query, id, deletedBy, expectedVersion))
+}
+
+// RestorePost takes a post back out of the trash
+func (r *PostRepository) RestorePost(id int) error {
+	query := `UPDATE posts SET deleted_at = NULL, deleted_by = NULL WHERE id = $1 AND deleted_at IS NOT NULL`
+	return expectOneRow(r.db.Exec(query, id))
+}
+
+// PurgePost permanently deletes a trashed post
+func (r *PostRepository) PurgePost(id int) error {
+	return expectOneRow(r.db.Exec(`DELETE FROM posts WHERE id = $1 AND deleted_at IS NOT NULL`, id))
+}
+
+// PurgeTrashedBefore permanently deletes posts trashed before cutoff
+func (r *PostRepository) PurgeTrashedBefore(cutoff time.Time) (int64, error) {
+	res, err := r.db.Exec(`DELETE FROM posts WHERE deleted_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ListTrashedPosts returns one page of trashed posts, optionally only those
+// of one author, together with the total number of matches.
+func (r *PostRepository) ListTrashedPosts(authorID, limit, offset int) ([]*model.Post, int, error) {
+	var q queryArgs
+	conditions := []string{"deleted_at IS NOT NULL"}
+	if authorID != 0 {
+		conditions = append(conditions, "user_id = "+q.add(authorID))
+	}
+	query := `SELECT ` + postColumns + `, COUNT(*) OVER () FROM posts
+WHERE ` + strings.Join(conditions, " AND ") + `
+ORDER BY deleted_at DESC, id DESC
+LIMIT ` + q.add(limit) + ` OFFSET ` + q.add(offset)
+
+	rows, err := r.db.Query(query, q.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	posts := []*model.Post{}
+	total := 0
 	for rows.Next() {
-		post := &model.Post{}
-		err := rows.Scan(
-			&post.ID,
-			&post.UserID,
-			&post.Title,
-			&post.Description,
-			&post.CategoryID,
-			&post.Body,
-			&post.CreatedAt,
-			&post.UpdatedAt,
-		)
+		post, err := scanPost(rows, &total)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 		posts = append(posts, post)
 	}
-	return posts, nil
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return posts, total, r.attachRelations(posts)
 }
-func (r *PostRepository) GetPaginatedPosts(limit, offset int, sort string, order string, search string) ([]*model.Post, error) {
-	query := fmt.Sprintf(`
-	SELECT id, user_id, title, description, category_id, body, created_at, updated_at
-	FROM posts
-	WHERE title ILIKE '%%' || $3 || '%%'
-	   OR description ILIKE '%%' || $3 || '%%'
-	ORDER BY %s %s
-	LIMIT $1 OFFSET $2
-`, sort, order)
-	rows, err := r.db.Query(query, limit, offset, search)
+
+// expectOneRow turns an Exec result that touched no rows into sql.ErrNoRows.
+func expectOneRow(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// postSortColumns maps the public sort names onto the expressions they order
+// by. Only these constant expressions ever reach ORDER BY.
+var postSortColumns = map[string]string{
+	"title":        "title",
+	"created_at":   "created_at",
+	"updated_at":   "updated_at",
+	"published_at": "COALESCE(published_at, '-infinity')",
+	"popularity":   "view_count",
+}
+
+// postFilterConditions turns a listing filter into WHERE conditions whose
+// values are all bound through q.
+func postFilterConditions(q *queryArgs, f model.PostFilter) []string {
+	conditions := []string{"deleted_at IS NULL"}
+	if f.Status != model.PostStatusAll {
+		status := f.Status
+		if status == "" {
+			status = model.PostStatusPublished
+		}
+		conditions = append(conditions, "status = "+q.add(status))
+	}
+	if f.Search != "" {
+		p := q.add(f.Search)
+		conditions = append(conditions, "(title ILIKE '%' || "+p+" || '%' OR description ILIKE '%' || "+p+" || '%')")
+	}
+	if f.CategoryID != 0 {
+		conditions = append(conditions, "category_id = "+q.add(f.CategoryID))
+	}
+	if f.AuthorID != 0 {
+		conditions = append(conditions, "user_id = "+q.add(f.AuthorID))
+	}
+	if f.Tag != "" {
+		conditions = append(conditions, `id IN (SELECT pt.post_id FROM post_tags pt JOIN tags t ON t.id = pt.tag_id WHERE t.slug = `+q.add(utils.Slugify(f.Tag))+`)`)
+	}
+	ranges := []struct {
+		column   string
+		from, to *time.Time
+	}{
+		{"created_at", f.CreatedFrom, f.CreatedTo},
+		{"updated_at", f.UpdatedFrom, f.UpdatedTo},
+		{"published_at", f.PublishedFrom, f.PublishedTo},
+	}
+	for _, rg := range ranges {
+		if rg.from != nil {
+			conditions = append(conditions, rg.column+" >= "+q.add(*rg.from))
+		}
+		if rg.to != nil {
+			conditions = append(conditions, rg.column+" <= "+q.add(*rg.to))
+		}
+	}
+	if f.FollowedBy != 0 {
+		p := q.add(f.FollowedBy)
+		conditions = append(conditions, `(user_id IN (SELECT af.author_id FROM author_follows af WHERE af.user_id = `+p+`)
+	OR category_id IN (SELECT cf.category_id FROM category_follows cf WHERE cf.user_id = `+p+`)
+	OR id IN (SELECT pt.post_id FROM post_tags pt JOIN tag_follows tf ON tf.tag_id = pt.tag_id WHERE tf.user_id = `+p+`))`)
+	}
+	if f.HasBody != nil {
+		if *f.HasBody {
+			conditions = append(conditions, "COALESCE(body, '') <> ''")
+		} else {
+			conditions = append(conditions, "COALESCE(body, '') = ''")
+		}
+	}
+	return conditions
+}
+
+// ListPosts returns up to params.Limit+1 posts after (or, for a backward
+// cursor, before) the cursor position, ordered by the sort column with id as
+// tie-breaker so pages stay stable while new posts are inserted. Backward
+// pages are returned in display order.
+func (r *PostRepository) ListPosts(params model.PostListParams) ([]*model.Post, error) {
+	column, ok := postSortColumns[params.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sort field %q", params.Sort)
+	}
+	desc := params.Order == "desc"
+	backward := params.Cursor != nil && params.Cursor.Backward
+	if backward {
+		desc = !desc
+	}
+
+	var q queryArgs
+	conditions := postFilterConditions(&q, params.Filter)
+	if params.Cursor != nil {
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		conditions = append(conditions, "("+column+", id) "+op+" ("+q.add(params.Cursor.Value)+", "+q.add(params.Cursor.ID)+")")
+	}
+
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+	query := `SELECT ` + postColumns + ` FROM posts
+WHERE ` + strings.Join(conditions, " AND ") + `
+ORDER BY ` + column + ` ` + direction + `, id ` + direction + `
+LIMIT ` + q.add(params.Limit+1)
+
+	rows, err := r.db.Query(query, q.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts, err := scanPosts(rows)
+	if err != nil {
+		return nil, err
+	}
+	if backward {
+		for i, j := 0, len(posts)-1; i < j; i, j = i+1, j-1 {
+			posts[i], posts[j] = posts[j], posts[i]
+		}
+	}
+	return posts, r.attachRelations(posts)
+}
+
+// EstimatePostCount returns the planner's row estimate, less the trashed
+// posts, for a completely unfiltered listing and an exact count otherwise.
+func (r *PostRepository) EstimatePostCount(filter model.PostFilter) (int64, error) {
+	var count int64
+	if filter == (model.PostFilter{Status: model.PostStatusAll}) {
+		err := r.db.QueryRow(`SELECT reltuples::bigint - (SELECT COUNT(*) FROM posts WHERE deleted_at IS NOT NULL)
+FROM pg_class WHERE oid = 'posts'::regclass`).Scan(&count)
+		if err != nil || count >= 0 {
+			return count, err
+		}
+		// never analysed yet; fall through to an exact count
+	}
+	var q queryArgs
+	query := `SELECT COUNT(*) FROM posts WHERE ` + strings.Join(postFilterConditions(&q, filter), " AND ")
+	err := r.db.QueryRow(query, q.args...).Scan(&count)
+	return count, err
+}
+
+// IncrementViewCount records one read of a post.
+func (r *PostRepository) IncrementViewCount(id int) error {
+	_, err := r.db.Exec(`UPDATE posts SET view_count = view_count + 1 WHERE id = $1 AND deleted_at IS NULL`, id)
+	return err
+}
+
+// GetTagIDBySlug returns the id of the tag with the given slug.
+func (r *PostRepository) GetTagIDBySlug(slug string) (int, error) {
+	var id int
+	err := r.db.QueryRow(`SELECT id FROM tags WHERE slug = $1`, slug).Scan(&id)
+	return id, err
+}
+
+// GetTagNameBySlug returns the display name of the tag with the given slug.
+func (r *PostRepository) GetTagNameBySlug(slug string) (string, error) {
+	var name string
+	err := r.db.QueryRow(`SELECT name FROM tags WHERE slug = $1`, slug).Scan(&name)
+	return name, err
+}
+
+// GetAuthorNames maps each of the given user ids that exists to its name.
+func (r *PostRepository) GetAuthorNames(ids []int) (map[int]string, error) {
+	names := map[int]string{}
+	if len(ids) == 0 {
+		return names, nil
+	}
+	rows, err := r.db.Query(`SELECT id, name FROM users WHERE id = ANY($1)`, pq.Array(ids))
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var posts []*model.Post
 	for rows.Next() {
-		post := &model.Post{}
-		if err := rows.Scan(&post.ID, &post.UserID, &post.Title, &post.Description, &post.CategoryID, &post.Body, &post.CreatedAt, &post.UpdatedAt); err != nil {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
 			return nil, err
 		}
+		names[id] = name
+	}
+	return names, rows.Err()
+}
+
+// SetPostTags replaces the tags of a post, creating any tags that don't exist yet.
+func (r *PostRepository) SetPostTags(postID int, tags []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM post_tags WHERE post_id = $1`, postID); err != nil {
+		return err
+	}
+	for _, name := range tags {
+		slug := utils.Slugify(name)
+		if slug == "" {
+			continue
+		}
+		var tagID int
+		query := `INSERT INTO tags (name, slug) VALUES ($1, $2)
+ON CONFLICT (slug) DO UPDATE SET slug = EXCLUDED.slug
+RETURNING id`
+		if err := tx.QueryRow(query, strings.TrimSpace(name), slug).Scan(&tagID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO post_tags (post_id, tag_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, postID, tagID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// attachRelations loads the tags, reaction counts and featured images of all given posts.
+func (r *PostRepository) attachRelations(posts []*model.Post) error {
+	if err := r.attachTags(posts); err != nil {
+		return err
+	}
+	if err := r.attachReactionCounts(posts); err != nil {
+		return err
+	}
+	return r.AttachFeaturedImages(posts)
+}
+
+// AttachFeaturedImages describes the featured images of all given posts in a
+// single query. Path matches the /media/ route that serves uploads.
+func (r *PostRepository) AttachFeaturedImages(posts []*model.Post) error {
+	ids := []int64{}
+	byMedia := map[int][]*model.Post{}
+	for _, p := range posts {
+		p.FeaturedImage = nil
+		if p.FeaturedMediaID != nil {
+			ids = append(ids, int64(*p.FeaturedMediaID))
+			byMedia[*p.FeaturedMediaID] = append(byMedia[*p.FeaturedMediaID], p)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query := `SELECT id, '/media/' || storage_key, COALESCE(width, 0), COALESCE(height, 0) FROM media WHERE id = ANY($1)`
+	rows, err := r.db.Query(query, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var img model.FeaturedImage
+		if err := rows.Scan(&img.MediaID, &img.Path, &img.Width, &img.Height); err != nil {
+			return err
+		}
+		for _, p := range byMedia[img.MediaID] {
+			featured := img
+			featured.Alt = p.FeaturedImageAlt
+			p.FeaturedImage = &featured
+		}
+	}
+	return rows.Err()
+}
+
+// GetFeaturableMedia returns the content type and privacy of the upload with
+// the given id, which a post may feature if it is a public image.
+func (r *PostRepository) GetFeaturableMedia(id int) (contentType string, private bool, err error) {
+	err = r.db.QueryRow(`SELECT content_type, private FROM media WHERE id = $1`, id).Scan(&contentType, &private)
+	return contentType, private, err
+}
+
+// attachReactionCounts loads the reaction counts of all given posts in a single query.
+func (r *PostRepository) attachReactionCounts(posts []*model.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(posts))
+	byID := make(map[int]*model.Post, len(posts))
+	for i, p := range posts {
+		ids[i] = int64(p.ID)
+		byID[p.ID] = p
+		p.Reactions = map[string]int{}
+	}
+
+	query := `SELECT post_id, reaction, count FROM post_reaction_counts WHERE post_id = ANY($1) AND count > 0`
+	rows, err := r.db.Query(query, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID, count int
+		var reaction string
+		if err := rows.Scan(&postID, &reaction, &count); err != nil {
+			return err
+		}
+		if p, ok := byID[postID]; ok {
+			p.Reactions[reaction] = count
+		}
+	}
+	return rows.Err()
+}
+
+// attachTags loads the tag names of all given posts in a single query.
+func (r *PostRepository) attachTags(posts []*model.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(posts))
+	byID := make(map[int]*model.Post, len(posts))
+	for i, p := range posts {
+		ids[i] = int64(p.ID)
+		byID[p.ID] = p
+		p.Tags = []string{}
+	}
+
+	query := `SELECT pt.post_id, t.name FROM post_tags pt JOIN tags t ON t.id = pt.tag_id
+WHERE pt.post_id = ANY($1) ORDER BY t.name`
+	rows, err := r.db.Query(query, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID int
+		var name string
+		if err := rows.Scan(&postID, &name); err != nil {
+			return err
+		}
+		if p, ok := byID[postID]; ok {
+			p.Tags = append(p.Tags, name)
+		}
+	}
+	return rows.Err()
+}
+
+// Highlight delimiters handed to ts_headline. They are control characters so
+// the snippet can be HTML-escaped before they are swapped for <mark> tags.
+const (
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
+)
+
+var highlightReplacer = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+
+func renderHighlight(s string) string {
+	return highlightReplacer.Replace(html.EscapeString(s))
+}
+
+// SearchPosts runs a ranked full-text search over published posts using
+// websearch syntax ("quoted phrases", OR, -excluded) and returns one page of
+// results together with the total number of matches.
+func (r *PostRepository) SearchPosts(params model.PostSearchParams) ([]*model.PostSearchResult, int, error) {
+	var q queryArgs
+	tsQuery := q.add(params.Query)
+	conditions := []string{
+		"status = 'published'",
+		"deleted_at IS NULL",
+		"search_vector @@ websearch_to_tsquery('english', " + tsQuery + ")",
+	}
+	if params.CategoryID != 0 {
+		conditions = append(conditions, "category_id = "+q.add(params.CategoryID))
+	}
+	if params.AuthorID != 0 {
+		conditions = append(conditions, "user_id = "+q.add(params.AuthorID))
+	}
+	if params.Tag != "" {
+		conditions = append(conditions, `id IN (SELECT pt.post_id FROM post_tags pt JOIN tags t ON t.id = pt.tag_id WHERE t.slug = `+q.add(utils.Slugify(params.Tag))+`)`)
+	}
+	if params.From != nil {
+		conditions = append(conditions, "published_at >= "+q.add(*params.From))
+	}
+	if params.To != nil {
+		conditions = append(conditions, "published_at <= "+q.add(*params.To))
+	}
+
+	titleOpts := q.add(`HighlightAll=true, StartSel="` + highlightStart + `", StopSel="` + highlightStop + `"`)
+	bodyOpts := q.add(`MaxFragments=2, MaxWords=35, MinWords=15, FragmentDelimiter=" … ", StartSel="` + highlightStart + `", StopSel="` + highlightStop + `"`)
+	limit := q.add(params.Limit)
+	offset := q.add(params.Offset)
+
+	query := `
+	SELECT ` + postColumns + `,
+	       ts_rank_cd(search_vector, websearch_to_tsquery('english', ` + tsQuery + `), 32) AS rank,
+	       ts_headline('english', title, websearch_to_tsquery('english', ` + tsQuery + `), ` + titleOpts + `),
+	       ts_headline('english', COALESCE(NULLIF(body, ''), description), websearch_to_tsquery('english', ` + tsQuery + `), ` + bodyOpts + `),
+	       COUNT(*) OVER ()
+	FROM posts
+	WHERE ` + strings.Join(conditions, " AND ") + `
+	ORDER BY rank DESC, published_at DESC, id DESC
+	LIMIT ` + limit + ` OFFSET ` + offset
+
+	rows, err := r.db.Query(query, q.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	results := []*model.PostSearchResult{}
+	posts := []*model.Post{}
+	total := 0
+	for rows.Next() {
+		result := &model.PostSearchResult{}
+		post, err := scanPost(rows, &result.Rank, &result.TitleHighlight, &result.Snippet, &total)
+		if err != nil {
+			return nil, 0, err
+		}
+		result.Post = post
+		result.TitleHighlight = renderHighlight(result.TitleHighlight)
+		result.Snippet = renderHighlight(result.Snippet)
+		results = append(results, result)
 		posts = append(posts, post)
 	}
-	return posts, nil
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	if err := r.attachRelations(posts); err != nil {
+		return nil, 0, err
+	}
+	return results, total, nil
 }
@@ -0,0 +1,14 @@
+package repository
+
+import "strconv"
+
+// queryArgs collects positional arguments for a dynamically built query.
+type queryArgs struct {
+	args []interface{}
+}
+
+// add appends v and returns its placeholder ($1, $2, ...).
+func (q *queryArgs) add(v interface{}) string {
+	q.args = append(q.args, v)
+	return "$" + strconv.Itoa(len(q.args))
+}
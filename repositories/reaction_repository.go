@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/wikasdude/blog-backend/model"
+)
+
+type ReactionRepository struct {
+	db *sql.DB
+}
+
+func NewReactionRepository(db *sql.DB) *ReactionRepository {
+	return &ReactionRepository{db: db}
+}
+
+// AddReaction records a reaction and bumps its count. Adding a reaction the
+// user already left changes nothing; the insert's conflict check decides, so
+// concurrent requests can never count a reaction twice.
+func (r *ReactionRepository) AddReaction(postID, userID int, reaction string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`INSERT INTO post_reactions (post_id, user_id, reaction) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`,
+		postID, userID, reaction)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	query := `INSERT INTO post_reaction_counts (post_id, reaction, count) VALUES ($1, $2, 1)
+ON CONFLICT (post_id, reaction) DO UPDATE SET count = post_reaction_counts.count + 1`
+	if _, err := tx.Exec(query, postID, reaction); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// RemoveReaction deletes a reaction and lowers its count; removing a
+// reaction that is not there changes nothing.
+func (r *ReactionRepository) RemoveReaction(postID, userID int, reaction string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`DELETE FROM post_reactions WHERE post_id = $1 AND user_id = $2 AND reaction = $3`,
+		postID, userID, reaction)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	query := `UPDATE post_reaction_counts SET count = count - 1 WHERE post_id = $1 AND reaction = $2`
+	if _, err := tx.Exec(query, postID, reaction); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// CountReactions returns the non-zero reaction counts of a post.
+func (r *ReactionRepository) CountReactions(postID int) (map[string]int, error) {
+	rows, err := r.db.Query(`SELECT reaction, count FROM post_reaction_counts WHERE post_id = $1 AND count > 0`, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var reaction string
+		var count int
+		if err := rows.Scan(&reaction, &count); err != nil {
+			return nil, err
+		}
+		counts[reaction] = count
+	}
+	return counts, rows.Err()
+}
+
+// UserReactions returns the reactions a user left on a post.
+func (r *ReactionRepository) UserReactions(postID, userID int) ([]string, error) {
+	var reactions []string
+	err := r.db.QueryRow(`SELECT COALESCE(ARRAY_AGG(reaction ORDER BY created_at), '{}') FROM post_reactions WHERE post_id = $1 AND user_id = $2`,
+		postID, userID).Scan(pq.Array(&reactions))
+	return reactions, err
+}
+
+// ListReactors returns one page of the users who left a reaction on a post,
+// most recent first, and how many there are in total.
+func (r *ReactionRepository) ListReactors(postID int, reaction string, limit, offset int) ([]*model.Reactor, int, error) {
+	query := `SELECT pr.user_id, COALESCE(u.name, ''), pr.created_at, COUNT(*) OVER ()
+FROM post_reactions pr
+LEFT JOIN users u ON u.id = pr.user_id
+WHERE pr.post_id = $1 AND pr.reaction = $2
+ORDER BY pr.created_at DESC, pr.user_id
+LIMIT $3 OFFSET $4`
+	rows, err := r.db.Query(query, postID, reaction, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	reactors := []*model.Reactor{}
+	total := 0
+	for rows.Next() {
+		var reactor model.Reactor
+		if err := rows.Scan(&reactor.UserID, &reactor.Name, &reactor.ReactedAt, &total); err != nil {
+			return nil, 0, err
+		}
+		reactors = append(reactors, &reactor)
+	}
+	return reactors, total, rows.Err()
+}
+
+// ListUserReactions returns one page of the visible posts a user reacted
+// to, most recently reacted first, with the reactions they left.
+func (r *ReactionRepository) ListUserReactions(userID, limit, offset int) ([]*model.UserReaction, int, error) {
+	query := `SELECT p.id, p.title, ARRAY_AGG(pr.reaction ORDER BY pr.created_at), MAX(pr.created_at), COUNT(*) OVER ()
+FROM post_reactions pr
+JOIN posts p ON p.id = pr.post_id
+WHERE pr.user_id = $1 AND p.status = 'published' AND p.deleted_at IS NULL
+GROUP BY p.id, p.title
+ORDER BY MAX(pr.created_at) DESC, p.id DESC
+LIMIT $2 OFFSET $3`
+	rows, err := r.db.Query(query, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	posts := []*model.UserReaction{}
+	total := 0
+	for rows.Next() {
+		var ur model.UserReaction
+		if err := rows.Scan(&ur.PostID, &ur.Title, pq.Array(&ur.Reactions), &ur.ReactedAt, &total); err != nil {
+			return nil, 0, err
+		}
+		posts = append(posts, &ur)
+	}
+	return posts, total, rows.Err()
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/wikasdude/blog-backend/model"
+)
+
+type ReadingListRepository struct {
+	db *sql.DB
+}
+
+func NewReadingListRepository(db *sql.DB) *ReadingListRepository {
+	return &ReadingListRepository{db: db}
+}
+
+// readingListSelect selects every list column plus its count of visible posts.
+const readingListSelect = `
+	SELECT l.id, l.user_id, l.name, l.description, l.share_token, l.created_at, l.updated_at,
+	       (SELECT COUNT(*) FROM reading_list_items i JOIN posts p ON p.id = i.post_id
+	        WHERE i.list_id = l.id AND p.status = 'published' AND p.deleted_at IS NULL)
+	FROM reading_lists l`
+
+func scanReadingList(row rowScanner) (*model.ReadingList, error) {
+	var l model.ReadingList
+	err := row.Scan(&l.ID, &l.UserID, &l.Name, &l.Description, &l.ShareToken, &l.CreatedAt, &l.UpdatedAt, &l.PostCount)
+	if err != nil {
+		return nil, err
+	}
+	l.Shared = l.ShareToken != nil
+	return &l, nil
+}
+
+func (r *ReadingListRepository) CreateList(list *model.ReadingList) error {
+	query := `INSERT INTO reading_lists (user_id, name, description, share_token, created_at, updated_at)
+VALUES ($1, $2, $3, $4, NOW(), NOW())
+RETURNING id, created_at, updated_at`
+	return r.db.QueryRow(query, list.UserID, list.Name, list.Description, list.ShareToken).
+		Scan(&list.ID, &list.CreatedAt, &list.UpdatedAt)
+}
+
+func (r *ReadingListRepository) GetListByID(id int) (*model.ReadingList, error) {
+	return scanReadingList(r.db.QueryRow(readingListSelect+` WHERE l.id = $1`, id))
+}
+
+func (r *ReadingListRepository) GetListByShareToken(token string) (*model.ReadingList, error) {
+	return scanReadingList(r.db.QueryRow(readingListSelect+` WHERE l.share_token = $1`, token))
+}
+
+// ListUserLists returns all of a user's reading lists, most recently changed first.
+func (r *ReadingListRepository) ListUserLists(userID int) ([]*model.ReadingList, error) {
+	rows, err := r.db.Query(readingListSelect+` WHERE l.user_id = $1 ORDER BY l.updated_at DESC, l.id DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := []*model.ReadingList{}
+	for rows.Next() {
+		l, err := scanReadingList(rows)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, l)
+	}
+	return lists, rows.Err()
+}
+
+func (r *ReadingListRepository) UpdateList(list *model.ReadingList) error {
+	query := `UPDATE reading_lists SET name = $1, description = $2, share_token = $3, updated_at = NOW()
+WHERE id = $4
+RETURNING updated_at`
+	return r.db.QueryRow(query, list.Name, list.Description, list.ShareToken, list.ID).Scan(&list.UpdatedAt)
+}
+
+func (r *ReadingListRepository) DeleteList(id int) error {
+	return expectOneRow(r.db.Exec(`DELETE FROM reading_lists WHERE id = $1`, id))
+}
+
+// AddItem appends a post to the end of a list; adding it again changes nothing.
+func (r *ReadingListRepository) AddItem(listID, postID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// lock the list so concurrent appends get distinct positions
+	if _, err := tx.Exec(`SELECT id FROM reading_lists WHERE id = $1 FOR UPDATE`, listID); err != nil {
+		return err
+	}
+	query := `INSERT INTO reading_list_items (list_id, post_id, position)
+SELECT $1, $2, COALESCE(MAX(position), 0) + 1 FROM reading_list_items WHERE list_id = $1
+ON CONFLICT DO NOTHING`
+	if _, err := tx.Exec(query, listID, postID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE reading_lists SET updated_at = NOW() WHERE id = $1`, listID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemoveItem takes a post off a list; removing a missing post changes nothing.
+func (r *ReadingListRepository) RemoveItem(listID, postID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM reading_list_items WHERE list_id = $1 AND post_id = $2`, listID, postID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE reading_lists SET updated_at = NOW() WHERE id = $1`, listID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ItemPostIDs returns the ids of every post on a list in list order,
+// including posts that are currently hidden.
+func (r *ReadingListRepository) ItemPostIDs(listID int) ([]int, error) {
+	rows, err := r.db.Query(`SELECT post_id FROM reading_list_items WHERE list_id = $1 ORDER BY position, added_at`, listID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// SetOrder renumbers a list's items to follow postIDs, which must hold
+// every post on the list exactly once.
+func (r *ReadingListRepository) SetOrder(listID int, postIDs []int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT id FROM reading_lists WHERE id = $1 FOR UPDATE`, listID); err != nil {
+		return err
+	}
+	for i, postID := range postIDs {
+		_, err := tx.Exec(`UPDATE reading_list_items SET position = $1 WHERE list_id = $2 AND post_id = $3`, i+1, listID, postID)
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`UPDATE reading_lists SET updated_at = NOW() WHERE id = $1`, listID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
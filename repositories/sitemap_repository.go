@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/wikasdude/blog-backend/model"
+)
+
+type SitemapRepository struct {
+	db *sql.DB
+}
+
+func NewSitemapRepository(db *sql.DB) *SitemapRepository {
+	return &SitemapRepository{db: db}
+}
+
+// publishedPost keeps the posts, aliased p, that readers can see.
+const publishedPost = `p.status = 'published' AND p.deleted_at IS NULL`
+
+// Entries lists every public page: published posts in id order, then the
+// categories, tags and authors that have published posts. Posts marked
+// noindex or canonical to another URL are left out. The order is stable, so
+// splitting the list into sitemaps keeps pages where they were. A listing
+// page changes whenever one of its posts does.
+func (r *SitemapRepository) Entries() ([]model.SitemapEntry, error) {
+	query := `SELECT kind, ref, lastmod FROM (
+    SELECT 1 AS rank, p.id AS num, 'post' AS kind, p.id::text AS ref, p.updated_at AS lastmod FROM posts p
+    WHERE ` + publishedPost + ` AND NOT p.noindex AND p.canonical_url = ''
+    UNION ALL
+    SELECT 2, 0, 'category', c.slug, MAX(p.updated_at) FROM categories c JOIN posts p ON p.category_id = c.id
+    WHERE ` + publishedPost + ` GROUP BY c.id
+    UNION ALL
+    SELECT 3, 0, 'tag', t.slug, MAX(p.updated_at) FROM tags t JOIN post_tags pt ON pt.tag_id = t.id JOIN posts p ON p.id = pt.post_id
+    WHERE ` + publishedPost + ` GROUP BY t.id
+    UNION ALL
+    SELECT 4, p.user_id, 'author', p.user_id::text, MAX(p.updated_at) FROM posts p
+    WHERE ` + publishedPost + ` GROUP BY p.user_id
+) e
+ORDER BY rank, num, ref`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []model.SitemapEntry
+	for rows.Next() {
+		var e model.SitemapEntry
+		if err := rows.Scan(&e.Kind, &e.Ref, &e.LastMod); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
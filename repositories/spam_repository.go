@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/wikasdude/blog-backend/spam"
+)
+
+// SpamRepository stores the spam filter's training counts.
+type SpamRepository struct {
+	db *sql.DB
+}
+
+func NewSpamRepository(db *sql.DB) *SpamRepository {
+	return &SpamRepository{db: db}
+}
+
+// LoadCorpus returns every token count and the number of spam and ham
+// documents trained so far.
+func (r *SpamRepository) LoadCorpus() (map[string]spam.TokenCount, int, int, error) {
+	var spamDocs, hamDocs int
+	rows, err := r.db.Query(`SELECT label, documents FROM spam_corpus`)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var label string
+		var docs int
+		if err := rows.Scan(&label, &docs); err != nil {
+			return nil, 0, 0, err
+		}
+		if label == spam.Spam {
+			spamDocs = docs
+		} else {
+			hamDocs = docs
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, 0, err
+	}
+
+	tokenRows, err := r.db.Query(`SELECT token, spam_count, ham_count FROM spam_tokens`)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer tokenRows.Close()
+	tokens := map[string]spam.TokenCount{}
+	for tokenRows.Next() {
+		var token string
+		var c spam.TokenCount
+		if err := tokenRows.Scan(&token, &c.Spam, &c.Ham); err != nil {
+			return nil, 0, 0, err
+		}
+		tokens[token] = c
+	}
+	return tokens, spamDocs, hamDocs, tokenRows.Err()
+}
+
+// Retrain moves the training of a comment from the label it was last trained
+// with to label, spam.Spam, spam.Ham or "" for none: the tokens it was trained
+// on are counted once less under the old label and tokens once more under the
+// new one. It returns the old label and tokens; when they already match label
+// nothing changes.
+func (r *SpamRepository) Retrain(commentID int, tokens []string, label string) (string, []string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return "", nil, err
+	}
+	defer tx.Rollback()
+
+	var previous string
+	var previousTokens []string
+	err = tx.QueryRow(`SELECT COALESCE(trained_as, ''), trained_tokens FROM comments WHERE id = $1 FOR UPDATE`, commentID).
+		Scan(&previous, pq.Array(&previousTokens))
+	if err != nil {
+		return "", nil, err
+	}
+	if previous == label {
+		return previous, previousTokens, nil
+	}
+	if previous != "" {
+		if err := countDocument(tx, previousTokens, previous, -1); err != nil {
+			return "", nil, err
+		}
+	}
+	if label != "" {
+		if err := countDocument(tx, tokens, label, 1); err != nil {
+			return "", nil, err
+		}
+	} else {
+		tokens = nil
+	}
+	_, err = tx.Exec(`UPDATE comments SET trained_as = NULLIF($2, ''), trained_tokens = $3 WHERE id = $1`,
+		commentID, label, pq.Array(append([]string{}, tokens...)))
+	if err != nil {
+		return "", nil, err
+	}
+	return previous, previousTokens, tx.Commit()
+}
+
+// countDocument adds delta spam or ham documents made of the given distinct
+// tokens. Counts never drop below zero.
+func countDocument(tx *sql.Tx, tokens []string, label string, delta int) error {
+	column := "ham_count"
+	if label == spam.Spam {
+		column = "spam_count"
+	}
+	query := `INSERT INTO spam_tokens (token, ` + column + `)
+SELECT t, GREATEST($2, 0) FROM UNNEST($1::text[]) AS t
+ON CONFLICT (token) DO UPDATE SET ` + column + ` = GREATEST(spam_tokens.` + column + ` + $2, 0)`
+	if _, err := tx.Exec(query, pq.Array(tokens), delta); err != nil {
+		return err
+	}
+	query = `INSERT INTO spam_corpus (label, documents) VALUES ($1, GREATEST($2, 0))
+ON CONFLICT (label) DO UPDATE SET documents = GREATEST(spam_corpus.documents + $2, 0)`
+	_, err := tx.Exec(query, label, delta)
+	return err
+}
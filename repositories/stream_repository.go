@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/wikasdude/blog-backend/model"
+)
+
+// StreamChannel is the Postgres NOTIFY channel stream events are broadcast on.
+const StreamChannel = "blog_stream"
+
+type StreamRepository struct {
+	db *sql.DB
+}
+
+func NewStreamRepository(db *sql.DB) *StreamRepository {
+	return &StreamRepository{db: db}
+}
+
+// Append stores an event, filling in its id and time, and broadcasts it as
+// JSON on StreamChannel once the insert commits.
+func (r *StreamRepository) Append(e *model.StreamEvent) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO stream_events (type, post_id, author_id, category_id, comment_id, title)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, created_at`
+	err = tx.QueryRow(query, e.Type, e.PostID, e.AuthorID, e.CategoryID, e.CommentID, e.Title).Scan(&e.ID, &e.At)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`SELECT pg_notify($1, $2)`, StreamChannel, string(payload)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Since returns up to limit events after afterID, oldest first.
+func (r *StreamRepository) Since(afterID int64, limit int) ([]*model.StreamEvent, error) {
+	rows, err := r.db.Query(`SELECT id, type, post_id, author_id, category_id, comment_id, title, created_at
+FROM stream_events WHERE id > $1 ORDER BY id LIMIT $2`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*model.StreamEvent
+	for rows.Next() {
+		var e model.StreamEvent
+		if err := rows.Scan(&e.ID, &e.Type, &e.PostID, &e.AuthorID, &e.CategoryID, &e.CommentID, &e.Title, &e.At); err != nil {
+			return nil, err
+		}
+		list = append(list, &e)
+	}
+	return list, rows.Err()
+}
+
+// LatestID returns the id of the newest stored event, or 0.
+func (r *StreamRepository) LatestID() (int64, error) {
+	var id int64
+	err := r.db.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM stream_events`).Scan(&id)
+	return id, err
+}
+
+// PruneBefore deletes events older than cutoff and returns how many went.
+func (r *StreamRepository) PruneBefore(cutoff time.Time) (int64, error) {
+	res, err := r.db.Exec(`DELETE FROM stream_events WHERE created_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Listen delivers events broadcast by any instance to onEvent until stop is
+// closed. The connection is re-established when it drops; onReconnect is
+// then called so the caller can catch up on what it missed.
+func (r *StreamRepository) Listen(dsn string, onEvent func(*model.StreamEvent), onReconnect func(), stop <-chan struct{}) error {
+	listener := pq.NewListener(dsn, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Println("stream listener:", err)
+		}
+	})
+	defer listener.Close()
+	if err := listener.Listen(StreamChannel); err != nil {
+		return err
+	}
+
+	ping := time.NewTicker(90 * time.Second)
+	defer ping.Stop()
+	for {
+		select {
+		case n := <-listener.Notify:
+			if n == nil {
+				// the connection was re-established; notifications may have been lost
+				onReconnect()
+				continue
+			}
+			var e model.StreamEvent
+			if err := json.Unmarshal([]byte(n.Extra), &e); err != nil {
+				log.Println("stream listener: bad payload:", err)
+				continue
+			}
+			onEvent(&e)
+		case <-ping.C:
+			go listener.Ping()
+		case <-stop:
+			return nil
+		}
+	}
+}
@@ -13,6 +13,7 @@ type UserRepository interface {
 	GetUserByID(id string) (*model.User, error)
 	UpdateUser(user *model.User) error
 	IsEmailTaken(email string, excludeUserID int) (bool, error)
+	IsHandleTaken(handle string, excludeUserID int) (bool, error)
 	DeleteUser(id int) error
 	GetUserByEmail(email string) (*model.User, error)
 }
@@ -32,15 +33,15 @@ func (r *userRepo) CreateUser(user *model.User) error {
 		log.Fatal("❌ Failed to fetch current database name:", err)
 	}
 	fmt.Println("✅ Connected to database:", dbName)
-	query := `INSERT INTO users (name, email, password, role,created_at) VALUES ($1, $2, $3,$4, NOW()) RETURNING id`
-	err = r.db.QueryRow(query, user.Name, user.Email, user.Password, user.Role).Scan(&user.ID)
+	query := `INSERT INTO users (name, handle, email, password, role,created_at) VALUES ($1, $2, $3, $4, $5, NOW()) RETURNING id`
+	err = r.db.QueryRow(query, user.Name, user.Handle, user.Email, user.Password, user.Role).Scan(&user.ID)
 	return err
 }
 
 func (r *userRepo) GetUserByID(id string) (*model.User, error) {
 	var user model.User
-	query := `SELECT id, name, email, password, role, created_at FROM users WHERE id = $1`
-	err := r.db.QueryRow(query, id).Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.Role, &user.CreatedAt)
+	query := `SELECT id, name, handle, email, password, role, created_at FROM users WHERE id = $1`
+	err := r.db.QueryRow(query, id).Scan(&user.ID, &user.Name, &user.Handle, &user.Email, &user.Password, &user.Role, &user.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -49,10 +50,10 @@ func (r *userRepo) GetUserByID(id string) (*model.User, error) {
 func (r *userRepo) UpdateUser(user *model.User) error {
 	query := `
 	UPDATE users
-	SET name = $1, email = $2, password = $3, role = $4, updated_at = CURRENT_TIMESTAMP
-	WHERE id = $5
+	SET name = $1, handle = $2, email = $3, password = $4, role = $5, updated_at = CURRENT_TIMESTAMP
+	WHERE id = $6
 `
-	_, err := r.db.Exec(query, user.Name, user.Email, user.Password, user.Role, user.ID)
+	_, err := r.db.Exec(query, user.Name, user.Handle, user.Email, user.Password, user.Role, user.ID)
 	return err
 }
 func (r *userRepo) IsEmailTaken(email string, excludeUserID int) (bool, error) {
@@ -67,6 +68,18 @@ func (r *userRepo) IsEmailTaken(email string, excludeUserID int) (bool, error) {
 	}
 	return true, nil
 }
+func (r *userRepo) IsHandleTaken(handle string, excludeUserID int) (bool, error) {
+	var existingID int
+	query := `SELECT id FROM users WHERE handle = $1 AND id != $2`
+	err := r.db.QueryRow(query, handle, excludeUserID).Scan(&existingID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
 func (r *userRepo) DeleteUser(id int) error {
 	query := `DELETE FROM users WHERE id = $1`
 	_, err := r.db.Exec(query, id)
@@ -74,8 +87,8 @@ func (r *userRepo) DeleteUser(id int) error {
 }
 func (r *userRepo) GetUserByEmail(email string) (*model.User, error) {
 	var user model.User
-	query := `SELECT id, name, email, password, role, created_at FROM users WHERE email = $1`
-	err := r.db.QueryRow(query, email).Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.Role, &user.CreatedAt)
+	query := `SELECT id, name, handle, email, password, role, created_at FROM users WHERE email = $1`
+	err := r.db.QueryRow(query, email).Scan(&user.ID, &user.Name, &user.Handle, &user.Email, &user.Password, &user.Role, &user.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/wikasdude/blog-backend/model"
+)
+
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+const webhookColumns = `id, url, description, events, active, failure_count, disabled_at, created_by, created_at, updated_at`
+
+func scanWebhook(row rowScanner) (*model.Webhook, error) {
+	var w model.Webhook
+	err := row.Scan(&w.ID, &w.URL, &w.Description, pq.Array(&w.Events), &w.Active, &w.FailureCount, &w.DisabledAt,
+		&w.CreatedBy, &w.CreatedAt, &w.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (r *WebhookRepository) CreateWebhook(w *model.Webhook) error {
+	query := `INSERT INTO webhooks (url, description, events, secret, active, created_by)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, created_at, updated_at`
+	return r.db.QueryRow(query, w.URL, w.Description, pq.Array(w.Events), w.Secret, w.Active, w.CreatedBy).
+		Scan(&w.ID, &w.CreatedAt, &w.UpdatedAt)
+}
+
+// GetWebhook returns a webhook without its secret.
+func (r *WebhookRepository) GetWebhook(id int) (*model.Webhook, error) {
+	return scanWebhook(r.db.QueryRow(`SELECT `+webhookColumns+` FROM webhooks WHERE id = $1`, id))
+}
+
+func (r *WebhookRepository) ListWebhooks() ([]*model.Webhook, error) {
+	rows, err := r.db.Query(`SELECT ` + webhookColumns + ` FROM webhooks ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []*model.Webhook{}
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, w)
+	}
+	return webhooks, rows.Err()
+}
+
+// UpdateWebhook saves a webhook's settings; the secret is only replaced when
+// w.Secret is set. Re-activating a webhook clears its failure count.
+func (r *WebhookRepository) UpdateWebhook(w *model.Webhook) error {
+	query := `UPDATE webhooks SET url = $1, description = $2, events = $3, active = $4,
+    secret = COALESCE(NULLIF($5, ''), secret),
+    failure_count = CASE WHEN $4 AND NOT active THEN 0 ELSE failure_count END,
+    disabled_at = CASE WHEN $4 THEN NULL ELSE disabled_at END,
+    updated_at = NOW()
+WHERE id = $6
+RETURNING failure_count, disabled_at, updated_at`
+	return r.db.QueryRow(query, w.URL, w.Description, pq.Array(w.Events), w.Active, w.Secret, w.ID).
+		Scan(&w.FailureCount, &w.DisabledAt, &w.UpdatedAt)
+}
+
+func (r *WebhookRepository) DeleteWebhook(id int) error {
+	return expectOneRow(r.db.Exec(`DELETE FROM webhooks WHERE id = $1`, id))
+}
+
+// EnqueueEvent queues payload for every active webhook subscribed to
+// eventType and returns how many deliveries were queued.
+func (r *WebhookRepository) EnqueueEvent(eventType string, payload []byte) (int64, error) {
+	res, err := r.db.Exec(`INSERT INTO webhook_deliveries (webhook_id, event_type, payload)
+SELECT id, $1, $2::jsonb FROM webhooks WHERE active AND $1 = ANY(events)`, eventType, payload)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// DueDelivery is a claimed delivery with what is needed to send it.
+type DueDelivery struct {
+	model.WebhookDelivery
+	URL    string
+	Secret string
+}
+
+// ClaimDueDeliveries picks up to limit pending deliveries of active webhooks
+// whose time has come and pushes their next attempt lease into the future,
+// so other instances skip them while they are being sent.
+func (r *WebhookRepository) ClaimDueDeliveries(limit int, lease time.Duration) ([]*DueDelivery, error) {
+	query := `UPDATE webhook_deliveries d SET next_attempt_at = NOW() + $2::float8 * INTERVAL '1 second'
+FROM webhooks w
+WHERE w.id = d.webhook_id AND d.id IN (
+    SELECT d2.id FROM webhook_deliveries d2 JOIN webhooks w2 ON w2.id = d2.webhook_id
+    WHERE d2.status = 'pending' AND d2.next_attempt_at <= NOW() AND w2.active
+    ORDER BY d2.next_attempt_at
+    LIMIT $1
+    FOR UPDATE OF d2 SKIP LOCKED)
+RETURNING d.id, d.webhook_id, d.event_type, d.payload, d.attempts, d.created_at, w.url, w.secret`
+	rows, err := r.db.Query(query, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var due []*DueDelivery
+	for rows.Next() {
+		var d DueDelivery
+		var payload []byte
+		err := rows.Scan(&d.ID, &d.WebhookID, &d.EventType, &payload, &d.Attempts, &d.CreatedAt, &d.URL, &d.Secret)
+		if err != nil {
+			return nil, err
+		}
+		d.Payload = payload
+		due = append(due, &d)
+	}
+	return due, rows.Err()
+}
+
+// MarkDelivered records a successful attempt and resets the webhook's failure count.
+func (r *WebhookRepository) MarkDelivered(d *DueDelivery, statusCode int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`UPDATE webhook_deliveries SET status = 'succeeded', attempts = attempts + 1,
+    last_status_code = $1, last_error = '', delivered_at = NOW()
+WHERE id = $2`, statusCode, d.ID)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE webhooks SET failure_count = 0 WHERE id = $1`, d.WebhookID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MarkFailed records a failed attempt. With a positive retryAfter the
+// delivery stays pending for that long; otherwise it is given up on. The
+// webhook's failure count goes up and it is disabled once the count reaches
+// disableAfter; the return value reports whether that happened now.
+func (r *WebhookRepository) MarkFailed(d *DueDelivery, statusCode *int, errText string, retryAfter time.Duration, disableAfter int) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	status := model.DeliveryPending
+	if retryAfter <= 0 {
+		status = model.DeliveryFailed
+	}
+	_, err = tx.Exec(`UPDATE webhook_deliveries SET status = $1, attempts = attempts + 1,
+    last_status_code = $2, last_error = $3, next_attempt_at = NOW() + $4::float8 * INTERVAL '1 second'
+WHERE id = $5`, status, statusCode, errText, retryAfter.Seconds(), d.ID)
+	if err != nil {
+		return false, err
+	}
+
+	var disabled bool
+	err = tx.QueryRow(`UPDATE webhooks SET failure_count = failure_count + 1,
+    active = active AND failure_count + 1 < $1,
+    disabled_at = CASE WHEN active AND failure_count + 1 >= $1 THEN NOW() ELSE disabled_at END
+WHERE id = $2
+RETURNING disabled_at IS NOT NULL AND NOT active AND failure_count = $1`, disableAfter, d.WebhookID).Scan(&disabled)
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+	return disabled, tx.Commit()
+}
+
+// ListDeliveries returns one page of a webhook's deliveries, newest first,
+// optionally only those with the given status.
+func (r *WebhookRepository) ListDeliveries(webhookID int, status string, limit, offset int) ([]*model.WebhookDelivery, int, error) {
+	var q queryArgs
+	where := `webhook_id = ` + q.add(webhookID)
+	if status != "" {
+		where += ` AND status = ` + q.add(status)
+	}
+	query := `SELECT id, webhook_id, event_type, payload, status, attempts,
+       CASE WHEN status = 'pending' THEN next_attempt_at END, last_status_code, last_error, replay_of, created_at, delivered_at,
+       COUNT(*) OVER ()
+FROM webhook_deliveries
+WHERE ` + where + `
+ORDER BY id DESC
+LIMIT ` + q.add(limit) + ` OFFSET ` + q.add(offset)
+	rows, err := r.db.Query(query, q.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	deliveries := []*model.WebhookDelivery{}
+	total := 0
+	for rows.Next() {
+		var d model.WebhookDelivery
+		var payload []byte
+		err := rows.Scan(&d.ID, &d.WebhookID, &d.EventType, &payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+			&d.LastStatusCode, &d.LastError, &d.ReplayOf, &d.CreatedAt, &d.DeliveredAt, &total)
+		if err != nil {
+			return nil, 0, err
+		}
+		d.Payload = payload
+		deliveries = append(deliveries, &d)
+	}
+	return deliveries, total, rows.Err()
+}
+
+// ReplayDelivery queues a fresh copy of one of a webhook's deliveries and
+// returns its id.
+func (r *WebhookRepository) ReplayDelivery(webhookID int, deliveryID int64) (int64, error) {
+	var id int64
+	err := r.db.QueryRow(`INSERT INTO webhook_deliveries (webhook_id, event_type, payload, replay_of)
+SELECT webhook_id, event_type, payload, id FROM webhook_deliveries WHERE id = $1 AND webhook_id = $2
+RETURNING id`, deliveryID, webhookID).Scan(&id)
+	return id, err
+}
@@ -10,7 +10,7 @@ import (
 	"github.com/wikasdude/blog-backend/middleware"
 )
 
-func InitRoutes(userController *controller.UserController, postController *controller.PostController, categoryController *controller.CategoryController) {
+func InitRoutes(userController *controller.UserController, postController *controller.PostController, categoryController *controller.CategoryController, commentController *controller.CommentController, reactionController *controller.ReactionController, readingListController *controller.ReadingListController, followController *controller.FollowController, notificationController *controller.NotificationController, streamController *controller.StreamController, webhookController *controller.WebhookController, feedController *controller.FeedController, sitemapController *controller.SitemapController, mediaController *controller.MediaController, seoController *controller.SEOController, importController *controller.ImportController, exportController *controller.ExportController, siteController *controller.SiteController) {
 	http.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPost {
 			userController.RegisterUser(w, r)
@@ -60,15 +60,78 @@ func InitRoutes(userController *controller.UserController, postController *contr
 	http.HandleFunc("/api/blog-posts", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodGet {
 			postController.GetAllPosts(w, r)
-			//postController.GetPaginatedPosts(w, r)
 			return
 		} else {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
 	})
+	http.HandleFunc("/api/blog-posts/search", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			postController.SearchPosts(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
 	http.HandleFunc("/api/blog-post/", func(w http.ResponseWriter, r *http.Request) {
 		fmt.Println("path is:", r.URL.Path)
 		pathParts := strings.Split(r.URL.Path, "/")
+		if len(pathParts) == 5 && pathParts[4] == "comments" {
+			postID, err := strconv.Atoi(pathParts[3])
+			if err != nil {
+				http.Error(w, "Invalid ID", http.StatusBadRequest)
+				return
+			}
+			switch r.Method {
+			case http.MethodGet:
+				commentController.ListComments(w, r, postID)
+			case http.MethodPost:
+				middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+					commentController.CreateComment(w, r, postID)
+				})(w, r)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+		if len(pathParts) >= 5 && pathParts[4] == "reactions" {
+			postID, err := strconv.Atoi(pathParts[3])
+			if err != nil {
+				http.Error(w, "Invalid ID", http.StatusBadRequest)
+				return
+			}
+			switch {
+			case len(pathParts) == 5 && r.Method == http.MethodGet:
+				reactionController.GetReactions(w, r, postID)
+			case len(pathParts) == 6 && r.Method == http.MethodPut:
+				middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+					reactionController.React(w, r, postID, pathParts[5])
+				})(w, r)
+			case len(pathParts) == 6 && r.Method == http.MethodDelete:
+				middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+					reactionController.Unreact(w, r, postID, pathParts[5])
+				})(w, r)
+			case len(pathParts) == 7 && pathParts[6] == "users" && r.Method == http.MethodGet:
+				reactionController.ListReactors(w, r, postID, pathParts[5])
+			case len(pathParts) > 7 || (len(pathParts) == 7 && pathParts[6] != "users"):
+				http.Error(w, "Invalid URL", http.StatusBadRequest)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+		if len(pathParts) == 5 && pathParts[4] == "meta" {
+			postID, err := strconv.Atoi(pathParts[3])
+			if err != nil {
+				http.Error(w, "Invalid ID", http.StatusBadRequest)
+				return
+			}
+			if r.Method == http.MethodGet {
+				seoController.PostMetadata(w, r, postID)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+			return
+		}
 		if len(pathParts) != 4 {
 			http.Error(w, "Invalid URL", http.StatusBadRequest)
 			return
@@ -77,6 +140,15 @@ func InitRoutes(userController *controller.UserController, postController *contr
 		fmt.Println("line no 62", len(pathParts))
 		fmt.Println(pathParts[2])
 
+		if pathParts[3] == "preview" {
+			if r.Method == http.MethodPost {
+				middleware.AuthMiddleware(postController.PreviewPost)(w, r)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
 		id, err := strconv.Atoi(pathParts[3])
 		fmt.Println(id, " error is:", err)
 		if err != nil {
@@ -106,6 +178,559 @@ func InitRoutes(userController *controller.UserController, postController *contr
 		}
 	})
 
+	http.HandleFunc("/api/reactions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			reactionController.ListReactionTypes(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	http.HandleFunc("/api/me/reactions", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			reactionController.MyReactions(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+
+	http.HandleFunc("/api/me/bookmarks", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			readingListController.ListBookmarks(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	http.HandleFunc("/api/me/bookmarks/", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		// /api/me/bookmarks/{postId}
+		pathParts := strings.Split(r.URL.Path, "/")
+		if len(pathParts) != 5 {
+			http.Error(w, "Invalid URL", http.StatusBadRequest)
+			return
+		}
+		postID, err := strconv.Atoi(pathParts[4])
+		if err != nil {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPut:
+			readingListController.Bookmark(w, r, postID)
+		case http.MethodDelete:
+			readingListController.Unbookmark(w, r, postID)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	http.HandleFunc("/api/me/reading-lists", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			readingListController.ListReadingLists(w, r)
+		case http.MethodPost:
+			readingListController.CreateReadingList(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	http.HandleFunc("/api/reading-lists/", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		// /api/reading-lists/{id}, /api/reading-lists/{id}/order or /api/reading-lists/{id}/posts/{postId}
+		pathParts := strings.Split(r.URL.Path, "/")
+		if len(pathParts) < 4 || len(pathParts) > 6 {
+			http.Error(w, "Invalid URL", http.StatusBadRequest)
+			return
+		}
+		id, err := strconv.Atoi(pathParts[3])
+		if err != nil {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+
+		switch {
+		case len(pathParts) == 4:
+			switch r.Method {
+			case http.MethodGet:
+				readingListController.GetReadingList(w, r, id)
+			case http.MethodPatch:
+				readingListController.UpdateReadingList(w, r, id)
+			case http.MethodDelete:
+				readingListController.DeleteReadingList(w, r, id)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		case len(pathParts) == 5 && pathParts[4] == "order":
+			if r.Method == http.MethodPut {
+				readingListController.ReorderReadingList(w, r, id)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		case len(pathParts) == 6 && pathParts[4] == "posts":
+			postID, err := strconv.Atoi(pathParts[5])
+			if err != nil {
+				http.Error(w, "Invalid ID", http.StatusBadRequest)
+				return
+			}
+			switch r.Method {
+			case http.MethodPut:
+				readingListController.AddToReadingList(w, r, id, postID)
+			case http.MethodDelete:
+				readingListController.RemoveFromReadingList(w, r, id, postID)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		default:
+			http.Error(w, "Invalid URL", http.StatusBadRequest)
+		}
+	}))
+	http.HandleFunc("/api/shared/reading-lists/", func(w http.ResponseWriter, r *http.Request) {
+		// /api/shared/reading-lists/{token}
+		pathParts := strings.Split(r.URL.Path, "/")
+		if len(pathParts) != 5 || pathParts[4] == "" {
+			http.Error(w, "Invalid URL", http.StatusBadRequest)
+			return
+		}
+		if r.Method == http.MethodGet {
+			readingListController.GetSharedReadingList(w, r, pathParts[4])
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/feed", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			followController.Feed(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	http.HandleFunc("/api/me/follows", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			followController.ListFollows(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	http.HandleFunc("/api/me/follows/", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		// /api/me/follows/{authors|categories|tags}/{id or tag slug}
+		pathParts := strings.Split(r.URL.Path, "/")
+		if len(pathParts) != 6 || pathParts[5] == "" {
+			http.Error(w, "Invalid URL", http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPut:
+			followController.Follow(w, r, pathParts[4], pathParts[5])
+		case http.MethodDelete:
+			followController.Unfollow(w, r, pathParts[4], pathParts[5])
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	http.HandleFunc("/api/authors/", func(w http.ResponseWriter, r *http.Request) {
+		pathParts := strings.Split(r.URL.Path, "/")
+		if len(pathParts) != 4 {
+			http.Error(w, "Invalid URL", http.StatusBadRequest)
+			return
+		}
+		id, err := strconv.Atoi(pathParts[3])
+		if err != nil {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+		if r.Method == http.MethodGet {
+			followController.GetAuthor(w, r, id)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/me/notifications", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			notificationController.ListNotifications(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	http.HandleFunc("/api/me/notifications/", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		// /api/me/notifications/unread-count, /api/me/notifications/read-all or /api/me/notifications/{id}/read
+		pathParts := strings.Split(r.URL.Path, "/")
+		switch {
+		case len(pathParts) == 5 && pathParts[4] == "unread-count":
+			if r.Method == http.MethodGet {
+				notificationController.UnreadCount(w, r)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		case len(pathParts) == 5 && pathParts[4] == "read-all":
+			if r.Method == http.MethodPost {
+				notificationController.MarkAllRead(w, r)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		case len(pathParts) == 6 && pathParts[5] == "read":
+			id, err := strconv.Atoi(pathParts[4])
+			if err != nil {
+				http.Error(w, "Invalid ID", http.StatusBadRequest)
+				return
+			}
+			if r.Method == http.MethodPost {
+				notificationController.MarkRead(w, r, id)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		default:
+			http.Error(w, "Invalid URL", http.StatusBadRequest)
+		}
+	}))
+	http.HandleFunc("/api/me/notification-preferences", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			notificationController.GetPreferences(w, r)
+		case http.MethodPut:
+			notificationController.UpdatePreferences(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+
+	http.HandleFunc("/api/stream", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			streamController.Stream(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/feeds/", func(w http.ResponseWriter, r *http.Request) {
+		// /api/feeds/{format} or /api/feeds/{kind}/{ref}/{format}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		pathParts := strings.Split(r.URL.Path, "/")
+		switch len(pathParts) {
+		case 4:
+			feedController.Feed(w, r, pathParts[3])
+		case 6:
+			feedController.ScopedFeed(w, r, pathParts[3], pathParts[4], pathParts[5])
+		default:
+			http.Error(w, "Invalid URL", http.StatusBadRequest)
+		}
+	})
+
+	http.HandleFunc("/sitemap.xml", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			sitemapController.Sitemap(w, r, 0)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	http.HandleFunc("/sitemaps/", func(w http.ResponseWriter, r *http.Request) {
+		// /sitemaps/{page}.xml
+		pathParts := strings.Split(r.URL.Path, "/")
+		if len(pathParts) != 3 || !strings.HasSuffix(pathParts[2], ".xml") {
+			http.Error(w, "Invalid URL", http.StatusBadRequest)
+			return
+		}
+		page, err := strconv.Atoi(strings.TrimSuffix(pathParts[2], ".xml"))
+		if err != nil || page < 1 {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			sitemapController.Sitemap(w, r, page)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	http.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			sitemapController.Robots(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/imports", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			importController.ListImports(w, r)
+		case http.MethodPost:
+			importController.StartImport(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	http.HandleFunc("/api/imports/", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		pathParts := strings.Split(r.URL.Path, "/")
+		if len(pathParts) != 4 {
+			http.Error(w, "Invalid URL", http.StatusBadRequest)
+			return
+		}
+		id, err := strconv.Atoi(pathParts[3])
+		if err != nil {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+		if r.Method == http.MethodGet {
+			importController.GetImport(w, r, id)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	http.HandleFunc("/api/exports", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			exportController.ListExports(w, r)
+		case http.MethodPost:
+			exportController.StartExport(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	http.HandleFunc("/api/exports/", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		// /api/exports/{id} or /api/exports/{id}/download
+		pathParts := strings.Split(r.URL.Path, "/")
+		if len(pathParts) < 4 || len(pathParts) > 5 {
+			http.Error(w, "Invalid URL", http.StatusBadRequest)
+			return
+		}
+		id, err := strconv.Atoi(pathParts[3])
+		if err != nil {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+
+		switch {
+		case len(pathParts) == 4:
+			switch r.Method {
+			case http.MethodGet:
+				exportController.GetExport(w, r, id)
+			case http.MethodDelete:
+				exportController.DeleteExport(w, r, id)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		case pathParts[4] == "download":
+			if r.Method == http.MethodGet {
+				exportController.DownloadExport(w, r, id)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		default:
+			http.Error(w, "Invalid URL", http.StatusBadRequest)
+		}
+	}))
+	http.HandleFunc("/api/media", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			mediaController.UploadMedia(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	http.HandleFunc("/api/media/", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		// /api/media/{id} or /api/media/{id}/url
+		pathParts := strings.Split(r.URL.Path, "/")
+		if len(pathParts) < 4 {
+			http.Error(w, "Invalid URL", http.StatusBadRequest)
+			return
+		}
+		id, err := strconv.Atoi(pathParts[3])
+		if err != nil {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+
+		switch {
+		case len(pathParts) == 4:
+			switch r.Method {
+			case http.MethodGet:
+				mediaController.GetMedia(w, r, id)
+			case http.MethodDelete:
+				mediaController.DeleteMedia(w, r, id)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		case len(pathParts) == 5 && pathParts[4] == "url":
+			if r.Method == http.MethodGet {
+				mediaController.SignedMediaURL(w, r, id)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		default:
+			http.Error(w, "Invalid URL", http.StatusBadRequest)
+		}
+	}))
+	http.HandleFunc("/api/me/media", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			mediaController.ListMyMedia(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	http.HandleFunc("/media/", func(w http.ResponseWriter, r *http.Request) {
+		// /media/{key}, where the key may itself contain slashes
+		key := strings.TrimPrefix(r.URL.Path, "/media/")
+		if key == "" {
+			http.Error(w, "Invalid URL", http.StatusBadRequest)
+			return
+		}
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			mediaController.ServeMedia(w, r, key)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/webhooks", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			webhookController.ListWebhooks(w, r)
+		case http.MethodPost:
+			webhookController.CreateWebhook(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	http.HandleFunc("/api/webhooks/", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		// /api/webhooks/{id}, /api/webhooks/{id}/deliveries or /api/webhooks/{id}/deliveries/{deliveryId}/replay
+		pathParts := strings.Split(r.URL.Path, "/")
+		if len(pathParts) < 4 {
+			http.Error(w, "Invalid URL", http.StatusBadRequest)
+			return
+		}
+		id, err := strconv.Atoi(pathParts[3])
+		if err != nil {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+
+		switch {
+		case len(pathParts) == 4:
+			switch r.Method {
+			case http.MethodGet:
+				webhookController.GetWebhook(w, r, id)
+			case http.MethodPatch:
+				webhookController.UpdateWebhook(w, r, id)
+			case http.MethodDelete:
+				webhookController.DeleteWebhook(w, r, id)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		case len(pathParts) == 5 && pathParts[4] == "deliveries":
+			if r.Method == http.MethodGet {
+				webhookController.ListDeliveries(w, r, id)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		case len(pathParts) == 7 && pathParts[4] == "deliveries" && pathParts[6] == "replay":
+			deliveryID, err := strconv.ParseInt(pathParts[5], 10, 64)
+			if err != nil {
+				http.Error(w, "Invalid ID", http.StatusBadRequest)
+				return
+			}
+			if r.Method == http.MethodPost {
+				webhookController.ReplayDelivery(w, r, id, deliveryID)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		default:
+			http.Error(w, "Invalid URL", http.StatusBadRequest)
+		}
+	}))
+
+	http.HandleFunc("/api/comments/", func(w http.ResponseWriter, r *http.Request) {
+		pathParts := strings.Split(r.URL.Path, "/")
+		if len(pathParts) != 4 {
+			http.Error(w, "Invalid URL", http.StatusBadRequest)
+			return
+		}
+		id, err := strconv.Atoi(pathParts[3])
+		if err != nil {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			commentController.GetComment(w, r, id)
+		case http.MethodPatch:
+			middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+				commentController.UpdateComment(w, r, id)
+			})(w, r)
+		case http.MethodDelete:
+			middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+				commentController.DeleteComment(w, r, id)
+			})(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/moderation/comments", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			commentController.ModerationQueue(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	http.HandleFunc("/api/moderation/comments/", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		// /api/moderation/comments/{id}/{approve|reject|spam}
+		pathParts := strings.Split(r.URL.Path, "/")
+		if len(pathParts) != 6 {
+			http.Error(w, "Invalid URL", http.StatusBadRequest)
+			return
+		}
+		id, err := strconv.Atoi(pathParts[4])
+		if err != nil {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+		if r.Method == http.MethodPost {
+			commentController.ModerateComment(w, r, id, pathParts[5])
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+
+	http.HandleFunc("/api/trash/posts", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			postController.ListTrash(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	http.HandleFunc("/api/trash/posts/", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		// /api/trash/posts/{id} or /api/trash/posts/{id}/restore
+		pathParts := strings.Split(r.URL.Path, "/")
+		if len(pathParts) != 5 && len(pathParts) != 6 {
+			http.Error(w, "Invalid URL", http.StatusBadRequest)
+			return
+		}
+		id, err := strconv.Atoi(pathParts[4])
+		if err != nil {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+
+		if len(pathParts) == 6 {
+			if pathParts[5] != "restore" {
+				http.Error(w, "Invalid URL", http.StatusBadRequest)
+			} else if r.Method == http.MethodPost {
+				postController.RestorePost(w, r, id)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		if r.Method == http.MethodDelete {
+			postController.PurgePost(w, r, id)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+
 	http.HandleFunc("/api/categories", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPost {
 			categoryController.CreateCategory(w, r)
@@ -115,4 +740,96 @@ func InitRoutes(userController *controller.UserController, postController *contr
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
 	})
+	http.HandleFunc("/api/categories/", func(w http.ResponseWriter, r *http.Request) {
+		pathParts := strings.Split(r.URL.Path, "/")
+		if len(pathParts) != 4 {
+			http.Error(w, "Invalid URL", http.StatusBadRequest)
+			return
+		}
+
+		if pathParts[3] == "tree" {
+			if r.Method == http.MethodGet {
+				categoryController.GetCategoryTree(w, r)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		id, err := strconv.Atoi(pathParts[3])
+		if err != nil {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			categoryController.GetCategoryByID(w, r, id)
+		case http.MethodPut:
+			middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+				categoryController.UpdateCategory(w, r, id)
+			})(w, r)
+		case http.MethodDelete:
+			middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+				categoryController.DeleteCategory(w, r, id)
+			})(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	if siteController != nil {
+		initSiteRoutes(siteController)
+	}
+}
+
+// initSiteRoutes serves the public site at the paths service.PublicPath
+// links to. Anything else under / is its not found page.
+func initSiteRoutes(siteController *controller.SiteController) {
+	site := func(handle func(w http.ResponseWriter, r *http.Request, pathParts []string)) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			handle(w, r, strings.Split(r.URL.Path, "/"))
+		}
+	}
+	withID := func(show func(w http.ResponseWriter, r *http.Request, id int)) http.HandlerFunc {
+		return site(func(w http.ResponseWriter, r *http.Request, pathParts []string) {
+			id, err := strconv.Atoi(pathParts[2])
+			if len(pathParts) != 3 || err != nil {
+				siteController.NotFound(w, r)
+				return
+			}
+			show(w, r, id)
+		})
+	}
+	withSlug := func(show func(w http.ResponseWriter, r *http.Request, slug string)) http.HandlerFunc {
+		return site(func(w http.ResponseWriter, r *http.Request, pathParts []string) {
+			if len(pathParts) != 3 || pathParts[2] == "" {
+				siteController.NotFound(w, r)
+				return
+			}
+			show(w, r, pathParts[2])
+		})
+	}
+
+	http.HandleFunc("/", site(func(w http.ResponseWriter, r *http.Request, pathParts []string) {
+		if r.URL.Path != "/" {
+			siteController.NotFound(w, r)
+			return
+		}
+		siteController.Home(w, r)
+	}))
+	http.HandleFunc("/posts/", withID(siteController.Post))
+	http.HandleFunc("/authors/", withID(siteController.Author))
+	http.HandleFunc("/categories/", withSlug(siteController.Category))
+	http.HandleFunc("/tags/", withSlug(siteController.Tag))
+	http.HandleFunc("/search", site(func(w http.ResponseWriter, r *http.Request, pathParts []string) {
+		siteController.Search(w, r)
+	}))
+	http.HandleFunc("/theme/", site(func(w http.ResponseWriter, r *http.Request, pathParts []string) {
+		siteController.Static(w, r, strings.TrimPrefix(r.URL.Path, "/theme/"))
+	}))
 }
@@ -1,8 +1,23 @@
 package service
 
 import (
+	"database/sql"
+	"errors"
+	"strings"
+
 	"github.com/wikasdude/blog-backend/model"
 	repository "github.com/wikasdude/blog-backend/repositories"
+	"github.com/wikasdude/blog-backend/utils"
+)
+
+var (
+	ErrCategoryNotFound  = errors.New("category not found")
+	ErrCategoryNameTaken = errors.New("category name is already taken")
+	ErrCategorySlugTaken = errors.New("category slug is already taken")
+	ErrCategoryInvalid   = errors.New("category name cannot be empty")
+	ErrCategoryParent    = errors.New("invalid parent category")
+	ErrCategoryReassign  = errors.New("invalid reassign_to category")
+	ErrCategoryInUse     = errors.New("category still has posts; pass reassign_to to move them")
 )
 
 type CategoryService struct {
@@ -14,9 +29,139 @@ func NewCategoryService(repo *repository.CategoryRepository) *CategoryService {
 }
 
 func (s *CategoryService) CreateCategory(category *model.Category) error {
+	if err := s.validate(category); err != nil {
+		return err
+	}
 	return s.Repo.CreateCategory(category)
 }
 
+func (s *CategoryService) GetCategoryByID(id int) (*model.Category, error) {
+	category, err := s.Repo.GetCategoryByID(id)
+	if err == sql.ErrNoRows {
+		return nil, ErrCategoryNotFound
+	}
+	return category, err
+}
+
 func (s *CategoryService) GetAllCategories() ([]*model.Category, error) {
 	return s.Repo.GetAllCategories()
 }
+
+// GetCategoryTree returns the root categories with their descendants nested under Children.
+func (s *CategoryService) GetCategoryTree() ([]*model.Category, error) {
+	categories, err := s.Repo.GetAllCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]*model.Category, len(categories))
+	for _, c := range categories {
+		byID[c.ID] = c
+	}
+
+	roots := []*model.Category{}
+	for _, c := range categories {
+		if c.ParentID != nil {
+			if parent, ok := byID[*c.ParentID]; ok {
+				parent.Children = append(parent.Children, c)
+				continue
+			}
+		}
+		roots = append(roots, c)
+	}
+	return roots, nil
+}
+
+func (s *CategoryService) UpdateCategory(category *model.Category) error {
+	if _, err := s.GetCategoryByID(category.ID); err != nil {
+		return err
+	}
+	if err := s.validate(category); err != nil {
+		return err
+	}
+	if category.ParentID != nil {
+		cycle, err := s.Repo.IsDescendant(category.ID, *category.ParentID)
+		if err != nil {
+			return err
+		}
+		if cycle {
+			return ErrCategoryParent
+		}
+	}
+	return s.Repo.UpdateCategory(category)
+}
+
+// DeleteCategory refuses to delete a category that still has posts unless
+// reassignTo names another existing category to move them into.
+func (s *CategoryService) DeleteCategory(id int, reassignTo *int) error {
+	if _, err := s.GetCategoryByID(id); err != nil {
+		return err
+	}
+	if reassignTo != nil {
+		if *reassignTo == id {
+			return ErrCategoryReassign
+		}
+		if _, err := s.GetCategoryByID(*reassignTo); err == ErrCategoryNotFound {
+			return ErrCategoryReassign
+		} else if err != nil {
+			return err
+		}
+	} else {
+		count, err := s.Repo.CountPosts(id)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrCategoryInUse
+		}
+	}
+
+	err := s.Repo.DeleteCategory(id, reassignTo)
+	if err == sql.ErrNoRows {
+		return ErrCategoryNotFound
+	}
+	return err
+}
+
+// validate normalises name and slug and checks uniqueness and the parent reference.
+func (s *CategoryService) validate(category *model.Category) error {
+	category.Name = strings.TrimSpace(category.Name)
+	category.Description = strings.TrimSpace(category.Description)
+	if category.Name == "" {
+		return ErrCategoryInvalid
+	}
+	if category.Slug == "" {
+		category.Slug = category.Name
+	}
+	category.Slug = utils.Slugify(category.Slug)
+	if category.Slug == "" {
+		return ErrCategoryInvalid
+	}
+
+	taken, err := s.Repo.IsNameTaken(category.Name, category.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrCategoryNameTaken
+	}
+	taken, err = s.Repo.IsSlugTaken(category.Slug, category.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrCategorySlugTaken
+	}
+
+	if category.ParentID != nil {
+		if *category.ParentID == category.ID {
+			return ErrCategoryParent
+		}
+		if _, err := s.GetCategoryByID(*category.ParentID); err == ErrCategoryNotFound {
+			return ErrCategoryParent
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/wikasdude/blog-backend/config"
+	"github.com/wikasdude/blog-backend/events"
+	"github.com/wikasdude/blog-backend/model"
+	repository "github.com/wikasdude/blog-backend/repositories"
+	"github.com/wikasdude/blog-backend/utils"
+)
+
+var (
+	ErrCommentNotFound  = errors.New("comment not found")
+	ErrCommentInvalid   = errors.New("invalid comment")
+	ErrCommentTooDeep   = errors.New("replies are nested too deeply")
+	ErrCommentForbidden = errors.New("you are not allowed to change this comment")
+	ErrCommentCursor    = errors.New("invalid cursor")
+)
+
+type CommentService struct {
+	commentRepo *repository.CommentRepository
+	postRepo    *repository.PostRepository
+	moderation  *ModerationService
+	events      *events.Bus
+	maxDepth    int
+}
+
+func NewCommentService(commentRepo *repository.CommentRepository, postRepo *repository.PostRepository, moderation *ModerationService, bus *events.Bus) *CommentService {
+	return &CommentService{
+		commentRepo: commentRepo,
+		postRepo:    postRepo,
+		moderation:  moderation,
+		events:      bus,
+		maxDepth:    config.GetEnvInt(config.EnvCommentMaxDepth, config.DefaultCommentDepth),
+	}
+}
+
+// commentablePost returns the post if readers can see, and so comment on, it.
+func (s *CommentService) commentablePost(postID int) (*model.Post, error) {
+	post, err := s.postRepo.GetPostByID(postID)
+	if err == sql.ErrNoRows || (err == nil && post.Status != model.PostStatusPublished) {
+		return nil, ErrPostNotFound
+	}
+	return post, err
+}
+
+func validateCommentBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", fmt.Errorf("%w: body cannot be empty", ErrCommentInvalid)
+	}
+	if utf8.RuneCountInString(body) > config.MaxCommentLength {
+		return "", fmt.Errorf("%w: body is longer than %d characters", ErrCommentInvalid, config.MaxCommentLength)
+	}
+	return body, nil
+}
+
+// Add a comment, or a reply when ParentID is set, to a published post. The
+// comment is screened first and may come back pending or as spam.
+func (s *CommentService) CreateComment(postID, userID int, role string, req model.CommentRequest) (*model.Comment, error) {
+	body, err := validateCommentBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.commentablePost(postID); err != nil {
+		return nil, err
+	}
+
+	comment := &model.Comment{PostID: postID, UserID: userID, Body: body}
+	if req.ParentID != nil {
+		parent, err := s.commentRepo.GetCommentByID(*req.ParentID)
+		if err == sql.ErrNoRows || (err == nil && (parent.PostID != postID || parent.Deleted || parent.Status != model.CommentStatusApproved)) {
+			return nil, fmt.Errorf("%w: parent comment not found on this post", ErrCommentInvalid)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if parent.Depth+1 > s.maxDepth {
+			return nil, ErrCommentTooDeep
+		}
+		comment.ParentID = &parent.ID
+		comment.Depth = parent.Depth + 1
+	}
+
+	if err := s.moderation.Screen(comment, role); err != nil {
+		return nil, err
+	}
+	if err := s.commentRepo.CreateComment(comment); err != nil {
+		return nil, err
+	}
+	if comment.Status == model.CommentStatusApproved {
+		s.events.Publish(events.Event{Type: events.CommentCreated, ActorID: userID, PostID: postID, CommentID: comment.ID})
+	}
+	return s.GetComment(comment.ID)
+}
+
+func (s *CommentService) GetComment(id int) (*model.Comment, error) {
+	comment, err := s.commentRepo.GetCommentByID(id)
+	if err == sql.ErrNoRows {
+		return nil, ErrCommentNotFound
+	}
+	return comment, err
+}
+
+// Get a comment as readers see it: approved only, without moderation details
+func (s *CommentService) GetPublicComment(id int) (*model.Comment, error) {
+	comment, err := s.GetComment(id)
+	if err != nil {
+		return nil, err
+	}
+	if comment.Status != model.CommentStatusApproved {
+		return nil, ErrCommentNotFound
+	}
+	hideModeration(comment)
+	return comment, nil
+}
+
+// hideModeration strips the spam score and moderation reasons from a comment
+// shown to readers.
+func hideModeration(c *model.Comment) {
+	c.SpamScore, c.Reasons = nil, nil
+}
+
+// Edit a comment's body; only its author or an admin may do so. Edited
+// comments are screened again.
+func (s *CommentService) UpdateComment(id, userID int, role, body string) (*model.Comment, error) {
+	comment, err := s.GetComment(id)
+	if err != nil {
+		return nil, err
+	}
+	if comment.Deleted {
+		return nil, ErrCommentNotFound
+	}
+	if comment.UserID != userID && role != "admin" {
+		return nil, ErrCommentForbidden
+	}
+	if comment.Body, err = validateCommentBody(body); err != nil {
+		return nil, err
+	}
+	if err := s.commentRepo.UpdateCommentBody(comment); err == sql.ErrNoRows {
+		return nil, ErrCommentNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if err := s.moderation.Rescreen(comment, role); err != nil {
+		return nil, err
+	}
+	if comment.Status == model.CommentStatusApproved {
+		s.events.Publish(events.Event{Type: events.CommentUpdated, ActorID: userID, PostID: comment.PostID, CommentID: id})
+	}
+	return comment, nil
+}
+
+// Delete a comment; its author, the post's author and admins may do so
+func (s *CommentService) DeleteComment(id, userID int, role string) error {
+	comment, err := s.GetComment(id)
+	if err != nil {
+		return err
+	}
+	if comment.Deleted {
+		return ErrCommentNotFound
+	}
+	if comment.UserID != userID && role != "admin" {
+		post, err := s.postRepo.GetPostByID(comment.PostID)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if post == nil || post.UserID != userID {
+			return ErrCommentForbidden
+		}
+	}
+	if err := s.commentRepo.DeleteComment(id); err == sql.ErrNoRows {
+		return ErrCommentNotFound
+	} else if err != nil {
+		return err
+	}
+	if comment.Status == model.CommentStatusApproved {
+		s.events.Publish(events.Event{Type: events.CommentDeleted, ActorID: userID, PostID: comment.PostID, CommentID: id})
+	}
+	return nil
+}
+
+// List one page of top-level comments on a post with all their replies
+// nested under them, oldest reply first
+func (s *CommentService) ListComments(params model.CommentListParams) (*model.CommentPage, error) {
+	if _, err := s.commentablePost(params.PostID); err != nil {
+		return nil, err
+	}
+
+	comments, err := s.commentRepo.ListComments(params)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.commentRepo.CountTopLevelComments(params.PostID)
+	if err != nil {
+		return nil, err
+	}
+	page := &model.CommentPage{Comments: comments, Total: total}
+	if len(comments) > params.Limit {
+		page.Comments = comments[:params.Limit]
+		page.NextCursor = utils.EncodeCursor(commentCursor(params.Sort, page.Comments[params.Limit-1]))
+	}
+
+	rootIDs := make([]int, len(page.Comments))
+	byID := make(map[int]*model.Comment, len(page.Comments))
+	for i, c := range page.Comments {
+		hideModeration(c)
+		rootIDs[i] = c.ID
+		byID[c.ID] = c
+	}
+	replies, err := s.commentRepo.ListReplies(rootIDs)
+	if err != nil {
+		return nil, err
+	}
+	// replies arrive oldest first, so every parent is seen before its children
+	for _, reply := range replies {
+		parent, ok := byID[*reply.ParentID]
+		if !ok {
+			continue // under a hidden deleted comment
+		}
+		hideModeration(reply)
+		parent.Replies = append(parent.Replies, reply)
+		byID[reply.ID] = reply
+	}
+	return page, nil
+}
+
+// DecodeCommentCursor parses a cursor token and checks it was issued for
+// sort and holds a position commentCursor could have written: a reply count
+// for the top sort and a time otherwise.
+func DecodeCommentCursor(token, sort string) (*model.CommentCursor, error) {
+	var cursor model.CommentCursor
+	if err := utils.DecodeCursor(token, &cursor); err != nil || cursor.Sort != sort || cursor.ID < 1 {
+		return nil, ErrCommentCursor
+	}
+	if sort == model.CommentSortTop {
+		if replies, err := strconv.Atoi(cursor.Value); err != nil || replies < 0 {
+			return nil, ErrCommentCursor
+		}
+	} else if _, err := time.Parse(time.RFC3339Nano, cursor.Value); err != nil {
+		return nil, ErrCommentCursor
+	}
+	return &cursor, nil
+}
+
+func commentCursor(sort string, c *model.Comment) model.CommentCursor {
+	cursor := model.CommentCursor{Sort: sort, ID: c.ID}
+	if sort == model.CommentSortTop {
+		cursor.Value = strconv.Itoa(c.ReplyCount)
+	} else {
+		cursor.Value = c.CreatedAt.Format(time.RFC3339Nano)
+	}
+	return cursor
+}
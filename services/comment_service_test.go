@@ -0,0 +1,34 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/wikasdude/blog-backend/model"
+	"github.com/wikasdude/blog-backend/utils"
+)
+
+func TestDecodeCommentCursor(t *testing.T) {
+	tests := []struct {
+		sort   string
+		cursor model.CommentCursor
+		ok     bool
+	}{
+		{model.CommentSortTop, model.CommentCursor{Sort: model.CommentSortTop, Value: "4", ID: 9}, true},
+		{model.CommentSortOldest, model.CommentCursor{Sort: model.CommentSortOldest, Value: "2024-05-01T10:00:00.5Z", ID: 9}, true},
+		{model.CommentSortTop, model.CommentCursor{Sort: model.CommentSortTop, Value: "abc", ID: 9}, false},
+		{model.CommentSortTop, model.CommentCursor{Sort: model.CommentSortTop, Value: "-2", ID: 9}, false},
+		{model.CommentSortOldest, model.CommentCursor{Sort: model.CommentSortOldest, Value: "4", ID: 9}, false},
+		{model.CommentSortOldest, model.CommentCursor{Sort: model.CommentSortOldest, Value: "2024-05-01T10:00:00Z"}, false},
+		{model.CommentSortTop, model.CommentCursor{Sort: model.CommentSortOldest, Value: "2024-05-01T10:00:00Z", ID: 9}, false},
+	}
+	for _, tt := range tests {
+		got, err := DecodeCommentCursor(utils.EncodeCursor(tt.cursor), tt.sort)
+		if tt.ok && (err != nil || *got != tt.cursor) {
+			t.Errorf("DecodeCommentCursor(%+v, %s) = %+v, %v", tt.cursor, tt.sort, got, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrCommentCursor) {
+			t.Errorf("DecodeCommentCursor(%+v, %s) = %v, want ErrCommentCursor", tt.cursor, tt.sort, err)
+		}
+	}
+}
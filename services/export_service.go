@@ -0,0 +1,668 @@
+package service
+
+import (
+	"archive/zip"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wikasdude/blog-backend/config"
+	"github.com/wikasdude/blog-backend/model"
+	repository "github.com/wikasdude/blog-backend/repositories"
+	"github.com/wikasdude/blog-backend/storage"
+	"github.com/wikasdude/blog-backend/utils"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrExportNotFound = errors.New("export not found")
+	ErrExportFilter   = errors.New("invalid export filter")
+	ErrExportNotReady = errors.New("export is not ready")
+	ErrExportRunning  = errors.New("export is still running")
+)
+
+// exportVersion is the version of the ZIP layout, recorded in manifest.json.
+const exportVersion = 1
+
+const exportBatch = 200
+
+// mediaRef finds the media a post body links to by the stem of its storage
+// key, so links to resized variants count as links to the original.
+var mediaRef = regexp.MustCompile(regexp.QuoteMeta(MediaPathPrefix) + `(\d+/[0-9a-f]{32})`)
+
+// ExportService writes the blog, or part of it, to a ZIP that static site
+// generators and other blogs can read:
+//
+//	manifest.json                what was exported, with counts
+//	authors.json                 the authors of the exported posts
+//	categories.json              categories, with their parent's slug
+//	tags.json                    the tags of the exported posts
+//	content/posts/{id}-{slug}.md posts as Markdown with YAML front matter
+//	static/media/{key}           public media the posts use
+//	private/media/{key}          private media the posts use
+//
+// The front matter uses the field names Hugo and Jekyll read, and media keeps
+// the /media/ paths post bodies link to when static/ is served as the site
+// root. Imported posts keep the slug they had on their old site, and their
+// old address is among their aliases. The ZIP can be imported again as a
+// Markdown export; the import takes category slugs and parents from
+// categories.json and the site from manifest.json.
+//
+// Exports run in the background and are kept in the media storage backend
+// until deleted; WriteExport writes one directly.
+type ExportService struct {
+	exportRepo   *repository.ExportRepository
+	importRepo   *repository.ImportRepository
+	postRepo     *repository.PostRepository
+	categoryRepo *repository.CategoryRepository
+	userRepo     repository.UserRepository
+	mediaRepo    *repository.MediaRepository
+	storage      storage.Storage
+	siteURL      string
+	wake         chan struct{}
+}
+
+func NewExportService(exportRepo *repository.ExportRepository, importRepo *repository.ImportRepository, postRepo *repository.PostRepository,
+	categoryRepo *repository.CategoryRepository, userRepo repository.UserRepository, mediaRepo *repository.MediaRepository, store storage.Storage) *ExportService {
+	return &ExportService{
+		exportRepo:   exportRepo,
+		importRepo:   importRepo,
+		postRepo:     postRepo,
+		categoryRepo: categoryRepo,
+		userRepo:     userRepo,
+		mediaRepo:    mediaRepo,
+		storage:      store,
+		siteURL:      strings.TrimRight(config.GetEnv(config.EnvSiteURL, ""), "/"),
+		wake:         make(chan struct{}, 1),
+	}
+}
+
+// NewExportFilter builds a filter from its parts; zero ids and empty dates
+// leave that part out. Dates are RFC 3339 timestamps or plain dates, and a
+// plain "to" date includes the whole day.
+func NewExportFilter(authorID, categoryID int, from, to string) (model.ExportFilter, error) {
+	filter := model.ExportFilter{AuthorID: authorID, CategoryID: categoryID}
+	if authorID < 0 || categoryID < 0 {
+		return filter, fmt.Errorf("%w: ids must be positive", ErrExportFilter)
+	}
+	if from != "" {
+		t, err := utils.ParseDate(from)
+		if err != nil {
+			return filter, fmt.Errorf("%w: invalid from date %q", ErrExportFilter, from)
+		}
+		filter.From = &t
+	}
+	if to != "" {
+		t, err := utils.ParseDate(to)
+		if err != nil {
+			return filter, fmt.Errorf("%w: invalid to date %q", ErrExportFilter, to)
+		}
+		if len(to) == len("2006-01-02") {
+			t = t.Add(24*time.Hour - time.Nanosecond) // include the whole day
+		}
+		filter.To = &t
+	}
+	if filter.From != nil && filter.To != nil && filter.To.Before(*filter.From) {
+		return filter, fmt.Errorf("%w: to is before from", ErrExportFilter)
+	}
+	return filter, nil
+}
+
+// StartExport queues an export of the posts that pass filter.
+func (s *ExportService) StartExport(userID int, filter model.ExportFilter) (*model.ExportJob, error) {
+	job := &model.ExportJob{UserID: userID, Filter: filter}
+	if err := s.exportRepo.CreateJob(job); err != nil {
+		return nil, err
+	}
+	s.poke()
+	return job, nil
+}
+
+func withDownloadPath(job *model.ExportJob) *model.ExportJob {
+	if job.Status == model.ExportDone {
+		job.DownloadPath = "/api/exports/" + strconv.Itoa(job.ID) + "/download"
+	}
+	return job
+}
+
+func (s *ExportService) GetExport(id int) (*model.ExportJob, error) {
+	job, err := s.exportRepo.GetJob(id)
+	if err == sql.ErrNoRows {
+		return nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return withDownloadPath(job), nil
+}
+
+// ListExports returns one page of exports, newest first.
+func (s *ExportService) ListExports(page, limit int) (*model.ExportPage, error) {
+	jobs, total, err := s.exportRepo.ListJobs(limit, (page-1)*limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		withDownloadPath(job)
+	}
+	return &model.ExportPage{Exports: jobs, Total: total, Page: page, Limit: limit}, nil
+}
+
+// OpenExport returns a finished export and its ZIP. The caller must close it.
+func (s *ExportService) OpenExport(id int) (*model.ExportJob, io.ReadCloser, error) {
+	job, err := s.GetExport(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if job.Status != model.ExportDone {
+		return nil, nil, ErrExportNotReady
+	}
+	rc, err := s.storage.Open(job.Key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return job, rc, nil
+}
+
+// DeleteExport forgets an export and deletes its ZIP. Running exports
+// cannot be deleted.
+func (s *ExportService) DeleteExport(id int) error {
+	job, err := s.GetExport(id)
+	if err != nil {
+		return err
+	}
+	if job.Status == model.ExportRunning {
+		return ErrExportRunning
+	}
+	if err := s.exportRepo.DeleteJob(id); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrExportNotFound
+		}
+		return err
+	}
+	if job.Key != "" {
+		if err := s.storage.Delete(job.Key); err != nil {
+			log.Printf("export: deleting %s: %v", job.Key, err)
+		}
+	}
+	return nil
+}
+
+func (s *ExportService) poke() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// exportFrontMatter is the front matter of an exported post.
+type exportFrontMatter struct {
+	ID               int        `yaml:"id"`
+	Title            string     `yaml:"title"`
+	Slug             string     `yaml:"slug"`
+	Date             time.Time  `yaml:"date"`
+	PublishDate      *time.Time `yaml:"publishDate,omitempty"`
+	LastMod          time.Time  `yaml:"lastmod"`
+	Draft            bool       `yaml:"draft"`
+	Description      string     `yaml:"description,omitempty"`
+	Author           string     `yaml:"author,omitempty"`
+	AuthorEmail      string     `yaml:"author_email,omitempty"`
+	Categories       []string   `yaml:"categories,omitempty"`
+	Tags             []string   `yaml:"tags,omitempty"`
+	ContentFormat    string     `yaml:"content_format,omitempty"`
+	FeaturedImage    string     `yaml:"featured_image,omitempty"`
+	FeaturedImageAlt string     `yaml:"featured_image_alt,omitempty"`
+	MetaTitle        string     `yaml:"meta_title,omitempty"`
+	MetaDescription  string     `yaml:"meta_description,omitempty"`
+	CanonicalURL     string     `yaml:"canonical_url,omitempty"`
+	NoIndex          bool       `yaml:"noindex,omitempty"`
+	Aliases          []string   `yaml:"aliases"`
+}
+
+type exportedAuthor struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Role  string `json:"role"`
+	Posts int    `json:"posts"`
+}
+
+type exportedCategory struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Slug        string `json:"slug"`
+	Description string `json:"description,omitempty"`
+	Parent      string `json:"parent,omitempty"`
+	Posts       int    `json:"posts"`
+}
+
+type exportedTag struct {
+	Name  string `json:"name"`
+	Slug  string `json:"slug"`
+	Posts int    `json:"posts"`
+}
+
+// exportRun is the state of one export while it is written.
+type exportRun struct {
+	s          *ExportService
+	zw         *zip.Writer
+	manifest   *model.ExportManifest
+	categories map[int]*model.Category
+	// usedCategories counts the exported posts in each category
+	usedCategories map[int]int
+	// imported holds where the posts of the current batch lived before they were imported
+	imported   map[int]model.ImportedPost
+	authors    map[int]*exportedAuthor
+	tags       map[string]*exportedTag
+	mediaIDs   map[int64]bool
+	mediaStems map[string]bool
+}
+
+// WriteExport writes a ZIP of the posts that pass filter, trashed ones left
+// out, to w and returns its manifest. An empty filter exports every post and
+// all media; otherwise only the media the exported posts use is included.
+func (s *ExportService) WriteExport(w io.Writer, filter model.ExportFilter) (*model.ExportManifest, error) {
+	categories, err := s.categoryRepo.GetAllCategories()
+	if err != nil {
+		return nil, err
+	}
+	x := &exportRun{
+		s:  s,
+		zw: zip.NewWriter(w),
+		manifest: &model.ExportManifest{
+			Version:   exportVersion,
+			Site:      s.siteURL,
+			CreatedAt: time.Now().UTC(),
+			Filter:    filter,
+			Posts:     []model.ExportedPost{},
+			Media:     []model.ExportedMedia{},
+		},
+		categories:     map[int]*model.Category{},
+		usedCategories: map[int]int{},
+		authors:        map[int]*exportedAuthor{},
+		tags:           map[string]*exportedTag{},
+		mediaIDs:       map[int64]bool{},
+		mediaStems:     map[string]bool{},
+	}
+	for _, c := range categories {
+		x.categories[c.ID] = c
+	}
+
+	postFilter := model.PostFilter{
+		Status:      model.PostStatusAll,
+		AuthorID:    filter.AuthorID,
+		CategoryID:  filter.CategoryID,
+		CreatedFrom: filter.From,
+		CreatedTo:   filter.To,
+	}
+	afterID := 0
+	for {
+		posts, err := s.postRepo.ListFilteredPostsAfterID(postFilter, afterID, exportBatch)
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]int, len(posts))
+		for i, p := range posts {
+			ids[i] = p.ID
+		}
+		if x.imported, err = s.importRepo.ImportedLinks(ids); err != nil {
+			return nil, err
+		}
+		for _, p := range posts {
+			if err := x.writePost(p); err != nil {
+				return nil, fmt.Errorf("post %d: %w", p.ID, err)
+			}
+		}
+		if len(posts) < exportBatch {
+			break
+		}
+		afterID = posts[len(posts)-1].ID
+	}
+
+	for _, write := range []func() error{x.writeMedia, x.writeAuthors, x.writeCategories, x.writeTags} {
+		if err := write(); err != nil {
+			return nil, err
+		}
+	}
+	x.manifest.Counts.Posts = len(x.manifest.Posts)
+	x.manifest.Counts.Media = len(x.manifest.Media)
+	if err := x.writeJSON("manifest.json", x.manifest); err != nil {
+		return nil, err
+	}
+	if err := x.zw.Close(); err != nil {
+		return nil, err
+	}
+	return x.manifest, nil
+}
+
+func (x *exportRun) create(name string, method uint16, modified time.Time) (io.Writer, error) {
+	return x.zw.CreateHeader(&zip.FileHeader{Name: name, Method: method, Modified: modified})
+}
+
+func (x *exportRun) writeJSON(name string, v interface{}) error {
+	fw, err := x.create(name, zip.Deflate, x.manifest.CreatedAt)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(fw)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (x *exportRun) author(userID int) (*exportedAuthor, error) {
+	if a, ok := x.authors[userID]; ok {
+		return a, nil
+	}
+	user, err := x.s.userRepo.GetUserByID(strconv.Itoa(userID))
+	if err != nil {
+		return nil, fmt.Errorf("author %d: %w", userID, err)
+	}
+	a := &exportedAuthor{ID: user.ID, Name: user.Name, Email: user.Email, Role: user.Role}
+	x.authors[userID] = a
+	return a, nil
+}
+
+func (x *exportRun) writePost(p *model.Post) error {
+	author, err := x.author(p.UserID)
+	if err != nil {
+		return err
+	}
+	author.Posts++
+
+	// an imported post keeps the slug and address it had on its old site
+	slug, aliases := utils.Slugify(p.Title), []string{PublicPath(model.SitemapPost, strconv.Itoa(p.ID))}
+	if old, ok := x.imported[p.ID]; ok {
+		if old.Slug != "" {
+			slug = old.Slug
+		}
+		if u, err := url.Parse(old.Link); err == nil && u.Path != "" && u.Path != "/" {
+			aliases = append(aliases, u.Path)
+		}
+	}
+	if slug == "" {
+		slug = "post"
+	}
+	fm := exportFrontMatter{
+		ID:               p.ID,
+		Title:            p.Title,
+		Slug:             slug,
+		Date:             p.CreatedAt.UTC(),
+		PublishDate:      p.PublishedAt,
+		LastMod:          p.UpdatedAt.UTC(),
+		Draft:            p.Status != model.PostStatusPublished,
+		Description:      p.Description,
+		Author:           author.Name,
+		AuthorEmail:      author.Email,
+		Tags:             p.Tags,
+		FeaturedImageAlt: p.FeaturedImageAlt,
+		MetaTitle:        p.MetaTitle,
+		MetaDescription:  p.MetaDescription,
+		CanonicalURL:     p.CanonicalURL,
+		NoIndex:          p.NoIndex,
+		Aliases:          aliases,
+	}
+	if p.ContentFormat != "" && p.ContentFormat != "markdown" {
+		fm.ContentFormat = p.ContentFormat
+	}
+	if c := x.categories[p.CategoryID]; c != nil {
+		fm.Categories = []string{c.Name}
+		x.usedCategories[c.ID]++
+	}
+	for _, tag := range p.Tags {
+		slug := utils.Slugify(tag)
+		if x.tags[slug] == nil {
+			x.tags[slug] = &exportedTag{Name: tag, Slug: slug}
+		}
+		x.tags[slug].Posts++
+	}
+	if p.FeaturedImage != nil {
+		fm.FeaturedImage = p.FeaturedImage.Path
+		x.mediaIDs[int64(p.FeaturedImage.MediaID)] = true
+	}
+
+	body := ""
+	if p.Body != nil {
+		body = *p.Body
+	}
+	for _, m := range mediaRef.FindAllStringSubmatch(body, -1) {
+		x.mediaStems[m[1]] = true
+	}
+
+	front, err := yaml.Marshal(fm)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("content/posts/%d-%s.md", p.ID, firstNonEmpty(utils.Slugify(slug), "post"))
+	fw, err := x.create(name, zip.Deflate, p.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(fw, "---\n"+string(front)+"---\n\n"+strings.TrimSpace(body)+"\n"); err != nil {
+		return err
+	}
+	x.manifest.Posts = append(x.manifest.Posts, model.ExportedPost{ID: p.ID, Path: name, Title: p.Title, Status: p.Status})
+	return nil
+}
+
+// writeMedia copies the media files into the ZIP: all of them for a full
+// export, else only those the exported posts use. Only originals are
+// copied; a static site can make its own resized images.
+func (x *exportRun) writeMedia() error {
+	if !x.manifest.Filter.IsZero() {
+		if len(x.mediaIDs) == 0 && len(x.mediaStems) == 0 {
+			return nil
+		}
+		ids := make([]int64, 0, len(x.mediaIDs))
+		for id := range x.mediaIDs {
+			ids = append(ids, id)
+		}
+		stems := make([]string, 0, len(x.mediaStems))
+		for stem := range x.mediaStems {
+			stems = append(stems, stem)
+		}
+		media, err := x.s.mediaRepo.FindMedia(ids, stems)
+		if err != nil {
+			return err
+		}
+		for _, m := range media {
+			if err := x.copyMedia(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	afterID := 0
+	for {
+		media, err := x.s.mediaRepo.ListMediaAfterID(afterID, exportBatch)
+		if err != nil {
+			return err
+		}
+		for _, m := range media {
+			if err := x.copyMedia(m); err != nil {
+				return err
+			}
+		}
+		if len(media) < exportBatch {
+			return nil
+		}
+		afterID = media[len(media)-1].ID
+	}
+}
+
+// copyMedia copies one media file into the ZIP. Private media goes outside
+// static/ so that building the site does not publish it. Media missing from
+// storage is listed in the manifest as missing.
+func (x *exportRun) copyMedia(m *model.Media) error {
+	entry := model.ExportedMedia{ID: m.ID, Key: m.Key, Filename: m.Filename, ContentType: m.ContentType, Size: m.Size, Private: m.Private}
+	rc, err := x.s.storage.Open(m.Key)
+	if errors.Is(err, storage.ErrNotFound) {
+		entry.Missing = true
+		x.manifest.Media = append(x.manifest.Media, entry)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("media %d: %w", m.ID, err)
+	}
+	defer rc.Close()
+
+	entry.Path = "static" + MediaPathPrefix + m.Key
+	if m.Private {
+		entry.Path = "private" + MediaPathPrefix + m.Key
+	}
+	// images are compressed already
+	method := zip.Deflate
+	if strings.HasPrefix(m.ContentType, "image/") {
+		method = zip.Store
+	}
+	fw, err := x.create(entry.Path, method, m.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(fw, rc); err != nil {
+		return fmt.Errorf("media %d: %w", m.ID, err)
+	}
+	x.manifest.Media = append(x.manifest.Media, entry)
+	return nil
+}
+
+func (x *exportRun) writeAuthors() error {
+	authors := make([]*exportedAuthor, 0, len(x.authors))
+	for _, a := range x.authors {
+		authors = append(authors, a)
+	}
+	sort.Slice(authors, func(i, j int) bool { return authors[i].ID < authors[j].ID })
+	x.manifest.Counts.Authors = len(authors)
+	return x.writeJSON("authors.json", authors)
+}
+
+// writeCategories lists every category for a full export, else the
+// categories of the exported posts and their ancestors.
+func (x *exportRun) writeCategories() error {
+	keep := map[int]bool{}
+	for id := range x.categories {
+		keep[id] = x.manifest.Filter.IsZero()
+	}
+	for id := range x.usedCategories {
+		for c := x.categories[id]; c != nil && !keep[c.ID]; {
+			keep[c.ID] = true
+			if c.ParentID == nil {
+				break
+			}
+			c = x.categories[*c.ParentID]
+		}
+	}
+
+	categories := []exportedCategory{}
+	for id, c := range x.categories {
+		if !keep[id] {
+			continue
+		}
+		entry := exportedCategory{ID: c.ID, Name: c.Name, Slug: c.Slug, Description: c.Description, Posts: x.usedCategories[c.ID]}
+		if c.ParentID != nil && x.categories[*c.ParentID] != nil {
+			entry.Parent = x.categories[*c.ParentID].Slug
+		}
+		categories = append(categories, entry)
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].ID < categories[j].ID })
+	x.manifest.Counts.Categories = len(categories)
+	return x.writeJSON("categories.json", categories)
+}
+
+func (x *exportRun) writeTags() error {
+	tags := make([]*exportedTag, 0, len(x.tags))
+	for _, t := range x.tags {
+		tags = append(tags, t)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Slug < tags[j].Slug })
+	x.manifest.Counts.Tags = len(tags)
+	return x.writeJSON("tags.json", tags)
+}
+
+// runExport writes an export job's ZIP to a temporary file and stores it.
+func (s *ExportService) runExport(job *model.ExportJob) (key string, size int64, counts *model.ExportCounts, err error) {
+	f, err := os.CreateTemp("", "blog-export-*.zip")
+	if err != nil {
+		return "", 0, nil, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	manifest, err := s.WriteExport(f, job.Filter)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	if size, err = f.Seek(0, io.SeekCurrent); err != nil {
+		return "", 0, nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", 0, nil, err
+	}
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", 0, nil, err
+	}
+	key = fmt.Sprintf("exports/%d-%s.zip", job.ID, hex.EncodeToString(b))
+	if err := s.storage.Put(key, f, size, "application/zip"); err != nil {
+		return "", 0, nil, err
+	}
+	return key, size, &manifest.Counts, nil
+}
+
+func (s *ExportService) runNext() bool {
+	job, err := s.exportRepo.ClaimJob(config.ExportLease)
+	if err == sql.ErrNoRows {
+		return false
+	}
+	if err != nil {
+		log.Println("export: claiming jobs:", err)
+		return false
+	}
+	status, errText := model.ExportDone, ""
+	key, size, counts, err := s.runExport(job)
+	if err != nil {
+		status, errText = model.ExportFailed, err.Error()
+		log.Printf("export: job %d: %v", job.ID, err)
+	}
+	if err := s.exportRepo.FinishJob(job.ID, status, key, size, counts, errText); err != nil {
+		log.Printf("export: job %d: %v", job.ID, err)
+		if key != "" {
+			// the job was deleted while it ran
+			s.storage.Delete(key)
+		}
+	}
+	return true
+}
+
+// RunExporter runs queued exports one at a time until stop is closed.
+// Exports cut short by a restart are run again once their lease runs out.
+func (s *ExportService) RunExporter(stop <-chan struct{}) {
+	ticker := time.NewTicker(config.ExportPollInterval)
+	defer ticker.Stop()
+	for {
+		for s.runNext() {
+		}
+		select {
+		case <-ticker.C:
+		case <-s.wake:
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/wikasdude/blog-backend/config"
+	"github.com/wikasdude/blog-backend/feed"
+	"github.com/wikasdude/blog-backend/model"
+	repository "github.com/wikasdude/blog-backend/repositories"
+	"github.com/wikasdude/blog-backend/utils"
+)
+
+var (
+	ErrFeedNotFound = errors.New("feed not found")
+	ErrFeedFormat   = errors.New("feed format must be rss, atom or json")
+)
+
+// Feed kinds; the zero kind is every published post
+const (
+	FeedCategory = model.SitemapCategory
+	FeedTag      = model.SitemapTag
+	FeedAuthor   = model.SitemapAuthor
+)
+
+// FeedOptions selects a feed. Ref is the category or author id, or the tag
+// slug. SiteURL is the public site the links point at; FeedURL is where the
+// feed itself is served.
+type FeedOptions struct {
+	Kind    string
+	Ref     string
+	Full    bool
+	Limit   int
+	SiteURL string
+	FeedURL string
+}
+
+type FeedService struct {
+	postRepo        *repository.PostRepository
+	categoryRepo    *repository.CategoryRepository
+	siteTitle       string
+	siteDescription string
+}
+
+func NewFeedService(postRepo *repository.PostRepository, categoryRepo *repository.CategoryRepository) *FeedService {
+	return &FeedService{
+		postRepo:        postRepo,
+		categoryRepo:    categoryRepo,
+		siteTitle:       config.GetEnv(config.EnvSiteTitle, config.DefaultSiteTitle),
+		siteDescription: config.GetEnv(config.EnvSiteDescription, ""),
+	}
+}
+
+// PostURL is the public page of a post.
+func PostURL(siteURL string, id int) string {
+	return strings.TrimRight(siteURL, "/") + PublicPath(model.SitemapPost, strconv.Itoa(id))
+}
+
+// absoluteURL resolves ref, which may be relative, against siteURL.
+func absoluteURL(siteURL, ref string) string {
+	base, err := url.Parse(strings.TrimRight(siteURL, "/") + "/")
+	if err != nil {
+		return ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
+
+// feedScope narrows the listing to the feed's kind and names the feed.
+func (s *FeedService) feedScope(opts FeedOptions, filter *model.PostFilter) (title, path string, err error) {
+	site := s.siteTitle
+	switch opts.Kind {
+	case "":
+		return site, "", nil
+	case FeedCategory:
+		id, convErr := strconv.Atoi(opts.Ref)
+		if convErr != nil {
+			return "", "", ErrFeedNotFound
+		}
+		category, err := s.categoryRepo.GetCategoryByID(id)
+		if err != nil {
+			return "", "", err
+		}
+		filter.CategoryID = id
+		return site + ": " + category.Name, PublicPath(model.SitemapCategory, category.Slug), nil
+	case FeedTag:
+		slug := utils.Slugify(opts.Ref)
+		name, err := s.postRepo.GetTagNameBySlug(slug)
+		if err != nil {
+			return "", "", err
+		}
+		filter.Tag = slug
+		return site + ": #" + name, PublicPath(model.SitemapTag, slug), nil
+	case FeedAuthor:
+		id, convErr := strconv.Atoi(opts.Ref)
+		if convErr != nil {
+			return "", "", ErrFeedNotFound
+		}
+		names, err := s.postRepo.GetAuthorNames([]int{id})
+		if err != nil {
+			return "", "", err
+		}
+		if _, ok := names[id]; !ok {
+			return "", "", ErrFeedNotFound
+		}
+		filter.AuthorID = id
+		return site + ": posts by " + names[id], PublicPath(model.SitemapAuthor, strconv.Itoa(id)), nil
+	}
+	return "", "", ErrFeedNotFound
+}
+
+// BuildFeed collects the latest published posts for a feed, newest first.
+// The feed's Updated time is the latest UpdatedAt among them.
+func (s *FeedService) BuildFeed(opts FeedOptions) (*feed.Feed, error) {
+	filter := model.PostFilter{Status: model.PostStatusPublished}
+	title, path, err := s.feedScope(opts, &filter)
+	if err == sql.ErrNoRows {
+		return nil, ErrFeedNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = config.DefaultFeedItems
+	}
+	posts, err := s.postRepo.ListPosts(model.PostListParams{Filter: filter, Sort: "published_at", Order: "desc", Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	if len(posts) > limit {
+		posts = posts[:limit]
+	}
+
+	categories, err := s.categoryRepo.GetAllCategories()
+	if err != nil {
+		return nil, err
+	}
+	categoryNames := map[int]string{}
+	for _, c := range categories {
+		categoryNames[c.ID] = c.Name
+	}
+	authorIDs := make([]int, 0, len(posts))
+	for _, p := range posts {
+		authorIDs = append(authorIDs, p.UserID)
+	}
+	authors, err := s.postRepo.GetAuthorNames(authorIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &feed.Feed{
+		Title:       title,
+		Description: s.siteDescription,
+		Link:        strings.TrimRight(opts.SiteURL, "/") + path,
+		FeedURL:     opts.FeedURL,
+		Items:       make([]feed.Item, 0, len(posts)),
+	}
+	if f.Description == "" {
+		f.Description = title
+	}
+	for _, p := range posts {
+		if p.BodyHTML == nil && p.Body != nil {
+			if err := renderBody(p); err != nil {
+				return nil, err
+			}
+		}
+		link := PostURL(opts.SiteURL, p.ID)
+		item := feed.Item{
+			ID:        link,
+			Title:     p.Title,
+			Link:      link,
+			Summary:   p.Excerpt,
+			Author:    authors[p.UserID],
+			Published: p.CreatedAt,
+			Updated:   p.UpdatedAt,
+		}
+		if p.PublishedAt != nil {
+			item.Published = *p.PublishedAt
+		}
+		if item.Summary == "" {
+			item.Summary = p.Description
+		}
+		if opts.Full && p.BodyHTML != nil {
+			item.ContentHTML = *p.BodyHTML
+		}
+		if name := categoryNames[p.CategoryID]; name != "" {
+			item.Categories = append(item.Categories, name)
+		}
+		item.Categories = append(item.Categories, p.Tags...)
+		if p.FirstImage != nil {
+			item.Image = absoluteURL(opts.SiteURL, *p.FirstImage)
+		}
+		if p.UpdatedAt.After(f.Updated) {
+			f.Updated = p.UpdatedAt
+		}
+		f.Items = append(f.Items, item)
+	}
+	return f, nil
+}
+
+// RenderFeed writes f in format and returns it with its content type.
+func (s *FeedService) RenderFeed(format string, f *feed.Feed) ([]byte, string, error) {
+	if !feed.IsValidFormat(format) {
+		return nil, "", ErrFeedFormat
+	}
+	return feed.Write(format, f)
+}
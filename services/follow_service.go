@@ -0,0 +1,131 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"github.com/wikasdude/blog-backend/config"
+	"github.com/wikasdude/blog-backend/events"
+	"github.com/wikasdude/blog-backend/model"
+	repository "github.com/wikasdude/blog-backend/repositories"
+	"github.com/wikasdude/blog-backend/utils"
+)
+
+var (
+	ErrFollowKind           = errors.New("you can follow authors, categories or tags")
+	ErrFollowTargetNotFound = errors.New("nothing to follow with that id or slug")
+	ErrFollowSelf           = errors.New("you cannot follow yourself")
+	ErrAuthorNotFound       = errors.New("author not found")
+	ErrFeedCursor           = errors.New("invalid cursor")
+)
+
+type FollowService struct {
+	followRepo   *repository.FollowRepository
+	postRepo     *repository.PostRepository
+	categoryRepo *repository.CategoryRepository
+	posts        *PostService
+	events       *events.Bus
+}
+
+func NewFollowService(followRepo *repository.FollowRepository, postRepo *repository.PostRepository, categoryRepo *repository.CategoryRepository, posts *PostService, bus *events.Bus) *FollowService {
+	return &FollowService{followRepo: followRepo, postRepo: postRepo, categoryRepo: categoryRepo, posts: posts, events: bus}
+}
+
+// resolveTarget turns an author or category id, or a tag slug, into the id
+// stored for the follow.
+func (s *FollowService) resolveTarget(kind, ref string) (int, error) {
+	var err error
+	id := 0
+	switch kind {
+	case model.FollowAuthor, model.FollowCategory:
+		if id, err = strconv.Atoi(ref); err != nil {
+			return 0, ErrFollowTargetNotFound
+		}
+		if kind == model.FollowAuthor {
+			_, err = s.followRepo.GetAuthorProfile(id)
+		} else {
+			_, err = s.categoryRepo.GetCategoryByID(id)
+		}
+	case model.FollowTag:
+		id, err = s.postRepo.GetTagIDBySlug(utils.Slugify(ref))
+	default:
+		return 0, ErrFollowKind
+	}
+	if err == sql.ErrNoRows {
+		return 0, ErrFollowTargetNotFound
+	}
+	return id, err
+}
+
+// Follow starts following an author, category or tag; following it again is a no-op
+func (s *FollowService) Follow(userID int, kind, ref string) error {
+	targetID, err := s.resolveTarget(kind, ref)
+	if err != nil {
+		return err
+	}
+	if kind == model.FollowAuthor && targetID == userID {
+		return ErrFollowSelf
+	}
+	added, err := s.followRepo.Follow(userID, kind, targetID)
+	if err == nil && added && kind == model.FollowAuthor {
+		s.events.Publish(events.Event{Type: events.UserFollowed, ActorID: userID, UserID: targetID})
+	}
+	return err
+}
+
+// Unfollow stops following; unfollowing something not followed is a no-op
+func (s *FollowService) Unfollow(userID int, kind, ref string) error {
+	targetID, err := s.resolveTarget(kind, ref)
+	if err != nil {
+		return err
+	}
+	return s.followRepo.Unfollow(userID, kind, targetID)
+}
+
+// Follows lists everything a user follows, most recently followed first
+func (s *FollowService) Follows(userID int) ([]*model.Follow, error) {
+	return s.followRepo.ListFollows(userID)
+}
+
+// AuthorProfile returns an author's public profile. When viewerID is not 0
+// the profile says whether the viewer follows the author.
+func (s *FollowService) AuthorProfile(authorID, viewerID int) (*model.AuthorProfile, error) {
+	profile, err := s.followRepo.GetAuthorProfile(authorID)
+	if err == sql.ErrNoRows {
+		return nil, ErrAuthorNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if viewerID != 0 && viewerID != authorID {
+		following, err := s.followRepo.IsFollowing(viewerID, model.FollowAuthor, authorID)
+		if err != nil {
+			return nil, err
+		}
+		profile.FollowedByMe = &following
+	}
+	return profile, nil
+}
+
+// Feed returns one page of the published posts from everything a user
+// follows, newest first. cursor is a token from a previous feed page.
+func (s *FollowService) Feed(userID, limit int, cursor string) (*model.PostPage, error) {
+	params := model.PostListParams{
+		Filter: model.PostFilter{FollowedBy: userID},
+		Sort:   "published_at",
+		Order:  "desc",
+		Limit:  limit,
+	}
+	if cursor != "" {
+		c, err := DecodePostCursor(cursor)
+		if err != nil || c.Sort != params.Sort || c.Order != params.Order {
+			return nil, ErrFeedCursor
+		}
+		params.Cursor = c
+	}
+	if params.Limit < 1 {
+		params.Limit = config.DefaultLimit
+	}
+	return s.posts.ListPosts(params)
+}
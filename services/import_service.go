@@ -0,0 +1,439 @@
+package service
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/wikasdude/blog-backend/config"
+	"github.com/wikasdude/blog-backend/importer"
+	"github.com/wikasdude/blog-backend/model"
+	repository "github.com/wikasdude/blog-backend/repositories"
+	"github.com/wikasdude/blog-backend/utils"
+)
+
+var (
+	ErrImportNotFound = errors.New("import not found")
+	ErrImportTooLarge = errors.New("export file is too large")
+	ErrImportFormat   = errors.New("format must be wxr or markdown")
+	ErrImportSource   = errors.New("source is required for a Markdown ZIP without a Hugo or Jekyll config naming its site")
+)
+
+// ImportService brings posts over from WordPress and Markdown exports.
+// Imports run in the background, one at a time; each source post is
+// remembered by its source and id so that importing the same export again
+// updates the posts it made instead of duplicating them. Imported posts keep
+// their old dates and don't notify followers or fire webhooks.
+type ImportService struct {
+	importRepo   *repository.ImportRepository
+	postRepo     *repository.PostRepository
+	categoryRepo *repository.CategoryRepository
+	userRepo     repository.UserRepository
+	maxBytes     int64
+	wake         chan struct{}
+}
+
+func NewImportService(importRepo *repository.ImportRepository, postRepo *repository.PostRepository, categoryRepo *repository.CategoryRepository, userRepo repository.UserRepository) *ImportService {
+	return &ImportService{
+		importRepo:   importRepo,
+		postRepo:     postRepo,
+		categoryRepo: categoryRepo,
+		userRepo:     userRepo,
+		maxBytes:     int64(config.GetEnvInt(config.EnvImportMaxBytes, config.DefaultImportMaxBytes)),
+		wake:         make(chan struct{}, 1),
+	}
+}
+
+// MaxBytes is the largest export accepted.
+func (s *ImportService) MaxBytes() int64 {
+	return s.maxBytes
+}
+
+// StartImport queues an import of data. An empty format is detected from
+// the data. source names the site a Markdown ZIP comes from, e.g. its old
+// address; it is required when the ZIP does not name one itself, and WXR
+// files always do. A dry run only reports what the import would do.
+func (s *ImportService) StartImport(userID int, format, filename, source string, data []byte, dryRun bool) (*model.ImportJob, error) {
+	if int64(len(data)) > s.maxBytes {
+		return nil, ErrImportTooLarge
+	}
+	if format == "" {
+		var err error
+		if format, err = importer.Detect(data); err != nil {
+			return nil, ErrImportFormat
+		}
+	}
+	if format != importer.FormatWXR && format != importer.FormatMarkdown {
+		return nil, ErrImportFormat
+	}
+	source = strings.TrimRight(strings.TrimSpace(source), "/")
+	if format != importer.FormatMarkdown {
+		source = ""
+	} else if source == "" {
+		// a ZIP that cannot be read fails when the job runs, saying why
+		if site, err := importer.MarkdownZipSite(data); err == nil && site == "" {
+			return nil, ErrImportSource
+		}
+	}
+	job := &model.ImportJob{UserID: userID, Format: format, Filename: filename, Source: source, DryRun: dryRun}
+	if err := s.importRepo.CreateJob(job, data); err != nil {
+		return nil, err
+	}
+	s.poke()
+	return job, nil
+}
+
+func (s *ImportService) GetImport(id int) (*model.ImportJob, error) {
+	job, err := s.importRepo.GetJob(id)
+	if err == sql.ErrNoRows {
+		return nil, ErrImportNotFound
+	}
+	return job, err
+}
+
+// ListImports returns one page of imports, newest first.
+func (s *ImportService) ListImports(page, limit int) (*model.ImportPage, error) {
+	jobs, total, err := s.importRepo.ListJobs(limit, (page-1)*limit)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ImportPage{Imports: jobs, Total: total, Page: page, Limit: limit}, nil
+}
+
+func (s *ImportService) poke() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// importRun is the state of one import while it runs.
+type importRun struct {
+	s      *ImportService
+	job    *model.ImportJob
+	export *importer.Export
+	report *model.ImportReport
+	// categories maps slugs to ids; a dry run maps categories it would create to 0
+	categories map[string]int
+	exported   map[string]importer.Category
+	authors    map[string]int
+	seen       map[string]bool
+}
+
+// runImport runs an import job against the export in data.
+func (s *ImportService) runImport(job *model.ImportJob, data []byte) (*model.ImportReport, error) {
+	export, err := importer.Parse(job.Format, data, job.Source)
+	if err != nil {
+		return nil, err
+	}
+	run := &importRun{
+		s:      s,
+		job:    job,
+		export: export,
+		report: &model.ImportReport{
+			Source:            export.Source,
+			DryRun:            job.DryRun,
+			CategoriesCreated: []string{},
+			UnmatchedAuthors:  []string{},
+			Posts:             []model.ImportedPost{},
+			Warnings:          append([]string{}, export.Warnings...),
+		},
+		categories: map[string]int{},
+		exported:   map[string]importer.Category{},
+		authors:    map[string]int{},
+		seen:       map[string]bool{},
+	}
+	for _, c := range export.Categories {
+		if c.Slug != "" {
+			run.exported[c.Slug] = c
+		}
+	}
+	for _, p := range export.Posts {
+		entry := run.importPost(p)
+		switch entry.Action {
+		case model.ImportCreate:
+			run.report.Created++
+		case model.ImportUpdate:
+			run.report.Updated++
+		case model.ImportUnchanged:
+			run.report.Unchanged++
+		case model.ImportSkip:
+			run.report.Skipped++
+		case model.ImportFail:
+			run.report.Failed++
+		}
+		run.report.Posts = append(run.report.Posts, entry)
+	}
+	run.report.Total = len(export.Posts)
+	return run.report, nil
+}
+
+// author resolves a source author to a user by email. Authors without a
+// matching user are credited to whoever started the import.
+func (r *importRun) author(ref string) (int, error) {
+	if id, ok := r.authors[ref]; ok {
+		return id, nil
+	}
+	email, label := "", ref
+	if strings.Contains(ref, "@") {
+		email = ref
+	}
+	for _, a := range r.export.Authors {
+		if a.Login == ref && a.Email != "" {
+			email, label = a.Email, a.Login+" <"+a.Email+">"
+			break
+		}
+	}
+	id, matched := r.job.UserID, false
+	if email != "" {
+		user, err := r.s.userRepo.GetUserByEmail(email)
+		if err == nil {
+			id, matched = user.ID, true
+		} else if err != sql.ErrNoRows {
+			return 0, err
+		}
+	}
+	if !matched && ref != "" {
+		r.report.UnmatchedAuthors = append(r.report.UnmatchedAuthors, label)
+	}
+	r.authors[ref] = id
+	return id, nil
+}
+
+// category resolves a category by slug, creating it, and the parents the
+// export gives it, when it doesn't exist yet.
+func (r *importRun) category(c importer.Category) (int, error) {
+	slug := c.Slug
+	if slug == "" {
+		slug = utils.Slugify(c.Name)
+	}
+	if slug == "" {
+		c = importer.Category{Name: config.DefaultImportCategory}
+		slug = utils.Slugify(c.Name)
+	}
+	if id, ok := r.categories[slug]; ok {
+		return id, nil
+	}
+	existing, err := r.s.categoryRepo.GetCategoryBySlug(slug)
+	if err == nil {
+		r.categories[slug] = existing.ID
+		return existing.ID, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	if full, ok := r.exported[slug]; ok {
+		c = full
+	}
+	category := &model.Category{Name: firstNonEmpty(c.Name, slug), Slug: slug, Description: c.Description}
+	// guard against parent loops in the export while the parent is resolved
+	r.categories[slug] = 0
+	if c.Parent != "" && c.Parent != slug {
+		parentID, err := r.category(importer.Category{Slug: c.Parent})
+		if err != nil {
+			return 0, err
+		}
+		if parentID != 0 {
+			category.ParentID = &parentID
+		}
+	}
+	r.report.CategoriesCreated = append(r.report.CategoriesCreated, slug)
+	if r.job.DryRun {
+		return 0, nil
+	}
+	taken, err := r.s.categoryRepo.IsNameTaken(category.Name, 0)
+	if err != nil {
+		return 0, err
+	}
+	if taken {
+		category.Name += " (" + slug + ")"
+	}
+	if err := r.s.categoryRepo.CreateCategory(category); err != nil {
+		return 0, err
+	}
+	r.categories[slug] = category.ID
+	return category.ID, nil
+}
+
+// importChecksum fingerprints a source post and who and where it goes to,
+// so a post that hasn't changed is left alone on the next run.
+func importChecksum(p importer.Post, post *model.Post) string {
+	data, _ := json.Marshal([]interface{}{p, post.UserID, post.CategoryID})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (r *importRun) importPost(p importer.Post) model.ImportedPost {
+	entry := model.ImportedPost{SourceID: p.SourceID, Title: p.Title, Slug: p.Slug, Link: p.Link}
+	switch {
+	case p.Skip != "":
+		entry.Action, entry.Reason = model.ImportSkip, p.Skip
+		return entry
+	case r.seen[p.SourceID]:
+		entry.Action, entry.Reason = model.ImportSkip, "another post in the export has the same source id"
+		return entry
+	}
+	r.seen[p.SourceID] = true
+
+	post, err := r.buildPost(p, &entry)
+	if err == nil {
+		err = r.save(p, post, &entry)
+	}
+	if err != nil {
+		entry.Action, entry.Reason = model.ImportFail, err.Error()
+	}
+	return entry
+}
+
+// buildPost maps a source post onto a post. Only its first category can be
+// kept; any others become tags. Posts are addressed by id, so the source
+// slug and link are recorded with the import item instead.
+func (r *importRun) buildPost(p importer.Post, entry *model.ImportedPost) (*model.Post, error) {
+	userID, err := r.author(p.Author)
+	if err != nil {
+		return nil, err
+	}
+	category := importer.Category{Slug: utils.Slugify(config.DefaultImportCategory), Name: config.DefaultImportCategory}
+	tags := append([]string{}, p.Tags...)
+	for i, c := range p.Categories {
+		if i == 0 {
+			category = c
+			continue
+		}
+		tags = append(tags, c.Name)
+		entry.Notes = append(entry.Notes, fmt.Sprintf("category %q was added as a tag", c.Name))
+	}
+	categoryID, err := r.category(category)
+	if err != nil {
+		return nil, err
+	}
+
+	body := p.Body
+	post := &model.Post{
+		UserID:        userID,
+		Title:         firstNonEmpty(p.Title, "Untitled"),
+		Description:   p.Description,
+		Body:          &body,
+		ContentFormat: p.Format,
+		CategoryID:    categoryID,
+		Status:        p.Status,
+		Tags:          tags,
+		CreatedAt:     p.Published,
+		UpdatedAt:     p.Modified,
+	}
+	if post.CreatedAt.IsZero() {
+		post.CreatedAt = time.Now().UTC()
+		entry.Notes = append(entry.Notes, "no date; imported with today's")
+	}
+	if post.UpdatedAt.Before(post.CreatedAt) {
+		post.UpdatedAt = post.CreatedAt
+	}
+	if post.Status == model.PostStatusPublished {
+		published := post.CreatedAt
+		post.PublishedAt = &published
+	}
+	if err := renderBody(post); err != nil {
+		return nil, err
+	}
+	if post.Description == "" {
+		post.Description = firstNonEmpty(post.Excerpt, post.Title)
+	}
+	return post, nil
+}
+
+// save creates or updates the post made of p, unless nothing has changed
+// since the last import. A dry run only decides which it would be.
+func (r *importRun) save(p importer.Post, post *model.Post, entry *model.ImportedPost) error {
+	source := r.export.Source
+	checksum := importChecksum(p, post)
+	postID, previous, err := r.s.importRepo.ImportedPost(source, p.SourceID)
+	switch {
+	case err == sql.ErrNoRows:
+		entry.Action = model.ImportCreate
+	case err != nil:
+		return err
+	case previous == checksum:
+		entry.Action, entry.PostID = model.ImportUnchanged, postID
+		return nil
+	default:
+		entry.Action, entry.PostID, post.ID = model.ImportUpdate, postID, postID
+	}
+	if r.job.DryRun {
+		return nil
+	}
+
+	if post.ID != 0 && p.Published.IsZero() {
+		// keep the date the post got when it was first imported
+		existing, err := r.s.postRepo.GetPostByID(post.ID)
+		if err == nil {
+			post.CreatedAt, post.UpdatedAt = existing.CreatedAt, time.Now().UTC()
+			if post.PublishedAt != nil {
+				post.PublishedAt = &existing.CreatedAt
+				if existing.PublishedAt != nil {
+					post.PublishedAt = existing.PublishedAt
+				}
+			}
+		} else if err != sql.ErrNoRows {
+			return err
+		}
+	}
+	err = r.s.postRepo.ImportPost(post)
+	if err == sql.ErrNoRows {
+		entry.Action, entry.Reason = model.ImportSkip, "the post imported earlier is in the trash"
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := r.s.postRepo.SetPostTags(post.ID, post.Tags); err != nil {
+		return err
+	}
+	entry.PostID = post.ID
+	return r.s.importRepo.RecordImportedPost(source, *entry, checksum)
+}
+
+func (s *ImportService) runNext() bool {
+	job, data, err := s.importRepo.ClaimJob(config.ImportLease)
+	if err == sql.ErrNoRows {
+		return false
+	}
+	if err != nil {
+		log.Println("import: claiming jobs:", err)
+		return false
+	}
+	status, errText := model.ImportDone, ""
+	report, err := s.runImport(job, data)
+	if err != nil {
+		status, errText = model.ImportFailed, err.Error()
+		log.Printf("import: job %d: %v", job.ID, err)
+	}
+	if err := s.importRepo.FinishJob(job.ID, status, report, errText); err != nil {
+		log.Printf("import: job %d: %v", job.ID, err)
+	}
+	return true
+}
+
+// RunImporter runs queued imports one at a time until stop is closed.
+// Imports cut short by a restart are run again once their lease runs out,
+// which is safe since imports can be repeated.
+func (s *ImportService) RunImporter(stop <-chan struct{}) {
+	ticker := time.NewTicker(config.ImportPollInterval)
+	defer ticker.Stop()
+	for {
+		for s.runNext() {
+		}
+		select {
+		case <-ticker.C:
+		case <-s.wake:
+		case <-stop:
+			return
+		}
+	}
+}
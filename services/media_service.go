@@ -0,0 +1,599 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wikasdude/blog-backend/config"
+	"github.com/wikasdude/blog-backend/imaging"
+	"github.com/wikasdude/blog-backend/model"
+	repository "github.com/wikasdude/blog-backend/repositories"
+	"github.com/wikasdude/blog-backend/storage"
+)
+
+var (
+	ErrMediaNotFound  = errors.New("media not found")
+	ErrMediaTooLarge  = errors.New("file is too large")
+	ErrMediaType      = errors.New("file type is not allowed")
+	ErrMediaForbidden = errors.New("you are not allowed to access this media")
+	ErrMediaInUse     = errors.New("media is used by posts")
+	ErrMediaSignature = errors.New("link is invalid or has expired")
+)
+
+// MediaPathPrefix is where media files are served.
+const MediaPathPrefix = "/media/"
+
+// imageFormats maps the image types uploads are inspected as to their format.
+var imageFormats = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+}
+
+// MediaService stores uploads in a storage backend and keeps their metadata.
+// Public media is served at a permanent URL; private media only through
+// URLs signed with the service's key, which stop working when they expire.
+//
+// Images are stored without their metadata and queued for processing; call
+// RunProcessor to make their variants and placeholders.
+type MediaService struct {
+	mediaRepo *repository.MediaRepository
+	storage   storage.Storage
+	maxBytes  int64
+	allowed   map[string]bool
+	signKey   []byte
+
+	maxPixels int
+	widths    []int
+	formats   []string
+	quality   int
+	wake      chan struct{}
+}
+
+// NewMediaService takes the key signed URLs are made with; with no key, a
+// random one is used, and signed URLs stop working when the server restarts.
+func NewMediaService(mediaRepo *repository.MediaRepository, store storage.Storage, signKey string) *MediaService {
+	s := &MediaService{
+		mediaRepo: mediaRepo,
+		storage:   store,
+		maxBytes:  int64(config.GetEnvInt(config.EnvMediaMaxBytes, config.DefaultMediaMaxBytes)),
+		allowed:   map[string]bool{},
+		signKey:   []byte(signKey),
+		maxPixels: config.GetEnvInt(config.EnvMediaMaxPixels, config.DefaultMediaMaxPixels),
+		quality:   max(1, min(100, config.GetEnvInt(config.EnvMediaImageQuality, config.DefaultMediaImageQuality))),
+		wake:      make(chan struct{}, 1),
+	}
+	for _, t := range splitList(config.GetEnv(config.EnvMediaAllowedTypes, config.DefaultMediaAllowedTypes)) {
+		s.allowed[t] = true
+	}
+	for _, w := range splitList(config.GetEnv(config.EnvMediaVariantWidths, config.DefaultMediaVariantWidths)) {
+		if width, err := strconv.Atoi(w); err == nil && width > 0 {
+			s.widths = append(s.widths, width)
+		} else {
+			log.Printf("media: ignoring variant width %q", w)
+		}
+	}
+	sort.Ints(s.widths)
+	for _, f := range splitList(config.GetEnv(config.EnvMediaVariantFormats, config.DefaultMediaVariantFormats)) {
+		if _, ok := imaging.LookupEncoder(f); ok || f == "original" {
+			s.formats = append(s.formats, f)
+		} else {
+			log.Printf("media: no encoder for variant format %q", f)
+		}
+	}
+	if len(s.signKey) == 0 {
+		log.Printf("media: %s is not set; signed media URLs will not survive a restart", config.EnvMediaSigningKey)
+		s.signKey = make([]byte, 32)
+		if _, err := rand.Read(s.signKey); err != nil {
+			log.Fatal("media: ", err)
+		}
+	}
+	return s
+}
+
+// MaxBytes is the largest upload accepted.
+func (s *MediaService) MaxBytes() int64 {
+	return s.maxBytes
+}
+
+// withURLs fills in where public media and its variants are served on the
+// site at siteURL.
+func withURLs(m *model.Media, siteURL string) *model.Media {
+	m.URL, m.SrcSet = "", ""
+	if m.Private {
+		return m
+	}
+	base := strings.TrimRight(siteURL, "/") + MediaPathPrefix
+	m.URL = base + m.Key
+	for i := range m.Variants {
+		m.Variants[i].URL = base + m.Variants[i].Key
+	}
+	m.SrcSet = SrcSet(m, m.URL, m.Variants)
+	return m
+}
+
+// SrcSet builds an <img srcset> from the variants sharing the original's
+// type, which every browser that shows the original can show, and the
+// original itself at url. Without such variants it uses the first format's.
+func SrcSet(m *model.Media, url string, variants []model.MediaVariant) string {
+	contentType := m.ContentType
+	if len(variants) > 0 {
+		found := false
+		for _, v := range variants {
+			found = found || v.ContentType == contentType
+		}
+		if !found {
+			contentType = variants[0].ContentType
+		}
+	}
+	var candidates []string
+	for _, v := range variants {
+		if v.ContentType == contentType && v.URL != "" {
+			candidates = append(candidates, v.URL+" "+strconv.Itoa(v.Width)+"w")
+		}
+	}
+	if contentType == m.ContentType && url != "" && m.Width > 0 {
+		candidates = append(candidates, url+" "+strconv.Itoa(m.Width)+"w")
+	}
+	return strings.Join(candidates, ", ")
+}
+
+// loadVariants attaches the variants of each of media.
+func (s *MediaService) loadVariants(media ...*model.Media) error {
+	ids := make([]int, len(media))
+	for i, m := range media {
+		ids[i] = m.ID
+	}
+	variants, err := s.mediaRepo.Variants(ids)
+	if err != nil {
+		return err
+	}
+	for _, m := range media {
+		m.Variants = variants[m.ID]
+	}
+	return nil
+}
+
+// mediaStem is key without its extension; variants are stored below it.
+func mediaStem(key string) string {
+	return strings.TrimSuffix(key, path.Ext(key))
+}
+
+func variantKey(key string, width int, ext string) string {
+	return mediaStem(key) + "/" + strconv.Itoa(width) + "w" + ext
+}
+
+// newMediaKey picks a fresh storage key under the owner's prefix.
+func newMediaKey(userID int, contentType string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	ext := ""
+	if exts, _ := mime.ExtensionsByType(contentType); len(exts) > 0 {
+		ext = exts[0]
+		// prefer the extensions people expect over the first registered one
+		for _, e := range exts {
+			if e == ".jpg" || e == ".png" || e == ".gif" || e == ".webp" || e == ".pdf" {
+				ext = e
+				break
+			}
+		}
+	}
+	return strconv.Itoa(userID) + "/" + hex.EncodeToString(b) + ext, nil
+}
+
+// Upload stores a file after checking its size and, by sniffing its first
+// bytes rather than trusting the client, its type. Images are stripped of
+// their metadata first and queued for processing.
+func (s *MediaService) Upload(userID int, filename string, file io.ReadSeeker, size int64, private bool, siteURL string) (*model.Media, error) {
+	if size > s.maxBytes {
+		return nil, fmt.Errorf("%w: the limit is %d bytes", ErrMediaTooLarge, s.maxBytes)
+	}
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	contentType, _, _ := strings.Cut(http.DetectContentType(head[:n]), ";")
+	if !s.allowed[contentType] {
+		return nil, fmt.Errorf("%w: %s", ErrMediaType, contentType)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	m := &model.Media{
+		UserID:      userID,
+		Filename:    filepath.Base(filename),
+		ContentType: contentType,
+		Size:        size,
+		Private:     private,
+		Status:      model.MediaReady,
+	}
+	var body io.Reader = file
+	if format, ok := imageFormats[contentType]; ok {
+		data, err := s.prepareImage(m, format, file)
+		if err != nil {
+			return nil, err
+		}
+		body, m.Size = bytes.NewReader(data), int64(len(data))
+	}
+
+	if m.Key, err = newMediaKey(userID, contentType); err != nil {
+		return nil, err
+	}
+	if err := s.storage.Put(m.Key, body, m.Size, contentType); err != nil {
+		return nil, err
+	}
+	if err := s.mediaRepo.CreateMedia(m); err != nil {
+		s.storage.Delete(m.Key)
+		return nil, err
+	}
+	if m.Status == model.MediaPending {
+		s.poke()
+	}
+	return withURLs(m, siteURL), nil
+}
+
+// prepareImage reads an uploaded image, records its size on m and returns it
+// without metadata, left pending for the processor.
+func (s *MediaService) prepareImage(m *model.Media, format string, file io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	info, err := imaging.Inspect(data)
+	if err != nil || info.Format != format {
+		return nil, fmt.Errorf("%w: the image could not be read", ErrMediaType)
+	}
+	if info.Width*info.Height > s.maxPixels {
+		return nil, fmt.Errorf("%w: images may have at most %d pixels", ErrMediaTooLarge, s.maxPixels)
+	}
+	if data, err = imaging.StripMetadata(data); err != nil {
+		return nil, fmt.Errorf("%w: the image could not be read", ErrMediaType)
+	}
+	m.Width, m.Height = info.DisplaySize()
+	m.Status = model.MediaPending
+	return data, nil
+}
+
+// GetMedia returns media the caller may see: their own, public media, or
+// anything for an admin.
+func (s *MediaService) GetMedia(id, userID int, role, siteURL string) (*model.Media, error) {
+	m, err := s.mediaRepo.GetMediaByID(id)
+	if err == sql.ErrNoRows {
+		return nil, ErrMediaNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if m.Private && m.UserID != userID && role != "admin" {
+		return nil, ErrMediaNotFound
+	}
+	if err := s.loadVariants(m); err != nil {
+		return nil, err
+	}
+	return withURLs(m, siteURL), nil
+}
+
+// ListMedia lists a user's uploads, newest first.
+func (s *MediaService) ListMedia(userID, page, limit int, siteURL string) (*model.MediaPage, error) {
+	media, total, err := s.mediaRepo.ListUserMedia(userID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadVariants(media...); err != nil {
+		return nil, err
+	}
+	for _, m := range media {
+		withURLs(m, siteURL)
+	}
+	return &model.MediaPage{Media: media, Total: total, Page: page, Limit: limit}, nil
+}
+
+// DeleteMedia removes media, its variants and their files. Media still
+// featured by or linked from a post, including posts in the trash, cannot be
+// deleted.
+func (s *MediaService) DeleteMedia(id, userID int, role string) error {
+	m, err := s.GetMedia(id, userID, role, "")
+	if err != nil {
+		return err
+	}
+	if m.UserID != userID && role != "admin" {
+		return ErrMediaForbidden
+	}
+	// the stem matches links to the original and to every variant
+	posts, err := s.mediaRepo.PostsReferencing(m.ID, MediaPathPrefix+mediaStem(m.Key))
+	if err != nil {
+		return err
+	}
+	if len(posts) > 0 {
+		ids := make([]string, len(posts))
+		for i, id := range posts {
+			ids[i] = strconv.Itoa(id)
+		}
+		return fmt.Errorf("%w: %s", ErrMediaInUse, strings.Join(ids, ", "))
+	}
+	if err := s.mediaRepo.DeleteMedia(m.ID); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrMediaNotFound
+		}
+		return err
+	}
+	s.deleteFiles(m.Key, m.Variants)
+	return nil
+}
+
+// deleteFiles removes stored files, logging failures; the rows that pointed
+// at them are already gone.
+func (s *MediaService) deleteFiles(key string, variants []model.MediaVariant) {
+	keys := []string{}
+	if key != "" {
+		keys = append(keys, key)
+	}
+	for _, v := range variants {
+		keys = append(keys, v.Key)
+	}
+	for _, k := range keys {
+		if err := s.storage.Delete(k); err != nil {
+			log.Printf("media: deleting %s: %v", k, err)
+		}
+	}
+}
+
+func (s *MediaService) signature(key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.signKey)
+	mac.Write([]byte(key + "\n" + strconv.FormatInt(expires, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// SignedURL returns links to media and its variants that work until ttl has
+// passed. Only the owner or an admin may create them.
+func (s *MediaService) SignedURL(id, userID int, role string, ttl time.Duration, siteURL string) (*model.SignedMediaURL, error) {
+	m, err := s.GetMedia(id, userID, role, siteURL)
+	if err != nil {
+		return nil, err
+	}
+	if m.UserID != userID && role != "admin" {
+		return nil, ErrMediaForbidden
+	}
+	expiresAt := time.Now().Add(ttl).Truncate(time.Second)
+	expires := expiresAt.Unix()
+	base := strings.TrimRight(siteURL, "/") + MediaPathPrefix
+	signedURL := func(key string) string {
+		query := url.Values{"expires": {strconv.FormatInt(expires, 10)}, "signature": {s.signature(key, expires)}}
+		return base + key + "?" + query.Encode()
+	}
+
+	signed := &model.SignedMediaURL{URL: signedURL(m.Key), Variants: m.Variants, ExpiresAt: expiresAt}
+	for i := range signed.Variants {
+		signed.Variants[i].URL = signedURL(signed.Variants[i].Key)
+	}
+	signed.SrcSet = SrcSet(m, signed.URL, signed.Variants)
+	return signed, nil
+}
+
+// MediaFile is a stored file of an upload: the original or a variant.
+type MediaFile struct {
+	ContentType string
+	Size        int64
+	Private     bool
+}
+
+// Open returns the file stored under key, the original of some media or one
+// of its variants, and its contents. Private media needs a signature and
+// expiry from SignedURL that has not yet passed.
+func (s *MediaService) Open(key, expires, signature string) (*MediaFile, io.ReadCloser, error) {
+	var file *MediaFile
+	m, err := s.mediaRepo.GetMediaByKey(key)
+	if err == nil {
+		file = &MediaFile{ContentType: m.ContentType, Size: m.Size, Private: m.Private}
+	} else if err == sql.ErrNoRows {
+		var v *model.MediaVariant
+		m, v, err = s.mediaRepo.GetVariantByKey(key)
+		if err == nil {
+			file = &MediaFile{ContentType: v.ContentType, Size: v.Size, Private: m.Private}
+		}
+	}
+	if err == sql.ErrNoRows {
+		return nil, nil, ErrMediaNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if m.Private {
+		at, err := strconv.ParseInt(expires, 10, 64)
+		if err != nil || time.Now().Unix() > at || !hmac.Equal([]byte(signature), []byte(s.signature(key, at))) {
+			return nil, nil, ErrMediaSignature
+		}
+	}
+	body, err := s.storage.Open(key)
+	if err == storage.ErrNotFound {
+		return nil, nil, ErrMediaNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, body, nil
+}
+
+// poke wakes an idle processor worker without waiting for it.
+func (s *MediaService) poke() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// variantFormats resolves the configured variant formats for an image in
+// format, dropping duplicates. "original" falls back to JPEG or PNG for
+// formats nothing is registered to encode, such as WebP.
+func (s *MediaService) variantFormats(format string, opaque bool) []string {
+	var formats []string
+	seen := map[string]bool{}
+	for _, f := range s.formats {
+		if f == "original" {
+			f = imaging.Fallback(format, opaque)
+		}
+		if !seen[f] {
+			seen[f] = true
+			formats = append(formats, f)
+		}
+	}
+	return formats
+}
+
+// writeVariants stores a copy of src, stored with info's orientation, at
+// each configured width narrower than the image, in each variant format.
+func (s *MediaService) writeVariants(m *model.Media, src *image.RGBA, info imaging.Info) ([]model.MediaVariant, error) {
+	var variants []model.MediaVariant
+	for _, width := range s.widths {
+		if width >= m.Width {
+			break
+		}
+		height := max(1, int(math.Round(float64(m.Height)*float64(width)/float64(m.Width))))
+		rw, rh := width, height
+		if info.Transposed() {
+			rw, rh = height, width
+		}
+		resized := imaging.Orient(imaging.Resize(src, rw, rh), info.Orientation)
+		for _, format := range s.variantFormats(info.Format, src.Opaque()) {
+			enc, ok := imaging.LookupEncoder(format)
+			if !ok {
+				continue
+			}
+			var buf bytes.Buffer
+			if err := enc.Encode(&buf, resized, s.quality); err != nil {
+				s.deleteFiles("", variants)
+				return nil, err
+			}
+			v := model.MediaVariant{
+				Key:         variantKey(m.Key, width, enc.Ext),
+				Format:      format,
+				ContentType: enc.ContentType,
+				Width:       width,
+				Height:      height,
+				Size:        int64(buf.Len()),
+			}
+			if err := s.storage.Put(v.Key, &buf, v.Size, v.ContentType); err != nil {
+				s.deleteFiles("", variants)
+				return nil, err
+			}
+			variants = append(variants, v)
+		}
+	}
+	return variants, nil
+}
+
+// process makes the variants and blurhash of a claimed image. Animated GIFs
+// would lose their animation when resized, so GIFs only get a blurhash.
+func (s *MediaService) process(m *model.Media) error {
+	rc, err := s.storage.Open(m.Key)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return err
+	}
+	info, err := imaging.Inspect(data)
+	if err != nil {
+		return err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	src := imaging.ToRGBA(img)
+
+	var variants []model.MediaVariant
+	if info.Format != "gif" {
+		if variants, err = s.writeVariants(m, src, info); err != nil {
+			return err
+		}
+	}
+	scale := float64(config.BlurHashSize) / float64(max(info.Width, info.Height))
+	thumb := imaging.Resize(src, int(math.Ceil(float64(info.Width)*scale)), int(math.Ceil(float64(info.Height)*scale)))
+	blurHash := imaging.BlurHash(imaging.Orient(thumb, info.Orientation), config.BlurHashX, config.BlurHashY)
+
+	if err := s.mediaRepo.SaveProcessed(m.ID, blurHash, variants); err != nil {
+		s.deleteFiles("", variants)
+		if err == sql.ErrNoRows { // deleted while we worked
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// processNext processes one queued image, reporting whether there was one.
+func (s *MediaService) processNext() bool {
+	m, err := s.mediaRepo.ClaimPendingMedia(config.MediaProcessLease)
+	if err == sql.ErrNoRows {
+		return false
+	}
+	if err != nil {
+		log.Println("media: claiming images:", err)
+		return false
+	}
+	// there may be more; let another idle worker look
+	s.poke()
+	if err := s.process(m); err != nil {
+		log.Printf("media: processing %d: %v", m.ID, err)
+		if err := s.mediaRepo.MarkMediaFailed(m.ID, err.Error()); err != nil && err != sql.ErrNoRows {
+			log.Printf("media: processing %d: %v", m.ID, err)
+		}
+	}
+	return true
+}
+
+// RunProcessor processes queued images with the given number of workers
+// until stop is closed. Decoding a photo takes tens of megabytes, so the
+// pool bounds memory as well as CPU. Images left pending or half done by a
+// restart are picked up again.
+func (s *MediaService) RunProcessor(workers int, stop <-chan struct{}) {
+	var wg sync.WaitGroup
+	for i := 0; i < max(1, workers); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := time.NewTicker(config.MediaPollInterval)
+			defer ticker.Stop()
+			for {
+				for s.processNext() {
+				}
+				select {
+				case <-ticker.C:
+				case <-s.wake:
+				case <-stop:
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
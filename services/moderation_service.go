@@ -0,0 +1,229 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/wikasdude/blog-backend/config"
+	"github.com/wikasdude/blog-backend/events"
+	"github.com/wikasdude/blog-backend/model"
+	repository "github.com/wikasdude/blog-backend/repositories"
+	"github.com/wikasdude/blog-backend/spam"
+)
+
+// Moderation actions
+const (
+	ModerationApprove = "approve"
+	ModerationReject  = "reject"
+	ModerationSpam    = "spam"
+)
+
+var (
+	ErrModerationAction = errors.New("action must be approve, reject or spam")
+	ErrModerationStatus = errors.New("status must be pending, spam or rejected")
+)
+
+var moderationStatuses = map[string]string{
+	ModerationApprove: model.CommentStatusApproved,
+	ModerationReject:  model.CommentStatusRejected,
+	ModerationSpam:    model.CommentStatusSpam,
+}
+
+// ModerationService screens new comments and applies moderator decisions.
+// Classifier can be replaced with any spam.Classifier; when it also
+// implements spam.Trainer it learns from admins' approve and mark-spam
+// decisions.
+type ModerationService struct {
+	Classifier       spam.Classifier
+	PremoderateFirst bool
+
+	commentRepo *repository.CommentRepository
+	postRepo    *repository.PostRepository
+	spamRepo    *repository.SpamRepository
+	events      *events.Bus
+}
+
+// NewModerationService builds the default classifier from the blocked-word
+// and link rules in the environment and a Bayes filter loaded with the
+// stored training data.
+func NewModerationService(commentRepo *repository.CommentRepository, postRepo *repository.PostRepository, spamRepo *repository.SpamRepository, bus *events.Bus) (*ModerationService, error) {
+	tokens, spamDocs, hamDocs, err := spamRepo.LoadCorpus()
+	if err != nil {
+		return nil, err
+	}
+
+	var blocked []string
+	if words := config.GetEnv(config.EnvCommentBlockedWords, ""); words != "" {
+		blocked = strings.Split(words, ",")
+	}
+	rules := spam.Rules{
+		BlockedWords: blocked,
+		MaxLinks:     config.GetEnvInt(config.EnvCommentMaxLinks, config.DefaultCommentLinks),
+	}
+
+	return &ModerationService{
+		Classifier:       spam.Chain{rules, spam.NewBayes(tokens, spamDocs, hamDocs)},
+		PremoderateFirst: config.GetEnvBool(config.EnvCommentPremoderateFirst, true),
+		commentRepo:      commentRepo,
+		postRepo:         postRepo,
+		spamRepo:         spamRepo,
+		events:           bus,
+	}, nil
+}
+
+// Screen sets the moderation status of a new comment. Admins' comments are
+// always approved; otherwise spam verdicts are filed as spam, suspect ones
+// are held, and so are first comments when pre-moderation is on.
+func (s *ModerationService) Screen(comment *model.Comment, role string) error {
+	comment.Status = model.CommentStatusApproved
+	if role == "admin" {
+		return nil
+	}
+
+	verdict := s.classify(comment)
+	switch verdict.Level {
+	case spam.Spam:
+		comment.Status = model.CommentStatusSpam
+		return nil
+	case spam.Suspect:
+		comment.Status = model.CommentStatusPending
+		return nil
+	}
+
+	if s.PremoderateFirst {
+		approved, err := s.commentRepo.HasApprovedComment(comment.UserID)
+		if err != nil {
+			return err
+		}
+		if !approved {
+			comment.Status = model.CommentStatusPending
+			comment.Reasons = append(comment.Reasons, "first comment")
+		}
+	}
+	return nil
+}
+
+// Rescreen classifies an edited comment again and holds or files it as spam
+// when the new body no longer passes.
+func (s *ModerationService) Rescreen(comment *model.Comment, role string) error {
+	if role == "admin" || comment.Status != model.CommentStatusApproved {
+		return nil
+	}
+	status := model.CommentStatusApproved
+	switch s.classify(comment).Level {
+	case spam.Spam:
+		status = model.CommentStatusSpam
+	case spam.Suspect:
+		status = model.CommentStatusPending
+	default:
+		return nil
+	}
+	if _, err := s.commentRepo.SetCommentStatus(comment.ID, status, nil); err != nil {
+		return err
+	}
+	comment.Status = status
+	return nil
+}
+
+func (s *ModerationService) classify(comment *model.Comment) spam.Verdict {
+	verdict := s.Classifier.Classify(spam.Input{Body: comment.Body, AuthorID: comment.UserID, PostID: comment.PostID})
+	if verdict.Score > 0 {
+		score := verdict.Score
+		comment.SpamScore = &score
+	}
+	comment.Reasons = verdict.Reasons
+	return verdict
+}
+
+// Queue lists comments awaiting a decision (or already filed as spam or
+// rejected). Admins see every post's comments, other users those on their own posts.
+func (s *ModerationService) Queue(status string, userID int, role string, page, limit int) (*model.ModerationPage, error) {
+	if status != model.CommentStatusPending && status != model.CommentStatusSpam && status != model.CommentStatusRejected {
+		return nil, ErrModerationStatus
+	}
+	postAuthorID := userID
+	if role == "admin" {
+		postAuthorID = 0
+	}
+	comments, total, err := s.commentRepo.ListByStatus(status, postAuthorID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ModerationPage{Comments: comments, Total: total, Page: page, Limit: limit}, nil
+}
+
+// Moderate applies a moderator decision to a comment. Admins may moderate
+// any comment and post authors those on their own posts. When an admin
+// approves a comment or marks it as spam, the classifier learns from it; a
+// later decision by an admin takes that back.
+func (s *ModerationService) Moderate(id int, action string, userID int, role string) (*model.Comment, error) {
+	status, ok := moderationStatuses[action]
+	if !ok {
+		return nil, ErrModerationAction
+	}
+
+	comment, err := s.commentRepo.GetCommentByID(id)
+	if err == sql.ErrNoRows || (err == nil && comment.Deleted) {
+		return nil, ErrCommentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if role != "admin" {
+		post, err := s.postRepo.GetPostByID(comment.PostID)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		if post == nil || post.UserID != userID {
+			return nil, ErrCommentForbidden
+		}
+	}
+
+	previous, err := s.commentRepo.SetCommentStatus(id, status, &userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrCommentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// the filter judges comments on every post, so only admins teach it
+	if role == "admin" {
+		if err := s.train(comment, status); err != nil {
+			return nil, err
+		}
+	}
+	if previous != status && status == model.CommentStatusApproved {
+		s.events.Publish(events.Event{Type: events.CommentCreated, ActorID: comment.UserID, PostID: comment.PostID, CommentID: comment.ID})
+	}
+	return s.commentRepo.GetCommentByID(id)
+}
+
+// trainingLabels maps the statuses the classifier learns from onto the
+// label it learns.
+var trainingLabels = map[string]string{
+	model.CommentStatusApproved: spam.Ham,
+	model.CommentStatusSpam:     spam.Spam,
+}
+
+// train teaches the classifier that comment now has status, first taking
+// back what it learnt from the comment's previous status.
+func (s *ModerationService) train(comment *model.Comment, status string) error {
+	trainer, ok := s.Classifier.(spam.Trainer)
+	if !ok {
+		return nil
+	}
+	label := trainingLabels[status]
+	previous, previousTokens, err := s.spamRepo.Retrain(comment.ID, spam.UniqueTokens(comment.Body), label)
+	if err != nil || previous == label {
+		return err
+	}
+	if previous != "" {
+		trainer.Untrain(previousTokens, previous == spam.Spam)
+	}
+	if label != "" {
+		trainer.Train(comment.Body, label == spam.Spam)
+	}
+	return nil
+}
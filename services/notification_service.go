@@ -0,0 +1,270 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/wikasdude/blog-backend/config"
+	"github.com/wikasdude/blog-backend/events"
+	"github.com/wikasdude/blog-backend/model"
+	repository "github.com/wikasdude/blog-backend/repositories"
+)
+
+var (
+	ErrNotificationNotFound = errors.New("notification not found")
+	ErrNotificationType     = errors.New("unknown notification type")
+)
+
+// NotificationService turns domain events into inbox entries and serves the
+// inbox. It only learns about activity through the events bus.
+type NotificationService struct {
+	notificationRepo *repository.NotificationRepository
+	postRepo         *repository.PostRepository
+	commentRepo      *repository.CommentRepository
+}
+
+// NewNotificationService subscribes the notification center to bus.
+func NewNotificationService(notificationRepo *repository.NotificationRepository, postRepo *repository.PostRepository, commentRepo *repository.CommentRepository, bus *events.Bus) *NotificationService {
+	s := &NotificationService{notificationRepo: notificationRepo, postRepo: postRepo, commentRepo: commentRepo}
+	bus.Subscribe(events.PostPublished, s.handle(s.onPostPublished))
+	bus.Subscribe(events.CommentCreated, s.handle(s.onCommentCreated))
+	bus.Subscribe(events.ReactionAdded, s.handle(s.onReactionAdded))
+	bus.Subscribe(events.UserFollowed, s.handle(s.onUserFollowed))
+	return s
+}
+
+// handle logs what a handler could not deliver; a failed notification must
+// never fail the action that caused it.
+func (s *NotificationService) handle(fn func(events.Event) error) events.Handler {
+	return func(e events.Event) {
+		if err := fn(e); err != nil {
+			log.Printf("notifications: %s: %v", e.Type, err)
+		}
+	}
+}
+
+// notify delivers one notification unless the recipient caused it or was
+// already notified about this event.
+func (s *NotificationService) notify(seen map[int]bool, userID int, kind, groupKey string, actorID int, postID, commentID *int) error {
+	if userID == 0 || userID == actorID || seen[userID] {
+		return nil
+	}
+	seen[userID] = true
+	return s.notificationRepo.Notify(userID, kind, groupKey, actorID, postID, commentID)
+}
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9_.-]+)`)
+
+// mentions returns the distinct @handles in text, lower-cased.
+func mentions(text string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, m := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		name := strings.ToLower(strings.TrimRight(m[1], ".-"))
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (s *NotificationService) notifyMentions(seen map[int]bool, text string, actorID int, groupKey string, postID, commentID *int) error {
+	handles := mentions(text)
+	if len(handles) == 0 {
+		return nil
+	}
+	ids, err := s.notificationRepo.UserIDsByHandles(handles)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := s.notify(seen, id, model.NotificationMention, groupKey, actorID, postID, commentID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *NotificationService) onPostPublished(e events.Event) error {
+	post, err := s.postRepo.GetPostByID(e.PostID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	key := "post:" + strconv.Itoa(post.ID)
+	if err := s.notificationRepo.NotifyFollowers(post.UserID, model.NotificationNewPost, "new_post:"+key, &post.ID); err != nil {
+		return err
+	}
+	if post.Body == nil {
+		return nil
+	}
+	return s.notifyMentions(map[int]bool{}, *post.Body, post.UserID, "mention:"+key, &post.ID, nil)
+}
+
+func (s *NotificationService) onCommentCreated(e events.Event) error {
+	comment, err := s.commentRepo.GetCommentByID(e.CommentID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	post, err := s.postRepo.GetPostByID(comment.PostID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// replies, comments and mentions in that order, so each person hears
+	// about the comment once, in the most specific way
+	seen := map[int]bool{}
+	if comment.ParentID != nil {
+		parent, err := s.commentRepo.GetCommentByID(*comment.ParentID)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if parent != nil && !parent.Deleted {
+			key := "reply:comment:" + strconv.Itoa(parent.ID)
+			if err := s.notify(seen, parent.UserID, model.NotificationReply, key, comment.UserID, &post.ID, &comment.ID); err != nil {
+				return err
+			}
+		}
+	}
+	key := "comment:post:" + strconv.Itoa(post.ID)
+	if err := s.notify(seen, post.UserID, model.NotificationComment, key, comment.UserID, &post.ID, &comment.ID); err != nil {
+		return err
+	}
+	key = "mention:comment:" + strconv.Itoa(comment.ID)
+	return s.notifyMentions(seen, comment.Body, comment.UserID, key, &post.ID, &comment.ID)
+}
+
+func (s *NotificationService) onReactionAdded(e events.Event) error {
+	post, err := s.postRepo.GetPostByID(e.PostID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	key := "reaction:post:" + strconv.Itoa(post.ID)
+	return s.notify(map[int]bool{}, post.UserID, model.NotificationReaction, key, e.ActorID, &post.ID, nil)
+}
+
+func (s *NotificationService) onUserFollowed(e events.Event) error {
+	return s.notify(map[int]bool{}, e.UserID, model.NotificationFollow, "follow", e.ActorID, nil, nil)
+}
+
+// actorSubject names who did something: "Ann", "Ann and Bob" or "5 people".
+func actorSubject(n *model.Notification) string {
+	switch {
+	case len(n.Actors) == 0:
+		return "Someone"
+	case n.ActorCount == 1:
+		return n.Actors[0].Name
+	case n.ActorCount == 2 && len(n.Actors) == 2:
+		return n.Actors[0].Name + " and " + n.Actors[1].Name
+	default:
+		return fmt.Sprintf("%d people", n.ActorCount)
+	}
+}
+
+// notificationMessage renders the inbox text of a notification.
+func notificationMessage(n *model.Notification) string {
+	who := actorSubject(n)
+	title := strconv.Quote(n.PostTitle)
+	switch n.Type {
+	case model.NotificationComment:
+		return who + " commented on your post " + title
+	case model.NotificationReply:
+		return who + " replied to your comment on " + title
+	case model.NotificationReaction:
+		return who + " reacted to your post " + title
+	case model.NotificationMention:
+		if n.CommentID != nil {
+			return who + " mentioned you in a comment on " + title
+		}
+		return who + " mentioned you in " + title
+	case model.NotificationFollow:
+		return who + " started following you"
+	case model.NotificationNewPost:
+		return who + " published " + title
+	}
+	return who + " did something"
+}
+
+// Inbox lists a user's notifications, most recent activity first
+func (s *NotificationService) Inbox(userID int, unreadOnly bool, page, limit int) (*model.NotificationPage, error) {
+	notifications, total, err := s.notificationRepo.ListNotifications(userID, unreadOnly, config.NotificationActors, limit, (page-1)*limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range notifications {
+		n.Message = notificationMessage(n)
+	}
+	unread, err := s.notificationRepo.CountUnread(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.NotificationPage{Notifications: notifications, Unread: unread, Total: total, Page: page, Limit: limit}, nil
+}
+
+func (s *NotificationService) UnreadCount(userID int) (int, error) {
+	return s.notificationRepo.CountUnread(userID)
+}
+
+func (s *NotificationService) MarkRead(userID, id int) error {
+	err := s.notificationRepo.MarkRead(userID, id)
+	if err == sql.ErrNoRows {
+		return ErrNotificationNotFound
+	}
+	return err
+}
+
+// MarkAllRead marks every unread notification read and returns how many changed
+func (s *NotificationService) MarkAllRead(userID int) (int64, error) {
+	return s.notificationRepo.MarkAllRead(userID)
+}
+
+// Preferences returns every notification type with whether it is enabled
+func (s *NotificationService) Preferences(userID int) (model.NotificationPreferences, error) {
+	stored, err := s.notificationRepo.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+	prefs := model.NotificationPreferences{}
+	for _, kind := range model.NotificationTypes {
+		enabled, ok := stored[kind]
+		prefs[kind] = !ok || enabled
+	}
+	return prefs, nil
+}
+
+// UpdatePreferences changes the types sent and returns the full set
+func (s *NotificationService) UpdatePreferences(userID int, prefs model.NotificationPreferences) (model.NotificationPreferences, error) {
+	for kind := range prefs {
+		known := false
+		for _, t := range model.NotificationTypes {
+			if t == kind {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("%w: %s", ErrNotificationType, kind)
+		}
+	}
+	if err := s.notificationRepo.SetPreferences(userID, prefs); err != nil {
+		return nil, err
+	}
+	return s.Preferences(userID)
+}
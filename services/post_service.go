@@ -1,46 +1,468 @@
 package service
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/wikasdude/blog-backend/config"
+	"github.com/wikasdude/blog-backend/events"
 	"github.com/wikasdude/blog-backend/model"
+	"github.com/wikasdude/blog-backend/render"
 	repository "github.com/wikasdude/blog-backend/repositories"
+	"github.com/wikasdude/blog-backend/utils"
+)
+
+var (
+	ErrInvalidContentFormat = errors.New("content_format must be one of markdown, html or plain")
+	ErrPostVersionConflict  = errors.New("post version does not match")
+	ErrInvalidPost          = errors.New("invalid post")
+	ErrPostNotFound         = errors.New("post not found")
+	ErrPostCursor           = errors.New("invalid cursor")
 )
 
 type PostService struct {
 	postRepo *repository.PostRepository
+	events   *events.Bus
 }
 
-// Constructor function to initialize PostService
-func NewPostService(postRepo *repository.PostRepository) *PostService {
+// Constructor function to initialize PostService; bus may be nil
+func NewPostService(postRepo *repository.PostRepository, bus *events.Bus) *PostService {
 	return &PostService{
 		postRepo: postRepo,
+		events:   bus,
+	}
+}
+
+// renderBody fills BodyHTML and TOC from the post's body and content format
+func renderBody(post *model.Post) error {
+	if post.ContentFormat == "" {
+		post.ContentFormat = render.FormatMarkdown
+	}
+	body := ""
+	if post.Body != nil {
+		body = *post.Body
+	}
+	rendered, err := renderContent(post.ContentFormat, body)
+	if err != nil {
+		return err
+	}
+	post.BodyHTML, post.TOC = nil, rendered.TOC
+	if post.Body != nil {
+		post.BodyHTML = &rendered.HTML
+	}
+
+	summary := render.Summarize(rendered.HTML)
+	post.WordCount = summary.WordCount
+	post.ReadingTime = 0
+	if summary.WordCount > 0 {
+		post.ReadingTime = (summary.WordCount + config.WordsPerMinute - 1) / config.WordsPerMinute
+	}
+	post.Excerpt = render.Excerpt(summary.Text, config.ExcerptWords)
+	if post.Excerpt == "" {
+		post.Excerpt = render.Excerpt(post.Description, config.ExcerptWords)
+	}
+	post.FirstImage = nil
+	if summary.FirstImage != "" {
+		post.FirstImage = &summary.FirstImage
+	}
+	return nil
+}
+
+func renderContent(format, body string) (*model.RenderedBody, error) {
+	if !render.IsValidFormat(format) {
+		return nil, ErrInvalidContentFormat
+	}
+	result, err := render.Render(format, body)
+	if err != nil {
+		return nil, err
+	}
+	toc := make([]model.TOCEntry, len(result.TOC))
+	for i, h := range result.TOC {
+		toc[i] = model.TOCEntry{Level: h.Level, Text: h.Text, ID: h.ID}
+	}
+	return &model.RenderedBody{HTML: result.HTML, TOC: toc}, nil
+}
+
+// Render a body without saving anything
+func (s *PostService) PreviewBody(format, body string) (*model.RenderedBody, error) {
+	if format == "" {
+		format = render.FormatMarkdown
+	}
+	return renderContent(format, body)
+}
+
+// validatePostMeta tidies and checks the featured image and SEO fields. A
+// featured image must be a public image from the media library.
+func (s *PostService) validatePostMeta(post *model.Post) error {
+	post.FeaturedImageAlt = strings.TrimSpace(post.FeaturedImageAlt)
+	post.MetaTitle = strings.TrimSpace(post.MetaTitle)
+	post.MetaDescription = strings.TrimSpace(post.MetaDescription)
+	post.CanonicalURL = strings.TrimSpace(post.CanonicalURL)
+
+	if post.FeaturedMediaID == nil {
+		post.FeaturedImageAlt = ""
+	} else {
+		if post.FeaturedImageAlt == "" {
+			return fmt.Errorf("%w: featured_image_alt is required with a featured image", ErrInvalidPost)
+		}
+		contentType, private, err := s.postRepo.GetFeaturableMedia(*post.FeaturedMediaID)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%w: media %d does not exist", ErrInvalidPost, *post.FeaturedMediaID)
+		}
+		if err != nil {
+			return err
+		}
+		if private || !strings.HasPrefix(contentType, "image/") {
+			return fmt.Errorf("%w: the featured image must be a public image", ErrInvalidPost)
+		}
+	}
+	switch {
+	case utf8.RuneCountInString(post.FeaturedImageAlt) > config.MaxAltTextLength:
+		return fmt.Errorf("%w: featured_image_alt may be at most %d characters", ErrInvalidPost, config.MaxAltTextLength)
+	case utf8.RuneCountInString(post.MetaTitle) > config.MaxMetaTitleLength:
+		return fmt.Errorf("%w: meta_title may be at most %d characters", ErrInvalidPost, config.MaxMetaTitleLength)
+	case utf8.RuneCountInString(post.MetaDescription) > config.MaxMetaDescriptionLength:
+		return fmt.Errorf("%w: meta_description may be at most %d characters", ErrInvalidPost, config.MaxMetaDescriptionLength)
 	}
+	if post.CanonicalURL != "" {
+		u, err := url.Parse(post.CanonicalURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: canonical_url must be an absolute http or https URL", ErrInvalidPost)
+		}
+	}
+	return nil
 }
 
 // Create a new post
 func (s *PostService) CreatePost(post *model.Post) error {
-	return s.postRepo.CreatePost(post)
+	if err := s.validatePostMeta(post); err != nil {
+		return err
+	}
+	if err := renderBody(post); err != nil {
+		return err
+	}
+	if err := s.postRepo.CreatePost(post); err != nil {
+		return err
+	}
+	if err := s.postRepo.AttachFeaturedImages([]*model.Post{post}); err != nil {
+		return err
+	}
+	if post.Tags == nil {
+		post.Tags = []string{}
+	} else if err := s.postRepo.SetPostTags(post.ID, post.Tags); err != nil {
+		return err
+	}
+	s.events.Publish(events.Event{Type: events.PostCreated, ActorID: post.UserID, PostID: post.ID})
+	if post.PublishedAt != nil {
+		s.events.Publish(events.Event{Type: events.PostPublished, ActorID: post.UserID, PostID: post.ID})
+	}
+	return nil
 }
 
-// Get post by ID
+// Get post by ID; bodies that were never rendered are rendered and cached here
 func (s *PostService) GetPostByID(id int) (*model.Post, error) {
-	return s.postRepo.GetPostByID(id)
+	post, err := s.postRepo.GetPostByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if post.BodyHTML == nil && post.Body != nil {
+		if err := renderBody(post); err != nil {
+			return nil, err
+		}
+		if err := s.postRepo.UpdateDerivedFields(post); err != nil {
+			return nil, err
+		}
+	}
+	return post, nil
+}
+
+// Record a read of a post for popularity sorting
+func (s *PostService) RecordView(id int) error {
+	return s.postRepo.IncrementViewCount(id)
+}
+
+// Update a post; tags are only replaced when the request carried them.
+// expectedVersion 0 skips the optimistic concurrency check.
+func (s *PostService) UpdatePost(post *model.Post, expectedVersion int) error {
+	if err := s.validatePostMeta(post); err != nil {
+		return err
+	}
+	if err := renderBody(post); err != nil {
+		return err
+	}
+	wasPublished := post.PublishedAt != nil
+	if err := s.postRepo.UpdatePost(post, expectedVersion); err != nil {
+		if err == sql.ErrNoRows && expectedVersion != 0 {
+			return ErrPostVersionConflict
+		}
+		return err
+	}
+	if err := s.postRepo.AttachFeaturedImages([]*model.Post{post}); err != nil {
+		return err
+	}
+	if post.Tags != nil {
+		if err := s.postRepo.SetPostTags(post.ID, post.Tags); err != nil {
+			return err
+		}
+	}
+	if !wasPublished && post.PublishedAt != nil {
+		s.events.Publish(events.Event{Type: events.PostPublished, ActorID: post.UserID, PostID: post.ID})
+	} else {
+		s.events.Publish(events.Event{Type: events.PostUpdated, ActorID: post.UserID, PostID: post.ID})
+	}
+	return nil
+}
+
+// Apply the result of a PATCH to an existing post. The merged fields are
+// validated as a whole and the stored post is returned as read back from the
+// database.
+func (s *PostService) PatchPost(existing *model.Post, patch model.PostPatch, expectedVersion int) (*model.Post, error) {
+	switch {
+	case strings.TrimSpace(patch.Title) == "":
+		return nil, fmt.Errorf("%w: title is required", ErrInvalidPost)
+	case strings.TrimSpace(patch.Description) == "":
+		return nil, fmt.Errorf("%w: description is required", ErrInvalidPost)
+	case patch.CategoryID <= 0:
+		return nil, fmt.Errorf("%w: category_id is required", ErrInvalidPost)
+	case !model.IsValidPostStatus(patch.Status):
+		return nil, fmt.Errorf("%w: status must be draft or published", ErrInvalidPost)
+	}
+
+	post := *existing
+	post.Title = patch.Title
+	post.Description = patch.Description
+	post.CategoryID = patch.CategoryID
+	post.Body = patch.Body
+	post.ContentFormat = patch.ContentFormat
+	post.Status = patch.Status
+	post.Tags = patch.Tags
+	post.FeaturedMediaID = patch.FeaturedMediaID
+	post.FeaturedImageAlt = patch.FeaturedImageAlt
+	post.MetaTitle = patch.MetaTitle
+	post.MetaDescription = patch.MetaDescription
+	post.CanonicalURL = patch.CanonicalURL
+	post.NoIndex = patch.NoIndex
+	if post.Tags == nil {
+		// a null tags member clears them
+		post.Tags = []string{}
+	}
+
+	if err := s.UpdatePost(&post, expectedVersion); err != nil {
+		return nil, err
+	}
+	return s.GetPostByID(post.ID)
+}
+
+// Move a post to the trash; expectedVersion 0 skips the optimistic concurrency check
+func (s *PostService) DeletePost(id, deletedBy, expectedVersion int) error {
+	err := s.postRepo.SoftDeletePost(id, deletedBy, expectedVersion)
+	if err == sql.ErrNoRows && expectedVersion != 0 {
+		return ErrPostVersionConflict
+	}
+	if err == nil {
+		s.events.Publish(events.Event{Type: events.PostDeleted, ActorID: deletedBy, PostID: id})
+	}
+	return err
+}
+
+// Get a post from the trash
+func (s *PostService) GetTrashedPost(id int) (*model.Post, error) {
+	return s.postRepo.GetTrashedPost(id)
+}
+
+// List trashed posts; authorID 0 lists every author's
+func (s *PostService) ListTrash(authorID, page, limit int) (*model.TrashPage, error) {
+	posts, total, err := s.postRepo.ListTrashedPosts(authorID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, err
+	}
+	return &model.TrashPage{Posts: posts, Total: total, Page: page, Limit: limit}, nil
+}
+
+// Restore a post from the trash
+func (s *PostService) RestorePost(id int) (*model.Post, error) {
+	if err := s.postRepo.RestorePost(id); err != nil {
+		return nil, err
+	}
+	post, err := s.GetPostByID(id)
+	if err == nil {
+		s.events.Publish(events.Event{Type: events.PostUpdated, ActorID: post.UserID, PostID: id})
+	}
+	return post, err
+}
+
+// Permanently delete a trashed post
+func (s *PostService) PurgePost(id int) error {
+	return s.postRepo.PurgePost(id)
+}
+
+// Permanently delete posts that have been in the trash longer than retention
+func (s *PostService) PurgeExpiredTrash(retention time.Duration) (int64, error) {
+	return s.postRepo.PurgeTrashedBefore(time.Now().Add(-retention))
 }
 
-// Update a post
-func (s *PostService) UpdatePost(post *model.Post) error {
-	return s.postRepo.UpdatePost(post)
+// RunTrashPurger purges expired trash every interval until stop is closed.
+// A retention of zero or less disables automatic purging.
+func (s *PostService) RunTrashPurger(retention, interval time.Duration, stop <-chan struct{}) {
+	if retention <= 0 || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		purged, err := s.PurgeExpiredTrash(retention)
+		if err != nil {
+			log.Println("failed to purge trashed posts:", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d trashed posts older than %s", purged, retention)
+		}
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
 }
 
-// Delete a post
-func (s *PostService) DeletePost(id int) error {
-	return s.postRepo.DeletePost(id)
+// List one page of posts with cursors for the neighbouring pages
+func (s *PostService) ListPosts(params model.PostListParams) (*model.PostPage, error) {
+	posts, err := s.postRepo.ListPosts(params)
+	if err != nil {
+		return nil, err
+	}
+
+	backward := params.Cursor != nil && params.Cursor.Backward
+	hasMore := len(posts) > params.Limit
+	if hasMore {
+		if backward {
+			posts = posts[1:]
+		} else {
+			posts = posts[:params.Limit]
+		}
+	}
+	hasNext := hasMore || backward
+	hasPrev := (hasMore && backward) || (params.Cursor != nil && !backward)
+
+	total, err := s.postRepo.EstimatePostCount(params.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &model.PostPage{
+		Posts:      posts,
+		Pagination: model.Pagination{Limit: params.Limit, TotalEstimate: total},
+	}
+	if page.Posts == nil {
+		page.Posts = []*model.Post{}
+	}
+	if len(posts) > 0 {
+		if hasNext {
+			page.Pagination.NextCursor = utils.EncodeCursor(postCursor(params, posts[len(posts)-1], false))
+		}
+		if hasPrev {
+			page.Pagination.PrevCursor = utils.EncodeCursor(postCursor(params, posts[0], true))
+		}
+	}
+	return page, nil
 }
 
-// List all posts (optional utility method)
-func (s *PostService) GetAllPosts() ([]*model.Post, error) {
-	return s.postRepo.GetAllPosts()
+// DecodePostCursor reads a cursor token from a post listing, checking that
+// its sort, order, id and position value are ones postCursor could have
+// written, so a forged token never reaches the database.
+func DecodePostCursor(token string) (*model.PostCursor, error) {
+	var c model.PostCursor
+	if err := utils.DecodeCursor(token, &c); err != nil {
+		return nil, ErrPostCursor
+	}
+	if !config.ValidSortFields[c.Sort] || (c.Order != "asc" && c.Order != "desc") || c.ID < 1 {
+		return nil, ErrPostCursor
+	}
+	var err error
+	switch c.Sort {
+	case "title":
+	case "published_at":
+		if c.Value != "-infinity" {
+			_, err = time.Parse(time.RFC3339Nano, c.Value)
+		}
+	case "popularity":
+		var views int
+		if views, err = strconv.Atoi(c.Value); err == nil && views < 0 {
+			err = ErrPostCursor
+		}
+	default:
+		_, err = time.Parse(time.RFC3339Nano, c.Value)
+	}
+	if err != nil {
+		return nil, ErrPostCursor
+	}
+	return &c, nil
+}
+
+func postCursor(params model.PostListParams, post *model.Post, backward bool) model.PostCursor {
+	cursor := model.PostCursor{Sort: params.Sort, Order: params.Order, ID: post.ID, Backward: backward}
+	switch params.Sort {
+	case "title":
+		cursor.Value = post.Title
+	case "updated_at":
+		cursor.Value = post.UpdatedAt.Format(time.RFC3339Nano)
+	case "published_at":
+		cursor.Value = "-infinity"
+		if post.PublishedAt != nil {
+			cursor.Value = post.PublishedAt.Format(time.RFC3339Nano)
+		}
+	case "popularity":
+		// view counts keep growing, so a post read between two pages can
+		// cross the cursor and show up twice or not at all
+		cursor.Value = strconv.Itoa(post.ViewCount)
+	default:
+		cursor.Value = post.CreatedAt.Format(time.RFC3339Nano)
+	}
+	return cursor
 }
-func (s *PostService) GetPaginatedPosts(page int, limit int, sort string, order string, search string) ([]*model.Post, error) {
-	offset := (page - 1) * limit
-	return s.postRepo.GetPaginatedPosts(limit, offset, sort, order, search)
+
+// Re-render every post and recompute its derived metadata, batchSize posts at
+// a time. Returns the number of posts updated.
+func (s *PostService) BackfillDerivedFields(batchSize int) (int, error) {
+	updated, afterID := 0, 0
+	for {
+		posts, err := s.postRepo.ListPostsAfterID(afterID, batchSize)
+		if err != nil {
+			return updated, err
+		}
+		if len(posts) == 0 {
+			return updated, nil
+		}
+		for _, post := range posts {
+			if err := renderBody(post); err != nil {
+				return updated, fmt.Errorf("post %d: %w", post.ID, err)
+			}
+			if err := s.postRepo.UpdateDerivedFields(post); err != nil {
+				return updated, fmt.Errorf("post %d: %w", post.ID, err)
+			}
+			updated++
+			afterID = post.ID
+		}
+	}
+}
+
+// Full-text search over published posts
+func (s *PostService) SearchPosts(params model.PostSearchParams, page int) (*model.PostSearchResponse, error) {
+	params.Offset = (page - 1) * params.Limit
+	results, total, err := s.postRepo.SearchPosts(params)
+	if err != nil {
+		return nil, err
+	}
+	return &model.PostSearchResponse{
+		Results: results,
+		Total:   total,
+		Page:    page,
+		Limit:   params.Limit,
+	}, nil
 }
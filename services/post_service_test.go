@@ -0,0 +1,43 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/wikasdude/blog-backend/model"
+	"github.com/wikasdude/blog-backend/utils"
+)
+
+func TestDecodePostCursor(t *testing.T) {
+	tests := []struct {
+		cursor model.PostCursor
+		ok     bool
+	}{
+		{model.PostCursor{Sort: "created_at", Order: "desc", Value: "2024-05-01T10:00:00.123456Z", ID: 3}, true},
+		{model.PostCursor{Sort: "updated_at", Order: "asc", Value: "2024-05-01T10:00:00+02:00", ID: 3, Backward: true}, true},
+		{model.PostCursor{Sort: "published_at", Order: "desc", Value: "-infinity", ID: 3}, true},
+		{model.PostCursor{Sort: "popularity", Order: "desc", Value: "120", ID: 3}, true},
+		{model.PostCursor{Sort: "title", Order: "asc", Value: "", ID: 3}, true},
+		{model.PostCursor{Sort: "popularity", Order: "desc", Value: "abc", ID: 3}, false},
+		{model.PostCursor{Sort: "popularity", Order: "desc", Value: "-1", ID: 3}, false},
+		{model.PostCursor{Sort: "created_at", Order: "desc", Value: "yesterday", ID: 3}, false},
+		{model.PostCursor{Sort: "updated_at", Order: "desc", Value: "-infinity", ID: 3}, false},
+		{model.PostCursor{Sort: "created_at", Order: "sideways", Value: "2024-05-01T10:00:00Z", ID: 3}, false},
+		{model.PostCursor{Sort: "created_at", Order: "desc", Value: "2024-05-01T10:00:00Z"}, false},
+		{model.PostCursor{Sort: "view_count", Order: "desc", Value: "1", ID: 3}, false},
+	}
+	for _, tt := range tests {
+		got, err := DecodePostCursor(utils.EncodeCursor(tt.cursor))
+		if tt.ok && (err != nil || *got != tt.cursor) {
+			t.Errorf("DecodePostCursor(%+v) = %+v, %v", tt.cursor, got, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrPostCursor) {
+			t.Errorf("DecodePostCursor(%+v) = %v, want ErrPostCursor", tt.cursor, err)
+		}
+	}
+	for _, token := range []string{"!!", "bm90IGpzb24"} {
+		if _, err := DecodePostCursor(token); !errors.Is(err, ErrPostCursor) {
+			t.Errorf("DecodePostCursor(%q) = %v, want ErrPostCursor", token, err)
+		}
+	}
+}
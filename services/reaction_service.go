@@ -0,0 +1,144 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/wikasdude/blog-backend/config"
+	"github.com/wikasdude/blog-backend/events"
+	"github.com/wikasdude/blog-backend/model"
+	repository "github.com/wikasdude/blog-backend/repositories"
+)
+
+var ErrUnknownReaction = errors.New("unknown reaction")
+
+type ReactionService struct {
+	reactionRepo *repository.ReactionRepository
+	postRepo     *repository.PostRepository
+	events       *events.Bus
+	types        []model.ReactionType
+}
+
+func NewReactionService(reactionRepo *repository.ReactionRepository, postRepo *repository.PostRepository, bus *events.Bus) *ReactionService {
+	return &ReactionService{
+		reactionRepo: reactionRepo,
+		postRepo:     postRepo,
+		events:       bus,
+		types:        parseReactionTypes(config.GetEnv(config.EnvReactions, config.DefaultReactions)),
+	}
+}
+
+// parseReactionTypes reads "name:emoji" pairs; like is always included.
+func parseReactionTypes(spec string) []model.ReactionType {
+	types := []model.ReactionType{{Name: model.ReactionLike, Emoji: "👍"}}
+	seen := map[string]bool{model.ReactionLike: true}
+	for _, item := range strings.Split(spec, ",") {
+		name, emoji, _ := strings.Cut(strings.TrimSpace(item), ":")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		types = append(types, model.ReactionType{Name: name, Emoji: strings.TrimSpace(emoji)})
+	}
+	return types
+}
+
+// The reactions readers can leave
+func (s *ReactionService) ReactionTypes() []model.ReactionType {
+	return s.types
+}
+
+func (s *ReactionService) validate(postID int, reaction string) error {
+	known := false
+	for _, t := range s.types {
+		if t.Name == reaction {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return ErrUnknownReaction
+	}
+	post, err := s.postRepo.GetPostByID(postID)
+	if err == sql.ErrNoRows || (err == nil && post.Status != model.PostStatusPublished) {
+		return ErrPostNotFound
+	}
+	return err
+}
+
+// React adds a reaction; adding one the user already left is a no-op
+func (s *ReactionService) React(postID, userID int, reaction string) (*model.ReactionSummary, error) {
+	if err := s.validate(postID, reaction); err != nil {
+		return nil, err
+	}
+	added, err := s.reactionRepo.AddReaction(postID, userID, reaction)
+	if err != nil {
+		return nil, err
+	}
+	if added {
+		s.events.Publish(events.Event{Type: events.ReactionAdded, ActorID: userID, PostID: postID, Reaction: reaction})
+	}
+	return s.Summary(postID, userID)
+}
+
+// Unreact removes a reaction; removing one that is not there is a no-op
+func (s *ReactionService) Unreact(postID, userID int, reaction string) (*model.ReactionSummary, error) {
+	if err := s.validate(postID, reaction); err != nil {
+		return nil, err
+	}
+	if _, err := s.reactionRepo.RemoveReaction(postID, userID, reaction); err != nil {
+		return nil, err
+	}
+	return s.Summary(postID, userID)
+}
+
+// Summary returns a post's reaction counts and, when userID is not 0, that
+// user's own reactions
+func (s *ReactionService) Summary(postID, userID int) (*model.ReactionSummary, error) {
+	counts, err := s.reactionRepo.CountReactions(postID)
+	if err != nil {
+		return nil, err
+	}
+	summary := &model.ReactionSummary{PostID: postID, Counts: counts}
+	if userID != 0 {
+		if summary.Mine, err = s.reactionRepo.UserReactions(postID, userID); err != nil {
+			return nil, err
+		}
+	}
+	return summary, nil
+}
+
+// PostSummary is Summary for a post readers can see
+func (s *ReactionService) PostSummary(postID, userID int) (*model.ReactionSummary, error) {
+	post, err := s.postRepo.GetPostByID(postID)
+	if err == sql.ErrNoRows || (err == nil && post.Status != model.PostStatusPublished) {
+		return nil, ErrPostNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.Summary(postID, userID)
+}
+
+// Reactors lists who left a reaction on a post, most recent first
+func (s *ReactionService) Reactors(postID int, reaction string, page, limit int) (*model.ReactorPage, error) {
+	if err := s.validate(postID, reaction); err != nil {
+		return nil, err
+	}
+	reactors, total, err := s.reactionRepo.ListReactors(postID, reaction, limit, (page-1)*limit)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ReactorPage{Reactors: reactors, Total: total, Page: page, Limit: limit}, nil
+}
+
+// MyReactions lists the posts a user reacted to, most recent first
+func (s *ReactionService) MyReactions(userID, page, limit int) (*model.UserReactionPage, error) {
+	posts, total, err := s.reactionRepo.ListUserReactions(userID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, err
+	}
+	return &model.UserReactionPage{Posts: posts, Total: total, Page: page, Limit: limit}, nil
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/wikasdude/blog-backend/config"
+	"github.com/wikasdude/blog-backend/model"
+	repository "github.com/wikasdude/blog-backend/repositories"
+)
+
+var (
+	ErrReadingListNotFound  = errors.New("reading list not found")
+	ErrReadingListInvalid   = errors.New("invalid reading list")
+	ErrReadingListForbidden = errors.New("you are not allowed to change this reading list")
+)
+
+type ReadingListService struct {
+	bookmarkRepo *repository.BookmarkRepository
+	listRepo     *repository.ReadingListRepository
+	postRepo     *repository.PostRepository
+}
+
+func NewReadingListService(bookmarkRepo *repository.BookmarkRepository, listRepo *repository.ReadingListRepository, postRepo *repository.PostRepository) *ReadingListService {
+	return &ReadingListService{bookmarkRepo: bookmarkRepo, listRepo: listRepo, postRepo: postRepo}
+}
+
+// readablePost fails with ErrPostNotFound unless readers can see the post.
+func (s *ReadingListService) readablePost(postID int) error {
+	post, err := s.postRepo.GetPostByID(postID)
+	if err == sql.ErrNoRows || (err == nil && post.Status != model.PostStatusPublished) {
+		return ErrPostNotFound
+	}
+	return err
+}
+
+// Bookmark saves a post for later; saving it again is a no-op
+func (s *ReadingListService) Bookmark(userID, postID int) error {
+	if err := s.readablePost(postID); err != nil {
+		return err
+	}
+	return s.bookmarkRepo.AddBookmark(userID, postID)
+}
+
+// Unbookmark forgets a saved post, even one that has since been hidden
+func (s *ReadingListService) Unbookmark(userID, postID int) error {
+	return s.bookmarkRepo.RemoveBookmark(userID, postID)
+}
+
+// Bookmarks lists a user's saved posts that are still readable, newest first
+func (s *ReadingListService) Bookmarks(userID, page, limit int) (*model.BookmarkPage, error) {
+	ids, savedAt, total, err := s.bookmarkRepo.ListBookmarks(userID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, err
+	}
+	posts, err := s.postRepo.GetPublishedPostsByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	bookmarks := make([]*model.Bookmark, 0, len(posts))
+	for _, post := range posts {
+		bookmarks = append(bookmarks, &model.Bookmark{Post: post, BookmarkedAt: savedAt[post.ID]})
+	}
+	return &model.BookmarkPage{Bookmarks: bookmarks, Total: total, Page: page, Limit: limit}, nil
+}
+
+func newShareToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// applyListRequest copies the fields sent in req onto list. Turning sharing
+// on issues a fresh link; turning it off revokes the old one.
+func applyListRequest(list *model.ReadingList, req model.ReadingListRequest) error {
+	if req.Name != nil {
+		list.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		list.Description = strings.TrimSpace(*req.Description)
+	}
+	if list.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrReadingListInvalid)
+	}
+	if utf8.RuneCountInString(list.Name) > config.MaxReadingListName {
+		return fmt.Errorf("%w: name is longer than %d characters", ErrReadingListInvalid, config.MaxReadingListName)
+	}
+	if req.Shared != nil && *req.Shared != list.Shared {
+		list.Shared = *req.Shared
+		list.ShareToken = nil
+		if list.Shared {
+			token, err := newShareToken()
+			if err != nil {
+				return err
+			}
+			list.ShareToken = &token
+		}
+	}
+	return nil
+}
+
+func (s *ReadingListService) CreateList(userID int, req model.ReadingListRequest) (*model.ReadingList, error) {
+	list := &model.ReadingList{UserID: userID}
+	if err := applyListRequest(list, req); err != nil {
+		return nil, err
+	}
+	if err := s.listRepo.CreateList(list); err != nil {
+		return nil, err
+	}
+	list.Posts = []*model.Post{}
+	return list, nil
+}
+
+// Lists returns a user's reading lists without their posts
+func (s *ReadingListService) Lists(userID int) ([]*model.ReadingList, error) {
+	return s.listRepo.ListUserLists(userID)
+}
+
+// ownedList loads a list for its owner; anyone else is refused.
+func (s *ReadingListService) ownedList(listID, userID int) (*model.ReadingList, error) {
+	list, err := s.listRepo.GetListByID(listID)
+	if err == sql.ErrNoRows {
+		return nil, ErrReadingListNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if list.UserID != userID {
+		return nil, ErrReadingListForbidden
+	}
+	return list, nil
+}
+
+// withPosts fills in the list's readable posts in list order.
+func (s *ReadingListService) withPosts(list *model.ReadingList) (*model.ReadingList, error) {
+	ids, err := s.listRepo.ItemPostIDs(list.ID)
+	if err != nil {
+		return nil, err
+	}
+	if list.Posts, err = s.postRepo.GetPublishedPostsByIDs(ids); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// GetList returns one of the user's lists with its posts
+func (s *ReadingListService) GetList(listID, userID int) (*model.ReadingList, error) {
+	list, err := s.ownedList(listID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.withPosts(list)
+}
+
+// SharedList returns the list behind a share link; the token is not echoed back
+func (s *ReadingListService) SharedList(token string) (*model.ReadingList, error) {
+	list, err := s.listRepo.GetListByShareToken(token)
+	if err == sql.ErrNoRows {
+		return nil, ErrReadingListNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	list.ShareToken = nil
+	return s.withPosts(list)
+}
+
+func (s *ReadingListService) UpdateList(listID, userID int, req model.ReadingListRequest) (*model.ReadingList, error) {
+	list, err := s.ownedList(listID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyListRequest(list, req); err != nil {
+		return nil, err
+	}
+	if err := s.listRepo.UpdateList(list); err != nil {
+		return nil, err
+	}
+	return s.withPosts(list)
+}
+
+func (s *ReadingListService) DeleteList(listID, userID int) error {
+	if _, err := s.ownedList(listID, userID); err != nil {
+		return err
+	}
+	err := s.listRepo.DeleteList(listID)
+	if err == sql.ErrNoRows {
+		return ErrReadingListNotFound
+	}
+	return err
+}
+
+// AddToList appends a readable post to the end of a list; adding it again is a no-op
+func (s *ReadingListService) AddToList(listID, userID, postID int) (*model.ReadingList, error) {
+	if _, err := s.ownedList(listID, userID); err != nil {
+		return nil, err
+	}
+	if err := s.readablePost(postID); err != nil {
+		return nil, err
+	}
+	if err := s.listRepo.AddItem(listID, postID); err != nil {
+		return nil, err
+	}
+	return s.GetList(listID, userID)
+}
+
+// RemoveFromList takes a post off a list, even one that has since been hidden
+func (s *ReadingListService) RemoveFromList(listID, userID, postID int) (*model.ReadingList, error) {
+	if _, err := s.ownedList(listID, userID); err != nil {
+		return nil, err
+	}
+	if err := s.listRepo.RemoveItem(listID, postID); err != nil {
+		return nil, err
+	}
+	return s.GetList(listID, userID)
+}
+
+// ReorderList moves the given posts to the front of the list in that order;
+// the rest follow in their current order.
+func (s *ReadingListService) ReorderList(listID, userID int, postIDs []int) (*model.ReadingList, error) {
+	if _, err := s.ownedList(listID, userID); err != nil {
+		return nil, err
+	}
+	current, err := s.listRepo.ItemPostIDs(listID)
+	if err != nil {
+		return nil, err
+	}
+
+	onList := make(map[int]bool, len(current))
+	for _, id := range current {
+		onList[id] = true
+	}
+	placed := make(map[int]bool, len(postIDs))
+	order := make([]int, 0, len(current))
+	for _, id := range postIDs {
+		if !onList[id] {
+			return nil, fmt.Errorf("%w: post %d is not on the list", ErrReadingListInvalid, id)
+		}
+		if placed[id] {
+			return nil, fmt.Errorf("%w: post %d is listed more than once", ErrReadingListInvalid, id)
+		}
+		placed[id] = true
+		order = append(order, id)
+	}
+	for _, id := range current {
+		if !placed[id] {
+			order = append(order, id)
+		}
+	}
+
+	if err := s.listRepo.SetOrder(listID, order); err != nil {
+		return nil, err
+	}
+	return s.GetList(listID, userID)
+}
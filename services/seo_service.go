@@ -0,0 +1,159 @@
+package service
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wikasdude/blog-backend/config"
+	"github.com/wikasdude/blog-backend/model"
+	repository "github.com/wikasdude/blog-backend/repositories"
+)
+
+// SEOService describes published posts to search engines and link previews.
+type SEOService struct {
+	postRepo     *repository.PostRepository
+	categoryRepo *repository.CategoryRepository
+	siteTitle    string
+	twitterSite  string
+}
+
+func NewSEOService(postRepo *repository.PostRepository, categoryRepo *repository.CategoryRepository) *SEOService {
+	return &SEOService{
+		postRepo:     postRepo,
+		categoryRepo: categoryRepo,
+		siteTitle:    config.GetEnv(config.EnvSiteTitle, config.DefaultSiteTitle),
+		twitterSite:  config.GetEnv(config.EnvTwitterSite, ""),
+	}
+}
+
+// PostMetadata builds the head metadata of a published post's page on
+// siteURL. The post's SEO fields win over what is derived from its content:
+// MetaTitle over the title, MetaDescription over the description and
+// excerpt, CanonicalURL over the post's own page. The featured image is
+// preferred for previews, then the first image in the body. Drafts and
+// trashed posts are not found.
+func (s *SEOService) PostMetadata(id int, siteURL string) (*model.PostMetadata, error) {
+	post, err := s.postRepo.GetPostByID(id)
+	if err == sql.ErrNoRows || (err == nil && post.Status != model.PostStatusPublished) {
+		return nil, ErrPostNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.DescribePost(post, siteURL)
+}
+
+// DescribePost builds the head metadata of a post already loaded, as
+// PostMetadata does.
+func (s *SEOService) DescribePost(post *model.Post, siteURL string) (*model.PostMetadata, error) {
+	authors, err := s.postRepo.GetAuthorNames([]int{post.UserID})
+	if err != nil {
+		return nil, err
+	}
+	var section string
+	category, err := s.categoryRepo.GetCategoryByID(post.CategoryID)
+	if err == nil {
+		section = category.Name
+	} else if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	meta := &model.PostMetadata{
+		Title:        firstNonEmpty(post.MetaTitle, post.Title),
+		Description:  firstNonEmpty(post.MetaDescription, post.Description, post.Excerpt),
+		CanonicalURL: firstNonEmpty(post.CanonicalURL, PostURL(siteURL, post.ID)),
+		Robots:       "index, follow",
+	}
+	if post.NoIndex {
+		meta.Robots = "noindex, follow"
+	}
+
+	var image *model.SchemaImage
+	if post.FeaturedImage != nil {
+		image = &model.SchemaImage{
+			Type:    "ImageObject",
+			URL:     absoluteURL(siteURL, post.FeaturedImage.Path),
+			Width:   post.FeaturedImage.Width,
+			Height:  post.FeaturedImage.Height,
+			Caption: post.FeaturedImage.Alt,
+		}
+	} else if post.FirstImage != nil {
+		image = &model.SchemaImage{Type: "ImageObject", URL: absoluteURL(siteURL, *post.FirstImage)}
+	}
+
+	published := post.CreatedAt
+	if post.PublishedAt != nil {
+		published = *post.PublishedAt
+	}
+	authorURL := strings.TrimRight(siteURL, "/") + PublicPath(model.SitemapAuthor, strconv.Itoa(post.UserID))
+
+	og := []model.MetaTag{
+		{Property: "og:type", Content: "article"},
+		{Property: "og:site_name", Content: s.siteTitle},
+		{Property: "og:title", Content: meta.Title},
+		{Property: "og:description", Content: meta.Description},
+		{Property: "og:url", Content: meta.CanonicalURL},
+	}
+	twitter := []model.MetaTag{
+		{Name: "twitter:card", Content: "summary"},
+		{Name: "twitter:title", Content: meta.Title},
+		{Name: "twitter:description", Content: meta.Description},
+	}
+	if image != nil {
+		og = append(og, model.MetaTag{Property: "og:image", Content: image.URL})
+		if image.Width > 0 && image.Height > 0 {
+			og = append(og,
+				model.MetaTag{Property: "og:image:width", Content: strconv.Itoa(image.Width)},
+				model.MetaTag{Property: "og:image:height", Content: strconv.Itoa(image.Height)})
+		}
+		twitter[0].Content = "summary_large_image"
+		twitter = append(twitter, model.MetaTag{Name: "twitter:image", Content: image.URL})
+		if image.Caption != "" {
+			og = append(og, model.MetaTag{Property: "og:image:alt", Content: image.Caption})
+			twitter = append(twitter, model.MetaTag{Name: "twitter:image:alt", Content: image.Caption})
+		}
+	}
+	og = append(og,
+		model.MetaTag{Property: "article:published_time", Content: published.UTC().Format(time.RFC3339)},
+		model.MetaTag{Property: "article:modified_time", Content: post.UpdatedAt.UTC().Format(time.RFC3339)},
+		model.MetaTag{Property: "article:author", Content: authorURL})
+	if section != "" {
+		og = append(og, model.MetaTag{Property: "article:section", Content: section})
+	}
+	for _, tag := range post.Tags {
+		og = append(og, model.MetaTag{Property: "article:tag", Content: tag})
+	}
+	if s.twitterSite != "" {
+		twitter = append(twitter, model.MetaTag{Name: "twitter:site", Content: s.twitterSite})
+	}
+	meta.OpenGraph = og
+	meta.Twitter = twitter
+
+	meta.JSONLD = model.BlogPosting{
+		Context:          "https://schema.org",
+		Type:             "BlogPosting",
+		Headline:         meta.Title,
+		Description:      meta.Description,
+		Image:            image,
+		DatePublished:    published.UTC().Format(time.RFC3339),
+		DateModified:     post.UpdatedAt.UTC().Format(time.RFC3339),
+		Author:           model.SchemaThing{Type: "Person", Name: authors[post.UserID], URL: authorURL},
+		Publisher:        model.SchemaThing{Type: "Organization", Name: s.siteTitle, URL: strings.TrimRight(siteURL, "/") + "/"},
+		MainEntityOfPage: meta.CanonicalURL,
+		Keywords:         strings.Join(post.Tags, ", "),
+		ArticleSection:   section,
+		WordCount:        post.WordCount,
+	}
+	return meta, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/wikasdude/blog-backend/config"
+	"github.com/wikasdude/blog-backend/model"
+	repository "github.com/wikasdude/blog-backend/repositories"
+	"github.com/wikasdude/blog-backend/utils"
+)
+
+var ErrPageNotFound = errors.New("page not found")
+
+// SiteService gathers what the pages of the public site show. Only
+// published posts appear; listings are newest first and paged with the same
+// cursors as the post listing API.
+type SiteService struct {
+	postService  *PostService
+	postRepo     *repository.PostRepository
+	categoryRepo *repository.CategoryRepository
+	seo          *SEOService
+	info         model.SiteInfo
+	pageSize     int
+}
+
+func NewSiteService(postService *PostService, postRepo *repository.PostRepository, categoryRepo *repository.CategoryRepository, seo *SEOService) *SiteService {
+	return &SiteService{
+		postService:  postService,
+		postRepo:     postRepo,
+		categoryRepo: categoryRepo,
+		seo:          seo,
+		info: model.SiteInfo{
+			Title:       config.GetEnv(config.EnvSiteTitle, config.DefaultSiteTitle),
+			Description: config.GetEnv(config.EnvSiteDescription, ""),
+		},
+		pageSize: max(1, min(config.MaxLimit, config.GetEnvInt(config.EnvSitePageSize, config.DefaultSitePageSize))),
+	}
+}
+
+// Info describes the site for the page being rendered on siteURL.
+func (s *SiteService) Info(siteURL string) (model.SiteInfo, error) {
+	info := s.info
+	info.URL = siteURL
+	info.Year = time.Now().Year()
+	categories, err := s.categoryRepo.GetAllCategories()
+	if err != nil {
+		return info, err
+	}
+	for _, c := range categories {
+		if c.ParentID == nil {
+			info.Categories = append(info.Categories, model.SiteLink{Name: c.Name, Path: PublicPath(model.SitemapCategory, c.Slug)})
+		}
+	}
+	return info, nil
+}
+
+// decorate links posts to their pages, authors, categories and tags.
+func (s *SiteService) decorate(posts []*model.Post) ([]*model.SitePost, error) {
+	ids := make([]int, 0, len(posts))
+	for _, p := range posts {
+		ids = append(ids, p.UserID)
+	}
+	authors, err := s.postRepo.GetAuthorNames(ids)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.categoryRepo.GetAllCategories()
+	if err != nil {
+		return nil, err
+	}
+	byID := map[int]*model.Category{}
+	for _, c := range categories {
+		byID[c.ID] = c
+	}
+
+	decorated := make([]*model.SitePost, 0, len(posts))
+	for _, p := range posts {
+		sp := &model.SitePost{
+			Post:   p,
+			Path:   PublicPath(model.SitemapPost, strconv.Itoa(p.ID)),
+			Author: model.SiteLink{Name: authors[p.UserID], Path: PublicPath(model.SitemapAuthor, strconv.Itoa(p.UserID))},
+		}
+		if c := byID[p.CategoryID]; c != nil {
+			sp.Category = &model.SiteLink{Name: c.Name, Path: PublicPath(model.SitemapCategory, c.Slug)}
+		}
+		for _, tag := range p.Tags {
+			sp.TagLinks = append(sp.TagLinks, model.SiteLink{Name: tag, Path: PublicPath(model.SitemapTag, utils.Slugify(tag))})
+		}
+		decorated = append(decorated, sp)
+	}
+	return decorated, nil
+}
+
+// pagePath is path with the query parameter key set to value.
+func pagePath(path, key, value string) string {
+	if value == "" {
+		return ""
+	}
+	return path + "?" + url.Values{key: {value}}.Encode()
+}
+
+// listing fills in one page of the published posts that pass filter. An
+// invalid cursor is not found.
+func (s *SiteService) listing(listing *model.SiteListing, filter model.PostFilter, cursor string) (*model.SiteListing, error) {
+	filter.Status = model.PostStatusPublished
+	params := model.PostListParams{Filter: filter, Sort: "published_at", Order: "desc", Limit: s.pageSize}
+	if cursor != "" {
+		c, err := DecodePostCursor(cursor)
+		if err != nil || c.Sort != params.Sort || c.Order != params.Order {
+			return nil, ErrPageNotFound
+		}
+		params.Cursor = c
+	}
+	page, err := s.postService.ListPosts(params)
+	if err != nil {
+		return nil, err
+	}
+	if listing.Posts, err = s.decorate(page.Posts); err != nil {
+		return nil, err
+	}
+	listing.PrevPath = pagePath(listing.Path, "cursor", page.Pagination.PrevCursor)
+	listing.NextPath = pagePath(listing.Path, "cursor", page.Pagination.NextCursor)
+	return listing, nil
+}
+
+// Home lists the latest posts.
+func (s *SiteService) Home(cursor string) (*model.SiteListing, error) {
+	listing := &model.SiteListing{Heading: s.info.Title, Description: s.info.Description, Path: "/", FeedPath: "/api/feeds/rss"}
+	return s.listing(listing, model.PostFilter{}, cursor)
+}
+
+// CategoryArchive lists the posts in the category with slug.
+func (s *SiteService) CategoryArchive(slug, cursor string) (*model.SiteListing, error) {
+	category, err := s.categoryRepo.GetCategoryBySlug(slug)
+	if err == sql.ErrNoRows {
+		return nil, ErrPageNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	listing := &model.SiteListing{
+		Heading:     category.Name,
+		Description: category.Description,
+		Path:        PublicPath(model.SitemapCategory, category.Slug),
+		FeedPath:    "/api/feeds/" + FeedCategory + "/" + strconv.Itoa(category.ID) + "/rss",
+	}
+	return s.listing(listing, model.PostFilter{CategoryID: category.ID}, cursor)
+}
+
+// TagArchive lists the posts with the tag whose slug is slug.
+func (s *SiteService) TagArchive(slug, cursor string) (*model.SiteListing, error) {
+	name, err := s.postRepo.GetTagNameBySlug(slug)
+	if err == sql.ErrNoRows {
+		return nil, ErrPageNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	listing := &model.SiteListing{
+		Heading:  "#" + name,
+		Path:     PublicPath(model.SitemapTag, slug),
+		FeedPath: "/api/feeds/" + FeedTag + "/" + slug + "/rss",
+	}
+	return s.listing(listing, model.PostFilter{Tag: slug}, cursor)
+}
+
+// AuthorArchive lists the posts by the user with id.
+func (s *SiteService) AuthorArchive(id int, cursor string) (*model.SiteListing, error) {
+	names, err := s.postRepo.GetAuthorNames([]int{id})
+	if err != nil {
+		return nil, err
+	}
+	name, ok := names[id]
+	if !ok {
+		return nil, ErrPageNotFound
+	}
+	listing := &model.SiteListing{
+		Heading:  "Posts by " + name,
+		Path:     PublicPath(model.SitemapAuthor, strconv.Itoa(id)),
+		FeedPath: "/api/feeds/" + FeedAuthor + "/" + strconv.Itoa(id) + "/rss",
+	}
+	return s.listing(listing, model.PostFilter{AuthorID: id}, cursor)
+}
+
+// Post returns a published post with the head metadata of its page on
+// siteURL, and counts the view. Drafts and trashed posts are not found.
+func (s *SiteService) Post(id int, siteURL string) (*model.SitePost, *model.PostMetadata, error) {
+	post, err := s.postService.GetPostByID(id)
+	if err == sql.ErrNoRows || (err == nil && post.Status != model.PostStatusPublished) {
+		return nil, nil, ErrPageNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	meta, err := s.seo.DescribePost(post, siteURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	decorated, err := s.decorate([]*model.Post{post})
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.postService.RecordView(id); err != nil {
+		log.Println("site: failed to record post view:", err)
+	}
+	return decorated[0], meta, nil
+}
+
+// Search runs a full-text search over published posts; an empty query finds
+// nothing.
+func (s *SiteService) Search(query string, page int) (*model.SiteSearch, error) {
+	search := &model.SiteSearch{Query: query, Page: page, Posts: []*model.SitePost{}}
+	if query == "" {
+		return search, nil
+	}
+	results, err := s.postService.SearchPosts(model.PostSearchParams{Query: query, Limit: s.pageSize}, page)
+	if err != nil {
+		return nil, err
+	}
+	posts := make([]*model.Post, 0, len(results.Results))
+	for _, r := range results.Results {
+		posts = append(posts, r.Post)
+	}
+	if search.Posts, err = s.decorate(posts); err != nil {
+		return nil, err
+	}
+	for i, r := range results.Results {
+		search.Posts[i].TitleHighlight = r.TitleHighlight
+		search.Posts[i].Snippet = r.Snippet
+	}
+	search.Total = results.Total
+
+	path := "/search?" + url.Values{"q": {query}}.Encode()
+	if page > 1 {
+		search.PrevPath = path
+		if page > 2 {
+			search.PrevPath += "&page=" + strconv.Itoa(page-1)
+		}
+	}
+	if page*s.pageSize < results.Total {
+		search.NextPath = path + "&page=" + strconv.Itoa(page+1)
+	}
+	return search, nil
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wikasdude/blog-backend/config"
+	"github.com/wikasdude/blog-backend/events"
+	"github.com/wikasdude/blog-backend/model"
+	repository "github.com/wikasdude/blog-backend/repositories"
+	"github.com/wikasdude/blog-backend/sitemap"
+)
+
+var ErrSitemapNotFound = errors.New("sitemap not found")
+
+// PublicPath is the path of a page of the public site. Without the built-in
+// site, the pages are expected to be served by a separate front end, at the
+// paths PUBLIC_*_PATH configure.
+func PublicPath(kind, ref string) string {
+	pattern, ok := publicPaths()[kind]
+	if !ok {
+		return "/"
+	}
+	return strings.ReplaceAll(pattern, "{ref}", ref)
+}
+
+// publicPaths maps page kinds to path patterns, in which "{ref}" stands for
+// the post or author ID or the category or tag slug. The built-in site only
+// serves the default paths, so they cannot be changed while it is enabled.
+var publicPaths = sync.OnceValue(func() map[string]string {
+	paths := map[string]string{
+		model.SitemapPost:     config.DefaultPublicPostPath,
+		model.SitemapCategory: config.DefaultPublicCategoryPath,
+		model.SitemapTag:      config.DefaultPublicTagPath,
+		model.SitemapAuthor:   config.DefaultPublicAuthorPath,
+	}
+	if config.GetEnvBool(config.EnvSiteEnabled, false) {
+		return paths
+	}
+	for kind, env := range map[string]string{
+		model.SitemapPost:     config.EnvPublicPostPath,
+		model.SitemapCategory: config.EnvPublicCategoryPath,
+		model.SitemapTag:      config.EnvPublicTagPath,
+		model.SitemapAuthor:   config.EnvPublicAuthorPath,
+	} {
+		if path := config.GetEnv(env, ""); path != "" {
+			paths[kind] = path
+		}
+	}
+	return paths
+})
+
+// SitemapService lists the public pages for search engines. The page list
+// is cached and rebuilt once posts are published, changed or deleted, or
+// after the TTL for changes no event announces, such as a renamed category.
+type SitemapService struct {
+	sitemapRepo *repository.SitemapRepository
+	ttl         time.Duration
+
+	robotsAllow    []string
+	robotsDisallow []string
+	robotsBlockAll bool
+
+	// generation goes up with every post change; the cache is fresh while
+	// it was built at the current generation
+	generation atomic.Int64
+	mu         sync.Mutex
+	entries    []model.SitemapEntry
+	builtAt    time.Time
+	builtGen   int64
+}
+
+// NewSitemapService subscribes the sitemap cache to post events on bus.
+func NewSitemapService(sitemapRepo *repository.SitemapRepository, bus *events.Bus) *SitemapService {
+	s := &SitemapService{
+		sitemapRepo:    sitemapRepo,
+		ttl:            config.GetEnvDuration(config.EnvSitemapTTL, config.DefaultSitemapTTL),
+		robotsAllow:    splitList(config.GetEnv(config.EnvRobotsAllow, config.DefaultRobotsAllow)),
+		robotsDisallow: splitList(config.GetEnv(config.EnvRobotsDisallow, config.DefaultRobotsDisallow)),
+		robotsBlockAll: config.GetEnvBool(config.EnvRobotsBlockAll, false),
+	}
+	for _, t := range []string{events.PostPublished, events.PostUpdated, events.PostDeleted} {
+		bus.Subscribe(t, s.invalidate)
+	}
+	return s
+}
+
+// splitList splits a comma separated setting, dropping empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (s *SitemapService) invalidate(events.Event) {
+	s.generation.Add(1)
+}
+
+// cachedEntries returns the page list, rebuilding it when stale. Concurrent
+// callers wait for one rebuild instead of each running the query.
+func (s *SitemapService) cachedEntries() ([]model.SitemapEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	generation := s.generation.Load()
+	if !s.builtAt.IsZero() && s.builtGen == generation && (s.ttl <= 0 || time.Since(s.builtAt) < s.ttl) {
+		return s.entries, nil
+	}
+	entries, err := s.sitemapRepo.Entries()
+	if err != nil {
+		return nil, err
+	}
+	s.entries, s.builtAt, s.builtGen = entries, time.Now(), generation
+	return entries, nil
+}
+
+// latest returns the newest LastMod among urls.
+func latest(urls []sitemap.URL) time.Time {
+	var t time.Time
+	for _, u := range urls {
+		if u.LastMod.After(t) {
+			t = u.LastMod
+		}
+	}
+	return t
+}
+
+// Sitemap returns the given sitemap page of the site at siteURL with its
+// last modification time. Page 0 is /sitemap.xml: the whole sitemap while
+// it fits in sitemap.MaxURLs, or else an index of the numbered pages, each
+// listing up to sitemap.MaxURLs URLs.
+func (s *SitemapService) Sitemap(siteURL string, page int) ([]byte, time.Time, error) {
+	entries, err := s.cachedEntries()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	site := strings.TrimRight(siteURL, "/")
+	urls := make([]sitemap.URL, 0, len(entries)+1)
+	urls = append(urls, sitemap.URL{Loc: site + "/"})
+	for _, e := range entries {
+		urls = append(urls, sitemap.URL{Loc: site + PublicPath(e.Kind, e.Ref), LastMod: e.LastMod})
+	}
+	urls[0].LastMod = latest(urls)
+
+	pages := (len(urls) + sitemap.MaxURLs - 1) / sitemap.MaxURLs
+	if page == 0 && pages == 1 {
+		body, err := sitemap.URLSet(urls)
+		return body, urls[0].LastMod, err
+	}
+	if page == 0 {
+		index := make([]sitemap.URL, pages)
+		for i := range index {
+			end := (i + 1) * sitemap.MaxURLs
+			if end > len(urls) {
+				end = len(urls)
+			}
+			index[i] = sitemap.URL{Loc: site + "/sitemaps/" + strconv.Itoa(i+1) + ".xml", LastMod: latest(urls[i*sitemap.MaxURLs : end])}
+		}
+		body, err := sitemap.Index(index)
+		return body, urls[0].LastMod, err
+	}
+	if page < 0 || page > pages {
+		return nil, time.Time{}, ErrSitemapNotFound
+	}
+	end := page * sitemap.MaxURLs
+	if end > len(urls) {
+		end = len(urls)
+	}
+	chunk := urls[(page-1)*sitemap.MaxURLs : end]
+	body, err := sitemap.URLSet(chunk)
+	return body, latest(chunk), err
+}
+
+// Robots returns robots.txt for the site at siteURL, as configured by the
+// ROBOTS_* settings.
+func (s *SitemapService) Robots(siteURL string) []byte {
+	return sitemap.Robots(s.robotsAllow, s.robotsDisallow, s.robotsBlockAll, strings.TrimRight(siteURL, "/")+"/sitemap.xml")
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wikasdude/blog-backend/config"
+	"github.com/wikasdude/blog-backend/events"
+	"github.com/wikasdude/blog-backend/model"
+	repository "github.com/wikasdude/blog-backend/repositories"
+)
+
+var ErrStreamTopic = errors.New("topic must be all, category:<id>, author:<id> or post:<id>")
+
+// StreamSubscription receives the live events matching its topics on C.
+// C is closed when the subscriber falls too far behind; the client should
+// then reconnect and resume with Last-Event-ID.
+type StreamSubscription struct {
+	C      chan *model.StreamEvent
+	topics []model.StreamTopic
+}
+
+func (sub *StreamSubscription) wants(e *model.StreamEvent) bool {
+	for _, t := range sub.topics {
+		if t.Matches(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// StreamService records public post and comment events and fans them out to
+// Server-Sent Events subscribers. Events reach subscribers through Postgres
+// LISTEN/NOTIFY, so every instance sees every event, wherever it happened.
+type StreamService struct {
+	streamRepo *repository.StreamRepository
+	postRepo   *repository.PostRepository
+
+	mu          sync.Mutex
+	subscribers map[*StreamSubscription]bool
+	lastID      int64
+}
+
+// NewStreamService subscribes the stream to bus. Call Run to start delivering.
+func NewStreamService(streamRepo *repository.StreamRepository, postRepo *repository.PostRepository, bus *events.Bus) *StreamService {
+	s := &StreamService{
+		streamRepo:  streamRepo,
+		postRepo:    postRepo,
+		subscribers: map[*StreamSubscription]bool{},
+	}
+	for _, t := range []string{events.PostPublished, events.PostUpdated, events.PostDeleted, events.CommentCreated, events.CommentUpdated, events.CommentDeleted} {
+		bus.Subscribe(t, s.record)
+	}
+	return s
+}
+
+// record stores a domain event in the stream log. Only changes readers can
+// see are streamed: events about drafts are dropped.
+func (s *StreamService) record(e events.Event) {
+	var post *model.Post
+	var err error
+	if e.Type == events.PostDeleted {
+		post, err = s.postRepo.GetTrashedPost(e.PostID)
+	} else {
+		post, err = s.postRepo.GetPostByID(e.PostID)
+	}
+	if err == sql.ErrNoRows || (err == nil && post.Status != model.PostStatusPublished) {
+		return
+	}
+	if err != nil {
+		log.Printf("stream: %s: %v", e.Type, err)
+		return
+	}
+
+	se := &model.StreamEvent{Type: e.Type, PostID: post.ID, AuthorID: post.UserID, CategoryID: post.CategoryID, Title: post.Title}
+	if e.CommentID != 0 {
+		se.CommentID = &e.CommentID
+	}
+	if err := s.streamRepo.Append(se); err != nil {
+		log.Printf("stream: %s: %v", e.Type, err)
+	}
+}
+
+// ParseStreamTopics reads topics such as "all", "category:3", "author:7" or
+// "post:12". No topics means all.
+func ParseStreamTopics(values []string) ([]model.StreamTopic, error) {
+	var topics []model.StreamTopic
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			if item == model.TopicAll {
+				topics = append(topics, model.StreamTopic{Kind: model.TopicAll})
+				continue
+			}
+			kind, ref, _ := strings.Cut(item, ":")
+			id, err := strconv.Atoi(ref)
+			if err != nil || (kind != model.TopicCategory && kind != model.TopicAuthor && kind != model.TopicPost) {
+				return nil, fmt.Errorf("%w: %q", ErrStreamTopic, item)
+			}
+			topics = append(topics, model.StreamTopic{Kind: kind, ID: id})
+		}
+	}
+	if len(topics) == 0 {
+		topics = []model.StreamTopic{{Kind: model.TopicAll}}
+	}
+	return topics, nil
+}
+
+// Subscribe starts buffering live events matching topics. The caller must
+// Unsubscribe when done.
+func (s *StreamService) Subscribe(topics []model.StreamTopic) *StreamSubscription {
+	sub := &StreamSubscription{C: make(chan *model.StreamEvent, config.StreamBuffer), topics: topics}
+	s.mu.Lock()
+	s.subscribers[sub] = true
+	s.mu.Unlock()
+	return sub
+}
+
+func (s *StreamService) Unsubscribe(sub *StreamSubscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subscribers[sub] {
+		delete(s.subscribers, sub)
+		close(sub.C)
+	}
+}
+
+// Replay returns the stored events after lastID that match topics, oldest
+// first, so a reconnecting client can catch up.
+func (s *StreamService) Replay(lastID int64, topics []model.StreamTopic) ([]*model.StreamEvent, error) {
+	stored, err := s.streamRepo.Since(lastID, config.StreamReplayLimit)
+	if err != nil {
+		return nil, err
+	}
+	sub := StreamSubscription{topics: topics}
+	replay := []*model.StreamEvent{}
+	for _, e := range stored {
+		if sub.wants(e) {
+			replay = append(replay, e)
+		}
+	}
+	return replay, nil
+}
+
+// broadcast hands e to every interested subscriber. Subscribers whose buffer
+// is full are dropped rather than allowed to hold up the others.
+func (s *StreamService) broadcast(e *model.StreamEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e.ID > s.lastID {
+		s.lastID = e.ID
+	}
+	for sub := range s.subscribers {
+		if !sub.wants(e) {
+			continue
+		}
+		select {
+		case sub.C <- e:
+		default:
+			delete(s.subscribers, sub)
+			close(sub.C)
+		}
+	}
+}
+
+// catchUp broadcasts the events stored since the last one delivered, for
+// when notifications may have been lost while the listener reconnected.
+func (s *StreamService) catchUp() {
+	s.mu.Lock()
+	lastID := s.lastID
+	s.mu.Unlock()
+
+	missed, err := s.streamRepo.Since(lastID, config.StreamReplayLimit)
+	if err != nil {
+		log.Println("stream: catching up:", err)
+		return
+	}
+	for _, e := range missed {
+		s.broadcast(e)
+	}
+}
+
+// Run listens for events from every instance on dsn and delivers them to
+// this instance's subscribers until stop is closed. Events older than
+// retention are pruned along the way.
+func (s *StreamService) Run(dsn string, retention time.Duration, stop <-chan struct{}) {
+	lastID, err := s.streamRepo.LatestID()
+	if err != nil {
+		log.Println("stream:", err)
+	}
+	s.mu.Lock()
+	s.lastID = lastID
+	s.mu.Unlock()
+
+	if retention > 0 {
+		go func() {
+			ticker := time.NewTicker(time.Hour)
+			defer ticker.Stop()
+			for {
+				if _, err := s.streamRepo.PruneBefore(time.Now().Add(-retention)); err != nil {
+					log.Println("stream: pruning:", err)
+				}
+				select {
+				case <-ticker.C:
+				case <-stop:
+					return
+				}
+			}
+		}()
+	}
+
+	if err := s.streamRepo.Listen(dsn, s.broadcast, s.catchUp, stop); err != nil {
+		log.Println("stream: listening:", err)
+	}
+}
@@ -2,9 +2,18 @@ package service
 
 import (
 	"errors"
+	"strconv"
+	"strings"
 
+	"github.com/wikasdude/blog-backend/events"
 	"github.com/wikasdude/blog-backend/model"
 	repository "github.com/wikasdude/blog-backend/repositories"
+	"github.com/wikasdude/blog-backend/utils"
+)
+
+var (
+	ErrInvalidHandle = errors.New("handle may only hold lower-case letters, digits, '_', '.' and '-', and must not start or end with '.' or '-'")
+	ErrHandleTaken   = errors.New("handle is already taken")
 )
 
 type UserService interface {
@@ -16,16 +25,30 @@ type UserService interface {
 }
 
 type userService struct {
-	repo repository.UserRepository
+	repo   repository.UserRepository
+	events *events.Bus
 }
 
-func NewUserService(repo repository.UserRepository) UserService {
-	return &userService{repo: repo}
+func NewUserService(repo repository.UserRepository, bus *events.Bus) UserService {
+	return &userService{repo: repo, events: bus}
 }
 
 func (s *userService) RegisterUser(user *model.User) error {
 	// Add validation or password hashing here
-	return s.repo.CreateUser(user)
+	if user.Handle == "" {
+		handle, err := s.freeHandle(utils.HandleFrom(user.Name))
+		if err != nil {
+			return err
+		}
+		user.Handle = handle
+	} else if err := s.checkHandle(user); err != nil {
+		return err
+	}
+	if err := s.repo.CreateUser(user); err != nil {
+		return err
+	}
+	s.events.Publish(events.Event{Type: events.UserRegistered, ActorID: user.ID, UserID: user.ID})
+	return nil
 }
 
 func (s *userService) GetUserByID(id string) (*model.User, error) {
@@ -39,8 +62,51 @@ func (s *userService) UpdateUser(user *model.User) error {
 	if exists {
 		return errors.New("email is already taken")
 	}
+	if user.Handle == "" {
+		current, err := s.repo.GetUserByID(strconv.Itoa(user.ID))
+		if err != nil {
+			return err
+		}
+		user.Handle = current.Handle
+	} else if err := s.checkHandle(user); err != nil {
+		return err
+	}
 	return s.repo.UpdateUser(user)
 }
+
+// checkHandle lower-cases the handle a user chose and makes sure it is
+// valid and nobody else has it.
+func (s *userService) checkHandle(user *model.User) error {
+	user.Handle = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(user.Handle), "@"))
+	if !utils.IsValidHandle(user.Handle) {
+		return ErrInvalidHandle
+	}
+	taken, err := s.repo.IsHandleTaken(user.Handle, user.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrHandleTaken
+	}
+	return nil
+}
+
+// freeHandle returns base, or base numbered "-2", "-3" and so on when it is
+// taken, for users who did not choose a handle.
+func (s *userService) freeHandle(base string) (string, error) {
+	if base == "" {
+		base = "user"
+	}
+	candidate := base
+	for n := 2; ; n++ {
+		taken, err := s.repo.IsHandleTaken(candidate, 0)
+		if err != nil || !taken {
+			return candidate, err
+		}
+		suffix := "-" + strconv.Itoa(n)
+		candidate = strings.TrimRight(base[:min(len(base), utils.MaxHandleLength-len(suffix))], "._-") + suffix
+	}
+}
 func (s *userService) DeleteUser(id int) error {
 	return s.repo.DeleteUser(id)
 }
@@ -0,0 +1,392 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wikasdude/blog-backend/config"
+	"github.com/wikasdude/blog-backend/events"
+	"github.com/wikasdude/blog-backend/model"
+	repository "github.com/wikasdude/blog-backend/repositories"
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrWebhookInvalid   = errors.New("invalid webhook")
+)
+
+// WebhookEvents are the event types webhooks can subscribe to.
+var WebhookEvents = []string{events.PostCreated, events.PostPublished, events.PostDeleted, events.UserRegistered, events.CommentCreated}
+
+// WebhookService sends domain events to the URLs admins subscribe. Events are
+// queued as delivery rows when they happen and sent by RunDispatcher, which
+// retries failures with exponential backoff.
+type WebhookService struct {
+	webhookRepo *repository.WebhookRepository
+	postRepo    *repository.PostRepository
+	commentRepo *repository.CommentRepository
+	userRepo    repository.UserRepository
+
+	client       *http.Client
+	maxAttempts  int
+	disableAfter int
+	wake         chan struct{}
+}
+
+// NewWebhookService subscribes webhooks to bus. Call RunDispatcher to start sending.
+func NewWebhookService(webhookRepo *repository.WebhookRepository, postRepo *repository.PostRepository, commentRepo *repository.CommentRepository, userRepo repository.UserRepository, bus *events.Bus) *WebhookService {
+	s := &WebhookService{
+		webhookRepo:  webhookRepo,
+		postRepo:     postRepo,
+		commentRepo:  commentRepo,
+		userRepo:     userRepo,
+		client:       &http.Client{Timeout: config.WebhookTimeout},
+		maxAttempts:  config.GetEnvInt(config.EnvWebhookMaxAttempts, config.DefaultWebhookMaxAttempts),
+		disableAfter: config.GetEnvInt(config.EnvWebhookDisableAfter, config.DefaultWebhookDisableAfter),
+		wake:         make(chan struct{}, 1),
+	}
+	if s.maxAttempts < 1 {
+		s.maxAttempts = config.DefaultWebhookMaxAttempts
+	}
+	if s.disableAfter < 1 {
+		s.disableAfter = config.DefaultWebhookDisableAfter
+	}
+	for _, t := range WebhookEvents {
+		bus.Subscribe(t, s.enqueue)
+	}
+	return s
+}
+
+func newWebhookID(prefix string, size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
+
+// eventData loads what a webhook receives about an event.
+func (s *WebhookService) eventData(e events.Event) (interface{}, error) {
+	switch e.Type {
+	case events.PostDeleted:
+		return s.postRepo.GetTrashedPost(e.PostID)
+	case events.PostCreated, events.PostPublished:
+		return s.postRepo.GetPostByID(e.PostID)
+	case events.CommentCreated:
+		return s.commentRepo.GetCommentByID(e.CommentID)
+	case events.UserRegistered:
+		return s.userRepo.GetUserByID(strconv.Itoa(e.UserID))
+	}
+	return nil, fmt.Errorf("unsupported event %s", e.Type)
+}
+
+// enqueue queues an event for every webhook subscribed to it and wakes the
+// dispatcher. A failure here is logged; it must never fail the action that
+// caused the event.
+func (s *WebhookService) enqueue(e events.Event) {
+	data, err := s.eventData(e)
+	if err == sql.ErrNoRows {
+		return
+	}
+	if err != nil {
+		log.Printf("webhooks: %s: %v", e.Type, err)
+		return
+	}
+	id, err := newWebhookID("evt_", 12)
+	if err != nil {
+		log.Printf("webhooks: %s: %v", e.Type, err)
+		return
+	}
+	payload, err := json.Marshal(model.WebhookPayload{ID: id, Type: e.Type, OccurredAt: e.At.UTC(), Data: data})
+	if err != nil {
+		log.Printf("webhooks: %s: %v", e.Type, err)
+		return
+	}
+	queued, err := s.webhookRepo.EnqueueEvent(e.Type, payload)
+	if err != nil {
+		log.Printf("webhooks: %s: %v", e.Type, err)
+		return
+	}
+	if queued > 0 {
+		s.poke()
+	}
+}
+
+// poke wakes the dispatcher without waiting for it.
+func (s *WebhookService) poke() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Sign returns the X-Webhook-Signature value for body sent at timestamp:
+// "sha256=" and the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the
+// webhook's secret. Receivers should recompute it and compare in constant time.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff is the wait after the given number of failed attempts.
+func webhookBackoff(attempts int) time.Duration {
+	wait := config.WebhookBackoff
+	for i := 1; i < attempts && wait < config.WebhookMaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > config.WebhookMaxBackoff {
+		wait = config.WebhookMaxBackoff
+	}
+	return wait
+}
+
+// send makes one delivery attempt. Any 2xx response is a success.
+func (s *WebhookService) send(d *repository.DueDelivery) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "blog-backend-webhooks/1")
+	req.Header.Set("X-Webhook-Event", d.EventType)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(d.ID, 10))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", Sign(d.Secret, timestamp, d.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// deliver sends one claimed delivery and records the outcome.
+func (s *WebhookService) deliver(d *repository.DueDelivery) {
+	code, err := s.send(d)
+	if err == nil {
+		if err := s.webhookRepo.MarkDelivered(d, code); err != nil {
+			log.Printf("webhooks: delivery %d: %v", d.ID, err)
+		}
+		return
+	}
+
+	var statusCode *int
+	if code != 0 {
+		statusCode = &code
+	}
+	var retryAfter time.Duration
+	if attempts := d.Attempts + 1; attempts < s.maxAttempts {
+		retryAfter = webhookBackoff(attempts)
+	}
+	disabled, markErr := s.webhookRepo.MarkFailed(d, statusCode, err.Error(), retryAfter, s.disableAfter)
+	if markErr != nil {
+		log.Printf("webhooks: delivery %d: %v", d.ID, markErr)
+	}
+	if disabled {
+		log.Printf("webhooks: webhook %d disabled after %d failed deliveries in a row", d.WebhookID, s.disableAfter)
+	}
+}
+
+// dispatch sends every delivery that is due. Deliveries are claimed one at a
+// time, just before they are sent, so that the claim only has to outlast one
+// request and other instances can send the rest meanwhile.
+func (s *WebhookService) dispatch() {
+	// a claim outlasts the request timeout so a slow endpoint is not sent twice
+	lease := 2 * config.WebhookTimeout
+	for {
+		due, err := s.webhookRepo.ClaimDueDeliveries(1, lease)
+		if err != nil {
+			log.Println("webhooks: claiming deliveries:", err)
+			return
+		}
+		if len(due) == 0 {
+			return
+		}
+		s.deliver(due[0])
+	}
+}
+
+// RunDispatcher sends due deliveries every interval, and as soon as new ones
+// are queued, until stop is closed. Pending deliveries survive restarts.
+func (s *WebhookService) RunDispatcher(interval time.Duration, stop <-chan struct{}) {
+	if interval <= 0 {
+		interval = config.DefaultWebhookPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.dispatch()
+		select {
+		case <-ticker.C:
+		case <-s.wake:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// applyWebhookRequest copies the fields sent in req onto w and validates them.
+func applyWebhookRequest(w *model.Webhook, req model.WebhookRequest) error {
+	if req.URL != nil {
+		w.URL = strings.TrimSpace(*req.URL)
+	}
+	if req.Description != nil {
+		w.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.Events != nil {
+		w.Events = req.Events
+	}
+	if req.Active != nil {
+		w.Active = *req.Active
+	}
+
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrWebhookInvalid)
+	}
+	if len(w.Events) == 0 {
+		return fmt.Errorf("%w: subscribe to at least one of %s", ErrWebhookInvalid, strings.Join(WebhookEvents, ", "))
+	}
+	seen := map[string]bool{}
+	subscribed := []string{}
+	for _, e := range w.Events {
+		known := false
+		for _, t := range WebhookEvents {
+			if t == e {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("%w: unknown event %q", ErrWebhookInvalid, e)
+		}
+		if !seen[e] {
+			seen[e] = true
+			subscribed = append(subscribed, e)
+		}
+	}
+	w.Events = subscribed
+	return nil
+}
+
+// CreateWebhook registers a webhook. The returned webhook carries its secret,
+// which is not shown again.
+func (s *WebhookService) CreateWebhook(req model.WebhookRequest, createdBy int) (*model.Webhook, error) {
+	w := &model.Webhook{Active: true, CreatedBy: &createdBy}
+	if err := applyWebhookRequest(w, req); err != nil {
+		return nil, err
+	}
+	secret, err := newWebhookID("whsec_", 24)
+	if err != nil {
+		return nil, err
+	}
+	w.Secret = secret
+	if err := s.webhookRepo.CreateWebhook(w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (s *WebhookService) Webhooks() ([]*model.Webhook, error) {
+	return s.webhookRepo.ListWebhooks()
+}
+
+func (s *WebhookService) GetWebhook(id int) (*model.Webhook, error) {
+	w, err := s.webhookRepo.GetWebhook(id)
+	if err == sql.ErrNoRows {
+		return nil, ErrWebhookNotFound
+	}
+	return w, err
+}
+
+// UpdateWebhook changes the fields sent in req. The secret is returned only
+// when it was rotated.
+func (s *WebhookService) UpdateWebhook(id int, req model.WebhookRequest) (*model.Webhook, error) {
+	w, err := s.GetWebhook(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyWebhookRequest(w, req); err != nil {
+		return nil, err
+	}
+	if req.RotateSecret {
+		if w.Secret, err = newWebhookID("whsec_", 24); err != nil {
+			return nil, err
+		}
+	}
+	err = s.webhookRepo.UpdateWebhook(w)
+	if err == sql.ErrNoRows {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if w.Active {
+		// deliveries held while it was disabled are due again
+		s.poke()
+	}
+	return w, nil
+}
+
+func (s *WebhookService) DeleteWebhook(id int) error {
+	err := s.webhookRepo.DeleteWebhook(id)
+	if err == sql.ErrNoRows {
+		return ErrWebhookNotFound
+	}
+	return err
+}
+
+// Deliveries lists a webhook's delivery log, newest first, optionally only
+// deliveries with the given status.
+func (s *WebhookService) Deliveries(webhookID int, status string, page, limit int) (*model.WebhookDeliveryPage, error) {
+	switch status {
+	case "", model.DeliveryPending, model.DeliverySucceeded, model.DeliveryFailed:
+	default:
+		return nil, fmt.Errorf("%w: status must be pending, succeeded or failed", ErrWebhookInvalid)
+	}
+	if _, err := s.GetWebhook(webhookID); err != nil {
+		return nil, err
+	}
+	deliveries, total, err := s.webhookRepo.ListDeliveries(webhookID, status, limit, (page-1)*limit)
+	if err != nil {
+		return nil, err
+	}
+	return &model.WebhookDeliveryPage{Deliveries: deliveries, Total: total, Page: page, Limit: limit}, nil
+}
+
+// Replay queues a delivery to be sent again as a new delivery, with the same
+// payload and event id, and returns the new delivery's id.
+func (s *WebhookService) Replay(webhookID int, deliveryID int64) (int64, error) {
+	id, err := s.webhookRepo.ReplayDelivery(webhookID, deliveryID)
+	if err == sql.ErrNoRows {
+		return 0, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	s.poke()
+	return id, nil
+}
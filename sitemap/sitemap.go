@@ -0,0 +1,93 @@
+// Package sitemap writes sitemaps and sitemap indexes following the
+// sitemaps.org protocol, and robots.txt files pointing at them.
+package sitemap
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+)
+
+// MaxURLs is the most URLs one sitemap may list; larger sites are split
+// into several sitemaps behind a sitemap index.
+const MaxURLs = 50000
+
+const namespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// URL is one page listed in a sitemap, or one sitemap listed in an index.
+// A zero LastMod is left out.
+type URL struct {
+	Loc     string
+	LastMod time.Time
+}
+
+type xmlURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type urlSet struct {
+	XMLName xml.Name `xml:"urlset"`
+	NS      string   `xml:"xmlns,attr"`
+	URLs    []xmlURL `xml:"url"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name `xml:"sitemapindex"`
+	NS       string   `xml:"xmlns,attr"`
+	Sitemaps []xmlURL `xml:"sitemap"`
+}
+
+func toXML(urls []URL) []xmlURL {
+	out := make([]xmlURL, len(urls))
+	for i, u := range urls {
+		out[i] = xmlURL{Loc: u.Loc}
+		if !u.LastMod.IsZero() {
+			out[i].LastMod = u.LastMod.UTC().Format(time.RFC3339)
+		}
+	}
+	return out
+}
+
+func marshal(doc interface{}) ([]byte, error) {
+	b, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(b, '\n')...), nil
+}
+
+// URLSet writes a sitemap listing urls, which should number at most MaxURLs.
+func URLSet(urls []URL) ([]byte, error) {
+	return marshal(urlSet{NS: namespace, URLs: toXML(urls)})
+}
+
+// Index writes a sitemap index listing the given sitemaps.
+func Index(sitemaps []URL) ([]byte, error) {
+	return marshal(sitemapIndex{NS: namespace, Sitemaps: toXML(sitemaps)})
+}
+
+// Robots writes a robots.txt for all user agents. With blockAll every path
+// is disallowed, e.g. for a staging site; otherwise allow and disallow list
+// path prefixes. sitemapURL, when set, tells crawlers where the sitemap is.
+func Robots(allow, disallow []string, blockAll bool, sitemapURL string) []byte {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	if blockAll {
+		b.WriteString("Disallow: /\n")
+	} else {
+		for _, p := range allow {
+			b.WriteString("Allow: " + p + "\n")
+		}
+		for _, p := range disallow {
+			b.WriteString("Disallow: " + p + "\n")
+		}
+		if len(allow) == 0 && len(disallow) == 0 {
+			b.WriteString("Disallow:\n")
+		}
+	}
+	if sitemapURL != "" && !blockAll {
+		b.WriteString("\nSitemap: " + sitemapURL + "\n")
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,170 @@
+package spam
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// TokenCount is how many spam and ham documents a token appeared in.
+type TokenCount struct {
+	Spam int
+	Ham  int
+}
+
+// Bayes is a naive Bayes filter in the style of Paul Graham's "A Plan for
+// Spam" with Robinson's smoothing for rare tokens. It is safe for concurrent
+// use. Until both classes have MinDocs training documents it returns ham.
+type Bayes struct {
+	SpamThreshold    float64
+	SuspectThreshold float64
+	MinDocs          int
+
+	mu       sync.RWMutex
+	tokens   map[string]TokenCount
+	spamDocs int
+	hamDocs  int
+}
+
+// interestingTokens is how many of the most decisive tokens are combined.
+const interestingTokens = 15
+
+// NewBayes returns a filter seeded with previously stored training counts.
+func NewBayes(tokens map[string]TokenCount, spamDocs, hamDocs int) *Bayes {
+	if tokens == nil {
+		tokens = map[string]TokenCount{}
+	}
+	return &Bayes{
+		SpamThreshold:    0.95,
+		SuspectThreshold: 0.7,
+		MinDocs:          20,
+		tokens:           tokens,
+		spamDocs:         spamDocs,
+		hamDocs:          hamDocs,
+	}
+}
+
+// Train counts each distinct token of text once as spam or ham.
+func (b *Bayes) Train(text string, isSpam bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, t := range UniqueTokens(text) {
+		c := b.tokens[t]
+		if isSpam {
+			c.Spam++
+		} else {
+			c.Ham++
+		}
+		b.tokens[t] = c
+	}
+	if isSpam {
+		b.spamDocs++
+	} else {
+		b.hamDocs++
+	}
+}
+
+// Untrain takes back a Train of a document with the given distinct tokens.
+// Counts never drop below zero.
+func (b *Bayes) Untrain(tokens []string, isSpam bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, t := range tokens {
+		c, ok := b.tokens[t]
+		if !ok {
+			continue
+		}
+		if isSpam {
+			c.Spam = max(0, c.Spam-1)
+		} else {
+			c.Ham = max(0, c.Ham-1)
+		}
+		if c.Spam == 0 && c.Ham == 0 {
+			delete(b.tokens, t)
+		} else {
+			b.tokens[t] = c
+		}
+	}
+	if isSpam {
+		b.spamDocs = max(0, b.spamDocs-1)
+	} else {
+		b.hamDocs = max(0, b.hamDocs-1)
+	}
+}
+
+// Score returns the probability that text is spam, 0.5 when untrained.
+func (b *Bayes) Score(text string) float64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.spamDocs == 0 || b.hamDocs == 0 {
+		return 0.5
+	}
+
+	var probs []float64
+	for _, t := range UniqueTokens(text) {
+		c, ok := b.tokens[t]
+		if !ok {
+			continue
+		}
+		spamFreq := float64(c.Spam) / float64(b.spamDocs)
+		hamFreq := float64(c.Ham) / float64(b.hamDocs)
+		p := spamFreq / (spamFreq + hamFreq)
+		// Robinson: pull tokens seen only a few times towards 0.5
+		n := float64(c.Spam + c.Ham)
+		p = (0.5 + n*p) / (1 + n)
+		probs = append(probs, math.Min(0.99, math.Max(0.01, p)))
+	}
+	if len(probs) == 0 {
+		return 0.5
+	}
+	sort.Slice(probs, func(i, j int) bool {
+		return math.Abs(probs[i]-0.5) > math.Abs(probs[j]-0.5)
+	})
+	if len(probs) > interestingTokens {
+		probs = probs[:interestingTokens]
+	}
+
+	// combine in log space: p1...pn / (p1...pn + (1-p1)...(1-pn))
+	var logSpam, logHam float64
+	for _, p := range probs {
+		logSpam += math.Log(p)
+		logHam += math.Log(1 - p)
+	}
+	return 1 / (1 + math.Exp(logHam-logSpam))
+}
+
+func (b *Bayes) Classify(in Input) Verdict {
+	b.mu.RLock()
+	trained := b.spamDocs >= b.MinDocs && b.hamDocs >= b.MinDocs
+	b.mu.RUnlock()
+	if !trained {
+		return Verdict{Level: Ham}
+	}
+
+	score := b.Score(in.Body)
+	v := Verdict{Level: Ham, Score: score}
+	switch {
+	case score >= b.SpamThreshold:
+		v.Level = Spam
+	case score >= b.SuspectThreshold:
+		v.Level = Suspect
+	default:
+		return v
+	}
+	v.Reasons = []string{fmt.Sprintf("spam probability %.2f", score)}
+	return v
+}
+
+// UniqueTokens returns the distinct tokens of text in first-seen order.
+func UniqueTokens(text string) []string {
+	seen := map[string]bool{}
+	var tokens []string
+	for _, t := range Tokenize(text) {
+		if !seen[t] {
+			seen[t] = true
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,41 @@
+package spam
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBayesUntrain(t *testing.T) {
+	b := NewBayes(map[string]TokenCount{"cheap": {Spam: 2, Ham: 1}}, 2, 1)
+	text := "cheap pills, cheap!"
+
+	b.Train(text, true)
+	if got := b.tokens["cheap"]; got != (TokenCount{Spam: 3, Ham: 1}) {
+		t.Fatalf("after Train, cheap = %+v", got)
+	}
+	b.Untrain(UniqueTokens(text), true)
+	want := map[string]TokenCount{"cheap": {Spam: 2, Ham: 1}}
+	if !reflect.DeepEqual(b.tokens, want) || b.spamDocs != 2 || b.hamDocs != 1 {
+		t.Errorf("after Untrain, tokens = %+v, docs = %d/%d; want %+v, 2/1", b.tokens, b.spamDocs, b.hamDocs, want)
+	}
+
+	// untraining what was never trained leaves counts at zero
+	b.Untrain([]string{"cheap", "unknown"}, false)
+	b.Untrain([]string{"cheap"}, false)
+	if got := b.tokens["cheap"]; got != (TokenCount{Spam: 2}) {
+		t.Errorf("cheap = %+v, want spam 2 and ham 0", got)
+	}
+	if b.hamDocs != 0 {
+		t.Errorf("hamDocs = %d, want 0", b.hamDocs)
+	}
+}
+
+func TestChainUntrain(t *testing.T) {
+	b := NewBayes(nil, 0, 0)
+	chain := Chain{Rules{}, b}
+	chain.Train("buy now", false)
+	chain.Untrain(UniqueTokens("buy now"), false)
+	if len(b.tokens) != 0 || b.hamDocs != 0 {
+		t.Errorf("after Untrain, tokens = %+v, hamDocs = %d", b.tokens, b.hamDocs)
+	}
+}
@@ -0,0 +1,42 @@
+package spam
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rules flags comments containing blocked words as spam and holds comments
+// with more than MaxLinks links for moderation. A negative MaxLinks disables
+// the link check.
+type Rules struct {
+	BlockedWords []string
+	MaxLinks     int
+}
+
+func (r Rules) Classify(in Input) Verdict {
+	body := strings.ToLower(in.Body)
+	words := map[string]bool{}
+	for _, t := range Tokenize(body) {
+		words[t] = true
+	}
+
+	var blocked []string
+	for _, w := range r.BlockedWords {
+		w = strings.ToLower(strings.TrimSpace(w))
+		if w == "" {
+			continue
+		}
+		// phrases match anywhere, single words only as whole words
+		if words[w] || (strings.Contains(w, " ") && strings.Contains(body, w)) {
+			blocked = append(blocked, w)
+		}
+	}
+	if len(blocked) > 0 {
+		return Verdict{Level: Spam, Score: 1, Reasons: []string{"blocked words: " + strings.Join(blocked, ", ")}}
+	}
+
+	if links := CountLinks(in.Body); r.MaxLinks >= 0 && links > r.MaxLinks {
+		return Verdict{Level: Suspect, Reasons: []string{fmt.Sprintf("%d links (at most %d allowed)", links, r.MaxLinks)}}
+	}
+	return Verdict{Level: Ham}
+}
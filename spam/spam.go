@@ -0,0 +1,112 @@
+// Package spam decides whether a comment should be published, held for
+// moderation or treated as spam. Classifiers can be combined with Chain; the
+// built-in ones are a rule checker and a naive Bayes filter trained from
+// moderator decisions.
+package spam
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Verdict levels, from most to least trusted
+const (
+	Ham     = "ham"
+	Suspect = "suspect"
+	Spam    = "spam"
+)
+
+// Input is what a classifier gets to see of a comment.
+type Input struct {
+	Body     string
+	AuthorID int
+	PostID   int
+}
+
+// Verdict is a classifier's decision. Score is the spam probability when the
+// classifier computes one.
+type Verdict struct {
+	Level   string
+	Score   float64
+	Reasons []string
+}
+
+// Classifier is implemented by anything that can judge a comment.
+type Classifier interface {
+	Classify(in Input) Verdict
+}
+
+// Trainer is implemented by classifiers that learn from moderator decisions.
+// Untrain takes back an earlier Train of a document with the given distinct
+// tokens, as returned by UniqueTokens, when the decision is reversed.
+type Trainer interface {
+	Train(text string, isSpam bool)
+	Untrain(tokens []string, isSpam bool)
+}
+
+var levelRank = map[string]int{Ham: 0, Suspect: 1, Spam: 2}
+
+// Chain runs every classifier and returns the most severe verdict, with the
+// reasons of all classifiers that did not consider the input ham.
+type Chain []Classifier
+
+func (c Chain) Classify(in Input) Verdict {
+	result := Verdict{Level: Ham}
+	for _, classifier := range c {
+		v := classifier.Classify(in)
+		if v.Score > result.Score {
+			result.Score = v.Score
+		}
+		if v.Level == Ham {
+			continue
+		}
+		result.Reasons = append(result.Reasons, v.Reasons...)
+		if levelRank[v.Level] > levelRank[result.Level] {
+			result.Level = v.Level
+		}
+	}
+	return result
+}
+
+// Train forwards a moderator decision to every classifier that learns.
+func (c Chain) Train(text string, isSpam bool) {
+	for _, classifier := range c {
+		if t, ok := classifier.(Trainer); ok {
+			t.Train(text, isSpam)
+		}
+	}
+}
+
+// Untrain forwards a reversed decision to every classifier that learns.
+func (c Chain) Untrain(tokens []string, isSpam bool) {
+	for _, classifier := range c {
+		if t, ok := classifier.(Trainer); ok {
+			t.Untrain(tokens, isSpam)
+		}
+	}
+}
+
+var linkRe = regexp.MustCompile(`(?i)\bhttps?://|\bwww\.|<a\s`)
+
+// CountLinks returns how many links text contains.
+func CountLinks(text string) int {
+	return len(linkRe.FindAllStringIndex(text, -1))
+}
+
+// Tokenize splits text into the lowercased words the Bayes filter counts.
+// Link hosts are kept whole so that spammy domains become tokens of their own.
+func Tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.' && r != '-' && r != '\'' && r != '$'
+	})
+	tokens := make([]string, 0, len(fields))
+	for _, f := range fields {
+		f = strings.Trim(f, ".-'")
+		if len(f) < 2 || len(f) > 40 {
+			continue
+		}
+		tokens = append(tokens, f)
+	}
+	return tokens
+}
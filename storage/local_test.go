@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testStorage runs the behaviour every backend shares against s.
+func testStorage(t *testing.T, s Storage) {
+	t.Helper()
+	const key, body = "7/a b+c.txt", "hello, storage"
+
+	if _, err := s.Open(key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Open before Put = %v, want ErrNotFound", err)
+	}
+	if err := s.Put(key, strings.NewReader("old"), 3, "text/plain"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := s.Put(key, strings.NewReader(body), int64(len(body)), "text/plain"); err != nil {
+		t.Fatalf("Put replacing an object: %v", err)
+	}
+	r, err := s.Open(key)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	r.Close()
+	if err != nil || string(got) != body {
+		t.Fatalf("Open read %q, %v, want %q", got, err, body)
+	}
+
+	if err := s.Put("7/empty", strings.NewReader(""), 0, ""); err != nil {
+		t.Fatalf("Put of an empty object: %v", err)
+	}
+
+	if err := s.Delete(key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Open(key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Open after Delete = %v, want ErrNotFound", err)
+	}
+	if err := s.Delete(key); err != nil {
+		t.Fatalf("Delete of a missing key = %v, want nil", err)
+	}
+
+	for _, bad := range []string{"", "/abs", "../up", "7/../../up"} {
+		if err := s.Put(bad, strings.NewReader("x"), 1, ""); err == nil {
+			t.Errorf("Put(%q) succeeded, want an invalid key error", bad)
+		}
+		if _, err := s.Open(bad); err == nil || errors.Is(err, ErrNotFound) {
+			t.Errorf("Open(%q) = %v, want an invalid key error", bad, err)
+		}
+	}
+}
+
+func TestLocal(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "media")
+	l, err := NewLocal(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStorage(t, l)
+}
+
+func TestLocalPutSizeMismatch(t *testing.T) {
+	root := t.TempDir()
+	l, err := NewLocal(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Put("1/short.txt", strings.NewReader("abc"), 10, ""); err == nil {
+		t.Fatal("Put with a short body succeeded")
+	}
+	if _, err := l.Open("1/short.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open after a failed Put = %v, want ErrNotFound", err)
+	}
+	entries, err := os.ReadDir(filepath.Join(root, "1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("a failed Put left %d files behind", len(entries))
+	}
+}
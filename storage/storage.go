@@ -0,0 +1,32 @@
+// Package storage keeps uploaded files in a pluggable backend: the local
+// filesystem or any S3-compatible object store.
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrNotFound is returned when no object is stored under a key.
+var ErrNotFound = errors.New("object not found")
+
+// Storage stores objects under slash-separated keys such as "7/ab12.png".
+type Storage interface {
+	// Put stores size bytes read from r under key, replacing any object
+	// already there.
+	Put(key string, r io.Reader, size int64, contentType string) error
+	// Open returns the object stored under key. The caller must close it.
+	Open(key string) (io.ReadCloser, error)
+	// Delete removes the object under key; deleting a missing key is not an error.
+	Delete(key string) error
+}
+
+// checkKey refuses keys that are empty or could step outside their prefix.
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "..") || strings.Contains(key, "\\") {
+		return fmt.Errorf("invalid storage key %q", key)
+	}
+	return nil
+}
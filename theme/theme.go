@@ -0,0 +1,284 @@
+// Package theme renders the pages of the public site from html/template
+// themes. A theme is a directory holding:
+//
+//	layout.html    the page every other template is rendered inside
+//	home.html      the latest posts
+//	post.html      one post
+//	archive.html   the posts in a category, with a tag or by an author
+//	search.html    search results
+//	error.html     not found and other errors
+//	partials/*.html snippets every page can use, each as a template named
+//	               after its file without the extension
+//	static/        stylesheets, scripts and images, served as they are
+//
+// Page templates define "content" and may define "title" and "head", which
+// layout.html places. Files a theme leaves out are taken from the built-in
+// default theme, so a theme can override as little as one partial.
+package theme
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Pages every theme renders
+const (
+	PageHome    = "home"
+	PagePost    = "post"
+	PageArchive = "archive"
+	PageSearch  = "search"
+	PageError   = "error"
+)
+
+var pages = []string{PageHome, PagePost, PageArchive, PageSearch, PageError}
+
+//go:embed default
+var builtinFS embed.FS
+
+// builtin is the default theme.
+var builtin, _ = fs.Sub(builtinFS, "default")
+
+// Funcs are the functions templates can call besides the html/template
+// builtins.
+var Funcs = template.FuncMap{
+	// date formats a time for readers, e.g. "January 2, 2006"
+	"date": func(t time.Time) string { return t.Format("January 2, 2006") },
+	// isoDate formats a time for <time datetime="...">
+	"isoDate": func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+	// markup marks HTML the server has already sanitized, such as a post's
+	// body_html or a search snippet, as safe to output as it is
+	"markup": func(v interface{}) template.HTML {
+		switch s := v.(type) {
+		case string:
+			return template.HTML(s)
+		case *string:
+			if s != nil {
+				return template.HTML(*s)
+			}
+		}
+		return ""
+	},
+}
+
+// Theme is a loaded theme. In dev mode, templates are parsed again whenever
+// a file of the theme changes, so edits show on the next request.
+type Theme struct {
+	dir string
+	dev bool
+
+	mu        sync.Mutex
+	templates map[string]*template.Template
+	stamp     string
+}
+
+// Load parses the theme in dir; an empty dir is the built-in default theme.
+func Load(dir string, dev bool) (*Theme, error) {
+	if dir != "" {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return nil, fmt.Errorf("theme: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("theme: %s is not a directory", dir)
+		}
+	}
+	t := &Theme{dir: dir, dev: dev}
+	t.stamp = t.changeStamp()
+	templates, err := t.parse()
+	if err != nil {
+		return nil, err
+	}
+	t.templates = templates
+	return t, nil
+}
+
+// readFile reads name from the theme, or from the default theme when the
+// theme has no such file.
+func (t *Theme) readFile(name string) ([]byte, error) {
+	if t.dir != "" {
+		data, err := os.ReadFile(filepath.Join(t.dir, filepath.FromSlash(name)))
+		if !errors.Is(err, fs.ErrNotExist) {
+			return data, err
+		}
+	}
+	return fs.ReadFile(builtin, name)
+}
+
+// partials lists the partials of the theme and of the default theme.
+func (t *Theme) partials() ([]string, error) {
+	entries, err := fs.ReadDir(builtin, "partials")
+	if err != nil {
+		return nil, err
+	}
+	if t.dir != "" {
+		own, err := os.ReadDir(filepath.Join(t.dir, "partials"))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		entries = append(entries, own...)
+	}
+	seen := map[string]bool{}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != ".html" || seen[e.Name()] {
+			continue
+		}
+		seen[e.Name()] = true
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// parse parses the layout and partials once and every page on top of a
+// copy of them.
+func (t *Theme) parse() (map[string]*template.Template, error) {
+	layout, err := t.readFile("layout.html")
+	if err != nil {
+		return nil, fmt.Errorf("theme: %w", err)
+	}
+	base, err := template.New("layout").Funcs(Funcs).Parse(string(layout))
+	if err != nil {
+		return nil, fmt.Errorf("theme: %w", err)
+	}
+	partials, err := t.partials()
+	if err != nil {
+		return nil, fmt.Errorf("theme: %w", err)
+	}
+	for _, name := range partials {
+		src, err := t.readFile("partials/" + name)
+		if err != nil {
+			return nil, fmt.Errorf("theme: %w", err)
+		}
+		if _, err := base.New(strings.TrimSuffix(name, ".html")).Parse(string(src)); err != nil {
+			return nil, fmt.Errorf("theme: %w", err)
+		}
+	}
+
+	templates := map[string]*template.Template{}
+	for _, page := range pages {
+		src, err := t.readFile(page + ".html")
+		if err != nil {
+			return nil, fmt.Errorf("theme: %w", err)
+		}
+		tmpl, err := base.Clone()
+		if err != nil {
+			return nil, fmt.Errorf("theme: %w", err)
+		}
+		if _, err := tmpl.New(page).Parse(string(src)); err != nil {
+			return nil, fmt.Errorf("theme: %w", err)
+		}
+		templates[page] = tmpl
+	}
+	return templates, nil
+}
+
+// changeStamp sums up the names, sizes and modification times of the
+// theme's templates; it changes when any of them does.
+func (t *Theme) changeStamp() string {
+	if t.dir == "" {
+		return ""
+	}
+	static := filepath.Join(t.dir, "static")
+	var b strings.Builder
+	filepath.WalkDir(t.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if p == static {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			fmt.Fprintf(&b, "%s %d %d\n", p, info.Size(), info.ModTime().UnixNano())
+		}
+		return nil
+	})
+	return b.String()
+}
+
+// template returns the template of page, parsing the theme again first in
+// dev mode if it changed. A theme that no longer parses keeps failing until
+// it is fixed.
+func (t *Theme) template(page string) (*template.Template, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.dev {
+		if stamp := t.changeStamp(); stamp != t.stamp {
+			templates, err := t.parse()
+			if err != nil {
+				return nil, err
+			}
+			t.templates, t.stamp = templates, stamp
+			log.Printf("theme: reloaded %s", t.dir)
+		}
+	}
+	tmpl, ok := t.templates[page]
+	if !ok {
+		return nil, fmt.Errorf("theme: no %q page", page)
+	}
+	return tmpl, nil
+}
+
+// Render writes page rendered with data to w.
+func (t *Theme) Render(w io.Writer, page string, data interface{}) error {
+	tmpl, err := t.template(page)
+	if err != nil {
+		return err
+	}
+	return tmpl.ExecuteTemplate(w, "layout", data)
+}
+
+// openStatic opens name below static/, in the theme or else the default theme.
+func (t *Theme) openStatic(name string) (fs.File, error) {
+	if t.dir != "" {
+		f, err := os.Open(filepath.Join(t.dir, "static", filepath.FromSlash(name)))
+		if !errors.Is(err, fs.ErrNotExist) {
+			return f, err
+		}
+	}
+	return builtin.Open("static/" + name)
+}
+
+// ServeStatic serves the file name from the theme's static/ directory.
+// Directories are not listed.
+func (t *Theme) ServeStatic(w http.ResponseWriter, r *http.Request, name string) {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if !fs.ValidPath(name) || name == "." {
+		http.NotFound(w, r)
+		return
+	}
+	f, err := t.openStatic(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	content, seekable := f.(io.ReadSeeker)
+	if err != nil || info.IsDir() || !seekable {
+		http.NotFound(w, r)
+		return
+	}
+	if t.dev {
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+	}
+	http.ServeContent(w, r, name, info.ModTime(), content)
+}
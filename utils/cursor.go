@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// EncodeCursor serialises a cursor value into an opaque, URL-safe token.
+func EncodeCursor(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor reverses EncodeCursor.
+func DecodeCursor(token string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// LinkHeader builds an RFC 8288 Link header value pointing at u with its
+// "cursor" query parameter replaced, one link per non-empty rel.
+func LinkHeader(u *url.URL, cursors map[string]string) string {
+	var links []string
+	for _, rel := range []string{"first", "prev", "next"} {
+		cursor, ok := cursors[rel]
+		if !ok {
+			continue
+		}
+		q := u.Query()
+		q.Del("cursor")
+		if cursor != "" {
+			q.Set("cursor", cursor)
+		}
+		link := url.URL{Path: u.Path, RawQuery: q.Encode()}
+		links = append(links, `<`+link.String()+`>; rel="`+rel+`"`)
+	}
+	return strings.Join(links, ", ")
+}
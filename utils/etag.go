@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// VersionETag formats a resource version as a strong ETag.
+func VersionETag(version int) string {
+	return `"v` + strconv.Itoa(version) + `"`
+}
+
+// ContentETag derives a strong ETag from a generated document, for
+// resources that have no version of their own.
+func ContentETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// MatchesETag reports whether an If-Match header value matches etag. "*"
+// matches anything; otherwise tags are compared strongly, as RFC 7232
+// requires for If-Match, so weak tags never match.
+func MatchesETag(header, etag string) bool {
+	if strings.HasPrefix(etag, "W/") {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
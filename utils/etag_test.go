@@ -0,0 +1,33 @@
+package utils
+
+import "testing"
+
+func TestMatchesETag(t *testing.T) {
+	tests := []struct {
+		header string
+		etag   string
+		want   bool
+	}{
+		{`"v3"`, `"v3"`, true},
+		{`"v2"`, `"v3"`, false},
+		{`"v1", "v3"`, `"v3"`, true},
+		{`*`, `"v3"`, true},
+		{` * `, `"v3"`, true},
+		{`W/"v3"`, `"v3"`, false},
+		{`W/"v3"`, `W/"v3"`, false},
+		{`"v3"`, `W/"v3"`, false},
+		{`v3`, `"v3"`, false},
+		{``, `"v3"`, false},
+	}
+	for _, tt := range tests {
+		if got := MatchesETag(tt.header, tt.etag); got != tt.want {
+			t.Errorf("MatchesETag(%q, %q) = %v, want %v", tt.header, tt.etag, got, tt.want)
+		}
+	}
+}
+
+func TestVersionETag(t *testing.T) {
+	if got, want := VersionETag(12), `"v12"`; got != want {
+		t.Errorf("VersionETag(12) = %s, want %s", got, want)
+	}
+}
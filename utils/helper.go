@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"regexp"
 	"strings"
+	"time"
 )
 
 func IsValidEmail(email string) bool {
@@ -23,3 +24,57 @@ func findUSerIDAndRole(w http.ResponseWriter, r *http.Request) (int, string) {
 	role := claims.Role
 	return userID, role
 }
+
+// SiteURL returns the public address of the site: configured, when set, or
+// else the scheme and host the request was made to.
+func SiteURL(r *http.Request, configured string) string {
+	if configured != "" {
+		return strings.TrimRight(configured, "/")
+	}
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slugify lowercases s and collapses every run of non-alphanumeric characters into a single dash.
+func Slugify(s string) string {
+	slug := nonSlugChars.ReplaceAllString(strings.ToLower(strings.TrimSpace(s)), "-")
+	return strings.Trim(slug, "-")
+}
+
+// MaxHandleLength is the longest user handle allowed.
+const MaxHandleLength = 30
+
+var (
+	handlePattern  = regexp.MustCompile(`^[a-z0-9_](?:[a-z0-9._-]*[a-z0-9_])?$`)
+	nonHandleChars = regexp.MustCompile(`[^a-z0-9_.-]+`)
+)
+
+// IsValidHandle reports whether s can be used as a handle: lower-case
+// letters, digits, underscores, dots and dashes, not starting or ending with
+// a dot or dash, at most MaxHandleLength characters.
+func IsValidHandle(s string) bool {
+	return len(s) <= MaxHandleLength && handlePattern.MatchString(s)
+}
+
+// HandleFrom derives a handle from a display name by dropping every
+// character a handle cannot have. It is empty when nothing is left.
+func HandleFrom(name string) string {
+	h := strings.Trim(nonHandleChars.ReplaceAllString(strings.ToLower(name), ""), "._-")
+	if len(h) > MaxHandleLength {
+		h = strings.TrimRight(h[:MaxHandleLength], "._-")
+	}
+	return h
+}
+
+// ParseDate accepts either an RFC 3339 timestamp or a plain YYYY-MM-DD date.
+func ParseDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
@@ -0,0 +1,54 @@
+package utils
+
+import "testing"
+
+func TestIsValidHandle(t *testing.T) {
+	tests := []struct {
+		handle string
+		want   bool
+	}{
+		{"ann", true},
+		{"a", true},
+		{"ann_b.c-d", true},
+		{"_ann_", true},
+		{"42", true},
+		{"", false},
+		{"Ann", false},
+		{"ann b", false},
+		{".ann", false},
+		{"ann-", false},
+		{"ann@example", false},
+		{"ännä", false},
+		{"abcdefghijklmnopqrstuvwxyz0123", true},
+		{"abcdefghijklmnopqrstuvwxyz01234", false},
+	}
+	for _, tt := range tests {
+		if got := IsValidHandle(tt.handle); got != tt.want {
+			t.Errorf("IsValidHandle(%q) = %v, want %v", tt.handle, got, tt.want)
+		}
+	}
+}
+
+func TestHandleFrom(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Ann", "ann"},
+		{"John Doe", "johndoe"},
+		{"  -j.doe- ", "j.doe"},
+		{"Zoë_Ng", "zo_ng"},
+		{"日本", ""},
+		{"abcdefghijklmnopqrstuvwxyz0123-456", "abcdefghijklmnopqrstuvwxyz0123"},
+		{"abcdefghijklmnopqrstuvwxyz012-3456", "abcdefghijklmnopqrstuvwxyz012"},
+	}
+	for _, tt := range tests {
+		got := HandleFrom(tt.name)
+		if got != tt.want {
+			t.Errorf("HandleFrom(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+		if got != "" && !IsValidHandle(got) {
+			t.Errorf("HandleFrom(%q) = %q, which is not a valid handle", tt.name, got)
+		}
+	}
+}
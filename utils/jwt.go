@@ -5,13 +5,16 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/joho/godotenv"
 )
 
-var jwtSecret = []byte(getJWTSecret())
+// jwtSecret is loaded on first use rather than at start up, so that packages
+// importing utils can be tested without a .env file.
+var jwtSecret = sync.OnceValue(func() []byte { return []byte(getJWTSecret()) })
 
 type Claims struct {
 	UserID int    `json:"user_id"`
@@ -46,9 +49,9 @@ func GenerateJWT(userID int, role string) (string, error) {
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
-	fmt.Println("JWT secret is :", string(jwtSecret))
+	fmt.Println("JWT secret is :", string(jwtSecret()))
 
-	return token.SignedString(jwtSecret)
+	return token.SignedString(jwtSecret())
 }
 
 //var jwtSecret = []byte(os.Getenv("JWT_SECRET")) // Make sure to set this in your env
@@ -57,7 +60,7 @@ func ValidateJWT(tokenString string) (*Claims, error) {
 	claims := &Claims{}
 
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
+		return jwtSecret(), nil
 	})
 
 	if err != nil {